@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"quizer_server/internal/app"
 	"quizer_server/internal/config"
 )
@@ -13,6 +14,10 @@ func main() {
 
 	services := app.SetupServices(pool)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.StartTrashPurge(ctx, cfg, services.GameSvc)
+
 	router := app.SetupRouter(services)
 
 	srv := app.SetupServer(cfg, router)
@@ -11,10 +11,17 @@ import (
 	"quizer_server/internal/db"
 	"quizer_server/internal/handler"
 	"quizer_server/internal/middleware"
+	"quizer_server/internal/service/apikey"
+	"quizer_server/internal/service/audit"
 	"quizer_server/internal/service/game"
 	"quizer_server/internal/service/jwt"
+	"quizer_server/internal/service/library"
 	"quizer_server/internal/service/lobby"
+	"quizer_server/internal/service/oidc"
+	"quizer_server/internal/service/organization"
+	"quizer_server/internal/service/player"
 	"quizer_server/internal/service/question"
+	"quizer_server/internal/service/throttle"
 	"quizer_server/internal/service/user"
 	"quizer_server/pkg/postgres"
 	"syscall"
@@ -26,20 +33,41 @@ import (
 
 func SetupServices(pool *pgxpool.Pool) services.Services {
 	storage := db.New(pool)
-	us := user.New(storage)
-	gs := game.New(storage)
-	ls := lobby.New(storage)
-	qs := question.New(storage)
-	js := jwt.New(us)
-	ua := middleware.NewUserAuthenticator(us, js)
+	as := audit.New(storage)
+	us := user.New(storage, as)
+	gs := game.New(storage, as)
+	ls := lobby.New(storage, as)
+	qs := question.New(storage, as)
+	js := jwt.New(us, storage)
+	ts := throttle.New(storage)
+	ks := apikey.New(storage)
+	orgs := organization.New(storage, as)
+	ua := middleware.NewUserAuthenticator(us, js, ks, orgs)
+
+	cfg := config.GetConfig()
+	oidcs := oidc.New(oidc.Config{
+		Issuer:       cfg.Oidc.Issuer,
+		ClientID:     cfg.Oidc.ClientID,
+		ClientSecret: cfg.Oidc.ClientSecret,
+		RedirectURL:  cfg.Oidc.RedirectURL,
+		Scopes:       cfg.Oidc.Scopes,
+		StateTTL:     cfg.Oidc.StateTTL,
+	}, us, storage, nil)
 
 	return services.Services{
 		UserSvc:     us,
 		JwtSvc:      js,
+		ThrottleSvc: ts,
+		ApiKeySvc:   ks,
+		AuditSvc:    as,
+		OrgSvc:      orgs,
+		OidcSvc:     oidcs,
+		PlayerSvc:   player.New(storage),
 		UserAuth:    ua,
 		GameSvc:     gs,
 		LobbySvc:    ls,
 		QuestionSvc: qs,
+		LibrarySvc:  library.New(storage, as),
 	}
 }
 
@@ -72,6 +100,27 @@ func StartServer(s *http.Server) {
 	}()
 }
 
+// StartTrashPurge removes games and questions that stayed in the trash longer than the retention
+// period, once at start and then every purge interval. It stops when the context is cancelled.
+func StartTrashPurge(ctx context.Context, cfg *config.Config, gs game.Service) {
+	if cfg.Trash.Retention <= 0 || cfg.Trash.PurgeInterval <= 0 {
+		log.Println("Trash purge is disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(cfg.Trash.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			gs.PurgeTrash(ctx, time.Now().Add(-cfg.Trash.Retention))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
 // HandleQuit gracefully shuts down the server when receiving SIGINT or SIGTERM signals.
 func HandleQuit(s *http.Server) {
 	quit := make(chan os.Signal, 1)
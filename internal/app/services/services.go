@@ -2,10 +2,17 @@ package services
 
 import (
 	"quizer_server/internal/middleware"
+	"quizer_server/internal/service/apikey"
+	"quizer_server/internal/service/audit"
 	"quizer_server/internal/service/game"
 	"quizer_server/internal/service/jwt"
+	"quizer_server/internal/service/library"
 	"quizer_server/internal/service/lobby"
+	"quizer_server/internal/service/oidc"
+	"quizer_server/internal/service/organization"
+	"quizer_server/internal/service/player"
 	"quizer_server/internal/service/question"
+	"quizer_server/internal/service/throttle"
 	"quizer_server/internal/service/user"
 )
 
@@ -14,6 +21,13 @@ type Services struct {
 	GameSvc     game.Service
 	LobbySvc    lobby.Service
 	QuestionSvc question.Service
+	LibrarySvc  library.Service
 	JwtSvc      jwt.Service
+	ThrottleSvc throttle.Service
+	ApiKeySvc   apikey.Service
+	AuditSvc    audit.Service
+	OrgSvc      organization.Service
+	OidcSvc     oidc.Service
+	PlayerSvc   player.Service
 	UserAuth    middleware.UserAuthenticator
 }
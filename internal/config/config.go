@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -23,7 +24,35 @@ type Config struct {
 		Password string `env:"PSQL_PASSWORD"`
 	}
 	Jwt struct {
-		SecretKey string `env:"JWT_SECRET_KEY"`
+		SecretKey  string        `env:"JWT_SECRET_KEY"`
+		Keys       []string      `env:"JWT_KEYS"`
+		ActiveKid  string        `env:"JWT_ACTIVE_KID"`
+		AccessTTL  time.Duration `env:"JWT_ACCESS_TTL" env-default:"15m"`
+		RefreshTTL time.Duration `env:"JWT_REFRESH_TTL" env-default:"720h"`
+		LobbyTTL   time.Duration `env:"JWT_LOBBY_TTL" env-default:"24h"`
+	}
+	Login struct {
+		MaxAttempts   int           `env:"LOGIN_MAX_ATTEMPTS" env-default:"5"`
+		IPMaxAttempts int           `env:"LOGIN_IP_MAX_ATTEMPTS" env-default:"20"`
+		BaseLockout   time.Duration `env:"LOGIN_BASE_LOCKOUT" env-default:"1m"`
+		MaxLockout    time.Duration `env:"LOGIN_MAX_LOCKOUT" env-default:"1h"`
+		FailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW" env-default:"24h"`
+	}
+	Users struct {
+		DefaultRoles []string `env:"DEFAULT_USER_ROLES" env-default:"author,host"`
+		DefaultOrg   string   `env:"DEFAULT_ORGANIZATION" env-default:"default"`
+	}
+	Oidc struct {
+		Issuer       string        `env:"OIDC_ISSUER"`
+		ClientID     string        `env:"OIDC_CLIENT_ID"`
+		ClientSecret string        `env:"OIDC_CLIENT_SECRET"`
+		RedirectURL  string        `env:"OIDC_REDIRECT_URL"`
+		Scopes       []string      `env:"OIDC_SCOPES" env-default:"openid,profile,email"`
+		StateTTL     time.Duration `env:"OIDC_STATE_TTL" env-default:"10m"`
+	}
+	Trash struct {
+		Retention     time.Duration `env:"TRASH_RETENTION" env-default:"720h"`
+		PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
 	}
 	CORS struct {
 		AllowedOrigins []string `env:"ALLOWED_ORIGINS"`
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *storage) CreateApiKey(ctx context.Context, data model.ApiKey) (int, error) {
+	var id int
+	query := `
+		INSERT INTO
+			api_keys (
+				user_id,
+				name,
+				prefix,
+				key_hash,
+				scopes
+			)
+		VALUES
+			(
+			@user_id,
+			@name,
+			@prefix,
+			@key_hash,
+			@scopes
+		)
+		RETURNING
+			id
+	`
+	args := pgx.NamedArgs{
+		"user_id":  data.UserId,
+		"name":     data.Name,
+		"prefix":   data.Prefix,
+		"key_hash": data.KeyHash,
+		"scopes":   data.Scopes,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&id)
+	if err != nil {
+		return id, fmt.Errorf("db create api key error: %v", err)
+	}
+	return id, nil
+}
+
+func (s *storage) ApiKeyByPrefix(ctx context.Context, prefix string) (model.ApiKey, error) {
+	var res model.ApiKey
+	query := `
+		SELECT
+			id,
+			user_id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			created_at,
+			last_used_at,
+			revoked_at
+		FROM api_keys
+		WHERE prefix = @prefix
+	`
+	args := pgx.NamedArgs{
+		"prefix": prefix,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.ApiKey])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *storage) ApiKeysByUser(ctx context.Context, userId int) ([]model.ApiKey, error) {
+	res := []model.ApiKey{}
+	query := `
+		SELECT
+			id,
+			user_id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			created_at,
+			last_used_at,
+			revoked_at
+		FROM api_keys
+		WHERE user_id = @user_id
+		ORDER BY id desc
+	`
+	args := pgx.NamedArgs{
+		"user_id": userId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.ApiKey])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *storage) RevokeApiKey(ctx context.Context, userId int, id int) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			api_keys
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			id = @id
+			AND user_id = @user_id
+			AND revoked_at IS NULL
+		RETURNING id
+	`
+	args := pgx.NamedArgs{
+		"id":      id,
+		"user_id": userId,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *storage) TouchApiKey(ctx context.Context, id int) error {
+	query := `
+		UPDATE
+			api_keys
+		SET
+			last_used_at = CURRENT_TIMESTAMP
+		WHERE
+			id = @id
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+	_, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db touch api key error: %v", err)
+	}
+	return nil
+}
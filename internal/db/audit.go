@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *storage) CreateAuditRecord(ctx context.Context, data model.AuditRecord) error {
+	query := `
+		INSERT INTO
+			audit_log (
+				actor_id,
+				action,
+				target_type,
+				target_id,
+				before,
+				after,
+				ip
+			)
+		VALUES
+			(
+			@actor_id,
+			@action,
+			@target_type,
+			@target_id,
+			@before,
+			@after,
+			@ip
+		)
+	`
+	args := pgx.NamedArgs{
+		"actor_id":    data.ActorId,
+		"action":      data.Action,
+		"target_type": data.TargetType,
+		"target_id":   data.TargetId,
+		"before":      data.Before,
+		"after":       data.After,
+		"ip":          data.IP,
+	}
+	_, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db create audit record error: %v", err)
+	}
+	return nil
+}
+
+// AuditList returns one page of audit records matching the filter and the total number of matches.
+func (s *storage) AuditList(ctx context.Context, filter dto.AuditFilter) ([]model.AuditRecord, int, error) {
+	res := []model.AuditRecord{}
+	total := 0
+	where := `
+		WHERE
+			(@actor_id = 0 OR actor_id = @actor_id)
+			AND (@target_type = '' OR target_type = @target_type)
+			AND (@from::timestamp IS NULL OR created_at >= @from)
+			AND (@to::timestamp IS NULL OR created_at < @to)
+	`
+	args := pgx.NamedArgs{
+		"actor_id":    filter.ActorId,
+		"target_type": filter.TargetType,
+		"from":        filter.From,
+		"to":          filter.To,
+		"limit":       filter.Limit,
+		"offset":      filter.Offset,
+	}
+
+	err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM audit_log`+where, args).Scan(&total)
+	if err != nil {
+		return res, total, err
+	}
+
+	query := `
+		SELECT
+			id,
+			actor_id,
+			action,
+			target_type,
+			target_id,
+			before,
+			after,
+			ip,
+			created_at
+		FROM audit_log
+	` + where + `
+		ORDER BY id desc
+		LIMIT @limit
+		OFFSET @offset
+	`
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, total, err
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.AuditRecord])
+
+	if err != nil {
+		return res, total, err
+	}
+
+	return res, total, nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CollaboratorPermission returns the permission granted to the user on the game.
+// pgx.ErrNoRows is returned when the game is not shared with the user.
+func (s *storage) CollaboratorPermission(ctx context.Context, gameId int, userId int) (string, error) {
+	var res string
+	query := `
+		SELECT
+			permission
+		FROM game_collaborators
+		WHERE game_id = @game_id AND user_id = @user_id
+	`
+	args := pgx.NamedArgs{
+		"game_id": gameId,
+		"user_id": userId,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *storage) CollaboratorsByGame(ctx context.Context, gameId int) ([]model.Collaborator, error) {
+	var res []model.Collaborator
+	query := `
+		SELECT
+			gc.game_id,
+			gc.user_id,
+			u.login,
+			gc.permission,
+			gc.created_at
+		FROM game_collaborators gc
+		JOIN users u on u.id = gc.user_id
+		WHERE gc.game_id = @game_id
+		ORDER BY u.login
+	`
+	args := pgx.NamedArgs{
+		"game_id": gameId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.Collaborator])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// SaveCollaborator shares the game with the user or changes the permission of an existing collaborator.
+func (s *storage) SaveCollaborator(ctx context.Context, gameId int, userId int, permission string) error {
+	query := `
+		INSERT INTO
+			game_collaborators (
+				game_id,
+				user_id,
+				permission
+			)
+		VALUES
+			(
+			@game_id,
+			@user_id,
+			@permission
+		)
+		ON CONFLICT (game_id, user_id) DO UPDATE SET permission = EXCLUDED.permission
+	`
+	args := pgx.NamedArgs{
+		"game_id":    gameId,
+		"user_id":    userId,
+		"permission": permission,
+	}
+	_, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db save collaborator error: %v", err)
+	}
+	return nil
+}
+
+func (s *storage) DeleteCollaborator(ctx context.Context, gameId int, userId int) (int, error) {
+	res := 0
+	query := `
+		DELETE FROM
+			game_collaborators
+		WHERE
+			game_id = @game_id AND user_id = @user_id
+		RETURNING user_id
+	`
+	args := pgx.NamedArgs{
+		"game_id": gameId,
+		"user_id": userId,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
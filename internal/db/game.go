@@ -11,19 +11,38 @@ import (
 )
 
 func (s *storage) CreateGame(ctx context.Context, data dto.CreateNewGame) (int, error) {
+	id, err := insertGame(ctx, s.db, data)
+	if err != nil {
+		return id, fmt.Errorf("db create new game error: %v", err)
+	}
+	return id, nil
+}
+
+// rowQuerier runs single row queries on the pool or within a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertGame(ctx context.Context, q rowQuerier, data dto.CreateNewGame) (int, error) {
 	var id int
 	query := `
 		INSERT INTO
 			games (
 				description,
 				owner_id,
-				link
+				org_id,
+				link,
+				category,
+				tags
 			)
 		VALUES
 			(
 			@description,
 			@owner_id,
-			@link
+			@org_id,
+			@link,
+			@category,
+			COALESCE(@tags::text[], '{}')
 		)
 		RETURNING
 			id
@@ -31,30 +50,221 @@ func (s *storage) CreateGame(ctx context.Context, data dto.CreateNewGame) (int,
 	args := pgx.NamedArgs{
 		"description": data.Description,
 		"owner_id":    data.OwnerId,
+		"org_id":      data.OrgId,
 		"link":        data.Link,
+		"category":    data.Category,
+		"tags":        data.Tags,
 	}
-	row := s.db.QueryRow(ctx, query, args)
-	err := row.Scan(&id)
+	err := q.QueryRow(ctx, query, args).Scan(&id)
+	return id, err
+}
+
+// CreateGameWithQuestions creates the game with its rounds and questions in one transaction.
+// The GameId of the questions is ignored, they are attached to the new game. The RoundId of
+// a question is the position of one of the rounds, it is replaced with the id of the new round.
+func (s *storage) CreateGameWithQuestions(ctx context.Context, data dto.CreateNewGame, rounds []dto.RoundRequest, questions []dto.CreateNewQuestionRequest) (int, error) {
+	var id int
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var err error
+		id, err = insertGame(ctx, tx, data)
+		if err != nil {
+			return err
+		}
+
+		roundIds := map[int]int{}
+		for _, r := range rounds {
+			roundId, err := insertRound(ctx, tx, id, r)
+			if err != nil {
+				return err
+			}
+			roundIds[r.Position] = roundId
+		}
+		for i, q := range questions {
+			if q.RoundId == nil {
+				continue
+			}
+			roundId, ok := roundIds[*q.RoundId]
+			if !ok {
+				return fmt.Errorf("question %d refers to the unknown round %d", q.Number, *q.RoundId)
+			}
+			questions[i].RoundId = &roundId
+		}
+
+		return insertQuestions(ctx, tx, id, questions)
+	})
 	if err != nil {
-		return id, fmt.Errorf("db create new game error: %v", err)
+		return 0, fmt.Errorf("db create game with questions error: %v", err)
 	}
 	return id, nil
 }
 
-func (s *storage) GameList(ctx context.Context) ([]model.Game, error) {
+// CloneGame creates the game from data and copies all rounds and questions of the game sourceId into it in one transaction.
+func (s *storage) CloneGame(ctx context.Context, sourceId int, data dto.CreateNewGame) (int, error) {
+	var id int
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var err error
+		id, err = insertGame(ctx, tx, data)
+		if err != nil {
+			return err
+		}
+
+		rounds, err := gameRounds(ctx, tx, sourceId)
+		if err != nil {
+			return err
+		}
+		sourceRounds := make([]int, 0, len(rounds))
+		cloneRounds := make([]int, 0, len(rounds))
+		for _, r := range rounds {
+			roundId, err := insertRound(ctx, tx, id, dto.RoundRequest{
+				Position:          r.Position,
+				Title:             r.Title,
+				Multiplier:        r.Multiplier,
+				IntermissionText:  r.IntermissionText,
+				IntermissionSlide: r.IntermissionSlide,
+			})
+			if err != nil {
+				return err
+			}
+			sourceRounds = append(sourceRounds, r.Id)
+			cloneRounds = append(cloneRounds, roundId)
+		}
+
+		query := `
+			INSERT INTO
+				questions (
+					number,
+					description,
+					game_id,
+					answer,
+					answer_text,
+					cost,
+					options,
+					round_id,
+					library_question_id,
+					forked
+				)
+			SELECT
+				q.number,
+				q.description,
+				@game_id,
+				q.answer,
+				q.answer_text,
+				q.cost,
+				q.options,
+				m.clone_id,
+				q.library_question_id,
+				q.forked
+			FROM questions q
+			LEFT JOIN unnest(@source_rounds::int[], @clone_rounds::int[]) AS m (source_id, clone_id)
+				ON m.source_id = q.round_id
+			WHERE
+				q.game_id = @source_id
+				AND q.deleted_at IS NULL
+			ORDER BY q.number
+		`
+		_, err = tx.Exec(ctx, query, pgx.NamedArgs{
+			"game_id":       id,
+			"source_id":     sourceId,
+			"source_rounds": sourceRounds,
+			"clone_rounds":  cloneRounds,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("db clone game error: %v", err)
+	}
+	return id, nil
+}
+
+// insertRound inserts the round of the game at the given position within the transaction.
+func insertRound(ctx context.Context, tx pgx.Tx, gameId int, r dto.RoundRequest) (int, error) {
+	var id int
+	query := `
+		INSERT INTO
+			rounds (
+				game_id,
+				position,
+				title,
+				multiplier,
+				intermission_text,
+				intermission_slide
+			)
+		VALUES
+			(
+			@game_id,
+			@position,
+			@title,
+			@multiplier,
+			@intermission_text,
+			@intermission_slide
+		)
+		RETURNING
+			id
+	`
+	err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"game_id":            gameId,
+		"position":           r.Position,
+		"title":              r.Title,
+		"multiplier":         r.Multiplier,
+		"intermission_text":  r.IntermissionText,
+		"intermission_slide": r.IntermissionSlide,
+	}).Scan(&id)
+	return id, err
+}
+
+// gameListConditions is the WHERE clause of dto.GameListFilter for games aliased g, see gameListArgs.
+const gameListConditions = `
+			g.org_id = @org_id
+			AND (@owner_id = 0 OR g.owner_id = @owner_id)
+			AND (@shared_with = 0 OR EXISTS (
+				SELECT 1 FROM game_collaborators gc
+				WHERE gc.game_id = g.id AND gc.user_id = @shared_with
+			))
+			AND (@member_id = 0 OR g.owner_id = @member_id OR EXISTS (
+				SELECT 1 FROM game_collaborators gc
+				WHERE gc.game_id = g.id AND gc.user_id = @member_id
+			))
+			AND (COALESCE(cardinality(@statuses::text[]), 0) = 0 OR g.status = ANY(@statuses::text[]))
+			AND (@category = '' OR g.category = @category)
+			AND g.tags @> COALESCE(@tags::text[], '{}')
+			AND (g.deleted_at IS NOT NULL) = @deleted
+`
+
+func gameListArgs(filter dto.GameListFilter) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"org_id":      filter.OrgId,
+		"owner_id":    filter.OwnerId,
+		"shared_with": filter.SharedWith,
+		"member_id":   filter.MemberId,
+		"statuses":    filter.Statuses,
+		"category":    filter.Category,
+		"tags":        filter.Tags,
+		"deleted":     filter.Deleted,
+	}
+}
+
+func (s *storage) GameList(ctx context.Context, filter dto.GameListFilter) ([]model.Game, error) {
 	var res []model.Game
 	query := `
 		SELECT
 			g.id, 
 			description, 
+			g.owner_id,
+			g.org_id,
 			login, 
-			created_at, 
-			link
+			g.created_at, 
+			link,
+			g.status,
+			g.category,
+			g.tags,
+			g.deleted_at
 		FROM games g 
 		JOIN users u on u.id = g.owner_id
+		WHERE ` + gameListConditions + `
 		ORDER BY id desc
 	`
-	rows, err := s.db.Query(ctx, query)
+	args := gameListArgs(filter)
+	rows, err := s.db.Query(ctx, query, args)
 	defer rows.Close()
 
 	if err != nil {
@@ -70,22 +280,32 @@ func (s *storage) GameList(ctx context.Context) ([]model.Game, error) {
 	return res, nil
 }
 
-func (s *storage) GameLoad(ctx context.Context, id int) (model.Game, error) {
+// GameLoad loads the game of the organization orgId, games of other organizations and deleted
+// games are not found. Lookups made on behalf of a lobby pass the organization of the lobby.
+func (s *storage) GameLoad(ctx context.Context, orgId int, id int) (model.Game, error) {
 	var res model.Game
 	query := `
 		SELECT
 			g.id, 
 			description, 
+			g.owner_id,
+			g.org_id,
 			login, 
-			created_at, 
-			link
+			g.created_at, 
+			link,
+			g.status,
+			g.category,
+			g.tags,
+			g.deleted_at
 		FROM games g 
 		JOIN users u on u.id = g.owner_id
-		WHERE g.id = @id
+		WHERE g.id = @id AND g.org_id = @org_id
+			AND g.deleted_at IS NULL
 	`
 
 	args := pgx.NamedArgs{
-		"id": id,
+		"id":     id,
+		"org_id": orgId,
 	}
 
 	rows, err := s.db.Query(ctx, query, args)
@@ -104,6 +324,54 @@ func (s *storage) GameLoad(ctx context.Context, id int) (model.Game, error) {
 	return res, nil
 }
 
+// UpdateGameStatus moves the game to the status.
+func (s *storage) UpdateGameStatus(ctx context.Context, id int, status string) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			games
+		SET
+			status = @status
+		WHERE
+			id = @id
+		RETURNING id
+	`
+	args := pgx.NamedArgs{
+		"id":     id,
+		"status": status,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db update game status error: %w", err)
+	}
+	return res, nil
+}
+
+// UpdateGameLabels replaces the category and the tags of the game.
+func (s *storage) UpdateGameLabels(ctx context.Context, id int, category string, tags []string) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			games
+		SET
+			category = @category,
+			tags = COALESCE(@tags::text[], '{}')
+		WHERE
+			id = @id
+		RETURNING id
+	`
+	args := pgx.NamedArgs{
+		"id":       id,
+		"category": category,
+		"tags":     tags,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db update game labels error: %w", err)
+	}
+	return res, nil
+}
+
 func (s *storage) UpdateGame(ctx context.Context, updated model.Game) (int, error) {
 	res := 0
 	query := `
@@ -158,13 +426,17 @@ func (s *storage) UpdateFilePath(ctx context.Context, gameId int, path string) (
 	return res, nil
 }
 
+// DeleteGame moves the game to the trash, see PurgeDeletedGames.
 func (s *storage) DeleteGame(ctx context.Context, id int) (int, error) {
 	res := 0
 	query := `
-		DELETE FROM
+		UPDATE
 			games
+		SET
+			deleted_at = now()
 		WHERE
 			id = @id
+			AND deleted_at IS NULL
 		RETURNING id
 	`
 	args := pgx.NamedArgs{
@@ -189,14 +461,16 @@ func (s *storage) SavePlayer(ctx context.Context, newPlayer model.Player) error
 				uuid,
 				lobby_id,
 				user_name,
-				is_admin
+				is_admin,
+				user_id
 			)
 		VALUES
 			(
 			@uuid,
 			@lobby_id,
 			@user_name,
-			@is_admin
+			@is_admin,
+			@user_id
 		)
 		RETURNING
 			uuid
@@ -206,6 +480,7 @@ func (s *storage) SavePlayer(ctx context.Context, newPlayer model.Player) error
 		"lobby_id":  newPlayer.LobbyUUID,
 		"user_name": newPlayer.UserName,
 		"is_admin":  newPlayer.IsAdmin,
+		"user_id":   newPlayer.UserId,
 	}
 	row := s.db.QueryRow(ctx, query, args)
 	err := row.Scan(&uuid)
@@ -215,20 +490,27 @@ func (s *storage) SavePlayer(ctx context.Context, newPlayer model.Player) error
 	return nil
 }
 
-func (s *storage) PlayersByGameUUID(ctx context.Context, gameUUID uuid.UUID) ([]model.Player, error) {
+// PlayersByGameUUID lists the players of the lobby gameUUID of the organization orgId.
+func (s *storage) PlayersByGameUUID(ctx context.Context, orgId int, gameUUID uuid.UUID) ([]model.Player, error) {
 	var res []model.Player
 	query := `
 		SELECT
-			uuid,
-			lobby_id,
-			user_name,
-			is_admin
-		FROM players
-		WHERE uuid = @gameUUID
-		ORDER BY id desc
+			p.uuid,
+			p.user_name,
+			p.lobby_id AS lobby_uuid,
+			p.is_admin,
+			l.game_id,
+			p.user_id
+		FROM players p
+		JOIN lobbies l ON l.uuid = p.lobby_id
+		WHERE
+			p.lobby_id = @gameUUID
+			AND l.org_id = @org_id
+		ORDER BY p.user_name
 	`
 	args := pgx.NamedArgs{
 		"gameUUID": gameUUID,
+		"org_id":   orgId,
 	}
 	rows, err := s.db.Query(ctx, query, args)
 	defer rows.Close()
@@ -327,16 +609,19 @@ func (s *storage) LoadTextAnswersByLobbyUUID(ctx context.Context, lobbyUUID uuid
 			pa.lobby_uuid,
 			pa.player_uuid,
 			p.user_name,
-			description,
+			COALESCE(sq.description, q.description) AS description,
 			pa.answer_text AS player_answer,
-			q.answer_text AS correct_answer,
+			COALESCE(sq.answer_text, q.answer_text) AS correct_answer,
 			pa.question_num,
 			pa.question_id
 		FROM player_answers pa
-		JOIN questions q ON pa.question_id = q.id
+		JOIN lobbies l ON l.uuid = pa.lobby_uuid
+		LEFT JOIN snapshot_questions sq ON sq.snapshot_id = l.snapshot_id AND sq.question_id = pa.question_id
+		LEFT JOIN questions q ON q.id = pa.question_id AND l.snapshot_id IS NULL
 		JOIN players p ON pa.player_uuid = p.uuid 
 		WHERE pa.lobby_uuid = @lobby_uuid 
 		AND pa.answer_text != ''
+		AND (sq.question_id IS NOT NULL OR q.id IS NOT NULL)
 		ORDER BY pa.id ASC;
 	`
 	args := pgx.NamedArgs{
@@ -365,19 +650,22 @@ func (s *storage) LoadTextAnswer(ctx context.Context, lobbyUUID uuid.UUID, playe
 			pa.lobby_uuid,
 			pa.player_uuid,
 			p.user_name,
-			description,
+			COALESCE(sq.description, q.description) AS description,
 			pa.answer_text AS player_answer,
-			q.answer_text AS correct_answer,
+			COALESCE(sq.answer_text, q.answer_text) AS correct_answer,
 			pa.question_num,
 			pa.question_id
 		FROM player_answers pa
-		JOIN questions q ON pa.question_id = q.id
+		JOIN lobbies l ON l.uuid = pa.lobby_uuid
+		LEFT JOIN snapshot_questions sq ON sq.snapshot_id = l.snapshot_id AND sq.question_id = pa.question_id
+		LEFT JOIN questions q ON q.id = pa.question_id AND l.snapshot_id IS NULL
 		JOIN players p ON pa.player_uuid = p.uuid 
 		WHERE 
 			pa.lobby_uuid = @lobby_uuid
 			AND pa.player_uuid = @player_uuid
 			AND pa.question_num = @question_num
 			AND pa.answer_text != ''
+			AND (sq.question_id IS NOT NULL OR q.id IS NOT NULL)
 	`
 	args := pgx.NamedArgs{
 		"lobby_uuid":   lobbyUUID,
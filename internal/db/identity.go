@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UserByIdentity returns the user linked to the subject of the external identity provider.
+func (s *storage) UserByIdentity(ctx context.Context, issuer string, subject string) (model.User, error) {
+	var res model.User
+	query := `
+		SELECT
+			u.id,
+			u.login,
+			u.password,
+			u.display_name,
+			u.email,
+			u.roles,
+			u.created_at
+		FROM users u
+		JOIN user_identities i on i.user_id = u.id
+		WHERE i.issuer = @issuer AND i.subject = @subject
+	`
+	args := pgx.NamedArgs{
+		"issuer":  issuer,
+		"subject": subject,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.User])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// CreateUserWithIdentity creates the user and links it to the external identity in one transaction.
+// Unique violations are wrapped with %w so they can be detected with IsUniqueViolation.
+func (s *storage) CreateUserWithIdentity(ctx context.Context, data dto.CreateUser, issuer string, subject string) (int, error) {
+	var id int
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		query := `
+			INSERT INTO
+				users (
+					login,
+					password,
+					display_name,
+					email,
+					roles
+				)
+			VALUES
+				(
+				@login,
+				@password,
+				@display_name,
+				@email,
+				@roles
+			)
+			RETURNING
+				id
+		`
+		args := pgx.NamedArgs{
+			"login":        data.Login,
+			"password":     data.Password,
+			"display_name": data.DisplayName,
+			"email":        data.Email,
+			"roles":        data.Roles,
+		}
+		err := tx.QueryRow(ctx, query, args).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO
+				user_identities (
+					issuer,
+					subject,
+					user_id
+				)
+			VALUES
+				(
+				@issuer,
+				@subject,
+				@user_id
+			)
+		`
+		args = pgx.NamedArgs{
+			"issuer":  issuer,
+			"subject": subject,
+			"user_id": id,
+		}
+		_, err = tx.Exec(ctx, query, args)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("db create user with identity error: %w", err)
+	}
+	return id, nil
+}
+
+// CreateOidcState stores a pending login. Expired states are purged on the way.
+func (s *storage) CreateOidcState(ctx context.Context, data model.OidcState) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM oidc_states WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return fmt.Errorf("db purge oidc states error: %v", err)
+	}
+
+	query := `
+		INSERT INTO
+			oidc_states (
+				state,
+				nonce,
+				code_verifier,
+				expires_at
+			)
+		VALUES
+			(
+			@state,
+			@nonce,
+			@code_verifier,
+			@expires_at
+		)
+	`
+	args := pgx.NamedArgs{
+		"state":         data.State,
+		"nonce":         data.Nonce,
+		"code_verifier": data.CodeVerifier,
+		"expires_at":    data.ExpiresAt,
+	}
+	_, err = s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db create oidc state error: %v", err)
+	}
+	return nil
+}
+
+// TakeOidcState deletes and returns the pending login, so every state can be used only once.
+func (s *storage) TakeOidcState(ctx context.Context, state string) (model.OidcState, error) {
+	var res model.OidcState
+	query := `
+		DELETE FROM
+			oidc_states
+		WHERE
+			state = @state
+		RETURNING
+			state,
+			nonce,
+			code_verifier,
+			expires_at
+	`
+	args := pgx.NamedArgs{
+		"state": state,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.OidcState])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
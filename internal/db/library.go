@@ -0,0 +1,431 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// libraryQuestionColumns selects a model.LibraryQuestion from library_questions lq joined with its owner u.
+const libraryQuestionColumns = `
+			lq.id,
+			lq.org_id,
+			lq.owner_id,
+			u.login,
+			lq.visibility,
+			lq.description,
+			lq.answer,
+			lq.answer_text,
+			lq.cost,
+			lq.options,
+			lq.created_at,
+			lq.updated_at,
+			(
+				SELECT COUNT(*)
+				FROM questions q
+				JOIN games g ON g.id = q.game_id
+				WHERE
+					q.library_question_id = lq.id
+					AND NOT q.forked
+					AND q.deleted_at IS NULL
+					AND g.deleted_at IS NULL
+			) AS used_by
+`
+
+// LibraryQuestions lists the library questions of the filter, the latest changed first.
+func (s *storage) LibraryQuestions(ctx context.Context, filter dto.LibraryFilter) ([]model.LibraryQuestion, error) {
+	res := []model.LibraryQuestion{}
+	query := `
+		SELECT` + libraryQuestionColumns + `
+		FROM library_questions lq
+		JOIN users u ON u.id = lq.owner_id
+		WHERE
+			lq.org_id = @org_id
+			AND (@user_id = 0 OR lq.owner_id = @user_id OR lq.visibility = 'organization')
+			AND (NOT @mine OR lq.owner_id = @user_id)
+			AND (@query = '' OR lq.search @@ websearch_to_tsquery('russian', @query))
+		ORDER BY lq.updated_at DESC, lq.id DESC
+		LIMIT @limit
+	`
+	args := pgx.NamedArgs{
+		"org_id":  filter.OrgId,
+		"user_id": filter.UserId,
+		"mine":    filter.Mine,
+		"query":   filter.Query,
+		"limit":   filter.Limit,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db library questions error: %v", err)
+	}
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.LibraryQuestion])
+	if err != nil {
+		return res, fmt.Errorf("db library questions error: %v", err)
+	}
+	return res, nil
+}
+
+// LibraryQuestionLoad loads the library question of the organization orgId.
+func (s *storage) LibraryQuestionLoad(ctx context.Context, orgId int, id int) (model.LibraryQuestion, error) {
+	var res model.LibraryQuestion
+	query := `
+		SELECT` + libraryQuestionColumns + `
+		FROM library_questions lq
+		JOIN users u ON u.id = lq.owner_id
+		WHERE
+			lq.id = @id
+			AND lq.org_id = @org_id
+	`
+	args := pgx.NamedArgs{
+		"id":     id,
+		"org_id": orgId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+	if err != nil {
+		return res, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.LibraryQuestion])
+}
+
+func (s *storage) CreateLibraryQuestion(ctx context.Context, orgId int, ownerId int, data dto.LibraryQuestionRequest) (int, error) {
+	var id int
+	query := `
+		INSERT INTO
+			library_questions (
+				org_id,
+				owner_id,
+				visibility,
+				description,
+				answer,
+				answer_text,
+				cost,
+				options
+			)
+		VALUES
+			(
+			@org_id,
+			@owner_id,
+			@visibility,
+			@description,
+			@answer,
+			@answer_text,
+			@cost,
+			COALESCE(@options::text[], '{}')
+		)
+		RETURNING
+			id
+	`
+	args := pgx.NamedArgs{
+		"org_id":      orgId,
+		"owner_id":    ownerId,
+		"visibility":  data.Visibility,
+		"description": data.Description,
+		"answer":      data.AnswerNum,
+		"answer_text": data.AnswerText,
+		"cost":        data.Cost,
+		"options":     data.Options,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&id)
+	if err != nil {
+		return id, fmt.Errorf("db create library question error: %v", err)
+	}
+	return id, nil
+}
+
+// UpdateLibraryQuestion saves the library question and copies it to every game question that
+// follows it in one transaction. It returns the number of game questions updated.
+func (s *storage) UpdateLibraryQuestion(ctx context.Context, id int, data dto.LibraryQuestionRequest) (int, error) {
+	propagated := 0
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		args := pgx.NamedArgs{
+			"id":          id,
+			"visibility":  data.Visibility,
+			"description": data.Description,
+			"answer":      data.AnswerNum,
+			"answer_text": data.AnswerText,
+			"cost":        data.Cost,
+			"options":     data.Options,
+		}
+		query := `
+			UPDATE
+				library_questions
+			SET
+				visibility = @visibility,
+				description = @description,
+				answer = @answer,
+				answer_text = @answer_text,
+				cost = @cost,
+				options = COALESCE(@options::text[], '{}'),
+				updated_at = CURRENT_TIMESTAMP
+			WHERE
+				id = @id
+			RETURNING id
+		`
+		var res int
+		err := tx.QueryRow(ctx, query, args).Scan(&res)
+		if err != nil {
+			return err
+		}
+
+		// deleted questions follow too, so that a restored question is up to date
+		query = `
+			UPDATE
+				questions
+			SET
+				description = @description,
+				answer = @answer,
+				answer_text = @answer_text,
+				cost = @cost,
+				options = COALESCE(@options::text[], '{}')
+			WHERE
+				library_question_id = @id
+				AND NOT forked
+		`
+		tag, err := tx.Exec(ctx, query, args)
+		if err != nil {
+			return err
+		}
+		propagated = int(tag.RowsAffected())
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("db update library question error: %w", err)
+	}
+	return propagated, nil
+}
+
+// LibraryQuestionPublishedGames returns the published games of the organization with questions
+// that follow the library question.
+func (s *storage) LibraryQuestionPublishedGames(ctx context.Context, orgId int, id int) ([]int, error) {
+	query := `
+		SELECT DISTINCT
+			g.id
+		FROM questions q
+		JOIN games g ON g.id = q.game_id
+		WHERE
+			q.library_question_id = @id
+			AND NOT q.forked
+			AND q.deleted_at IS NULL
+			AND g.org_id = @org_id
+			AND g.status = @status
+			AND g.deleted_at IS NULL
+		ORDER BY g.id
+	`
+	args := pgx.NamedArgs{
+		"id":     id,
+		"org_id": orgId,
+		"status": model.GameStatusPublished,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("db library question published games error: %v", err)
+	}
+	res, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("db library question published games error: %v", err)
+	}
+	return res, nil
+}
+
+// DeleteLibraryQuestion removes the library question, the game questions that followed it keep their contents.
+func (s *storage) DeleteLibraryQuestion(ctx context.Context, id int) (int, error) {
+	res := 0
+	query := `
+		DELETE FROM
+			library_questions
+		WHERE
+			id = @id
+		RETURNING id
+	`
+	err := s.db.QueryRow(ctx, query, pgx.NamedArgs{"id": id}).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db delete library question error: %w", err)
+	}
+	return res, nil
+}
+
+// AddLibraryQuestion puts a copy of the library question into the game that follows the library question.
+// A zero number puts it after the other questions of the game.
+func (s *storage) AddLibraryQuestion(ctx context.Context, gameId int, libraryQuestionId int, number int, roundId *int) (int, error) {
+	var id int
+	query := `
+		INSERT INTO
+			questions (
+				number,
+				description,
+				game_id,
+				answer,
+				answer_text,
+				cost,
+				options,
+				round_id,
+				library_question_id
+			)
+		SELECT
+			CASE
+				WHEN @number > 0 THEN @number
+				ELSE COALESCE((SELECT MAX(number) FROM questions WHERE game_id = @game_id AND deleted_at IS NULL), 0) + 1
+			END,
+			lq.description,
+			@game_id,
+			lq.answer,
+			lq.answer_text,
+			lq.cost,
+			lq.options,
+			@round_id,
+			lq.id
+		FROM library_questions lq
+		WHERE
+			lq.id = @library_question_id
+		RETURNING
+			id
+	`
+	args := pgx.NamedArgs{
+		"game_id":             gameId,
+		"library_question_id": libraryQuestionId,
+		"number":              number,
+		"round_id":            roundId,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&id)
+	if err != nil {
+		return id, fmt.Errorf("db add library question error: %w", err)
+	}
+	return id, nil
+}
+
+// SaveQuestionToLibrary creates a library question from the game question and makes the
+// game question follow it, in one transaction.
+func (s *storage) SaveQuestionToLibrary(ctx context.Context, questionId int, ownerId int, visibility string) (int, error) {
+	var id int
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		query := `
+			INSERT INTO
+				library_questions (
+					org_id,
+					owner_id,
+					visibility,
+					description,
+					answer,
+					answer_text,
+					cost,
+					options
+				)
+			SELECT
+				g.org_id,
+				@owner_id,
+				@visibility,
+				COALESCE(q.description, ''),
+				COALESCE(q.answer, 0),
+				CASE WHEN q.answer_text IS NULL OR q.answer_text = 'NULL' THEN '' ELSE q.answer_text END,
+				COALESCE(q.cost, 0),
+				q.options
+			FROM questions q
+			JOIN games g ON g.id = q.game_id
+			WHERE
+				q.id = @question_id
+			RETURNING
+				id
+		`
+		err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+			"question_id": questionId,
+			"owner_id":    ownerId,
+			"visibility":  visibility,
+		}).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			UPDATE
+				questions
+			SET
+				library_question_id = @library_question_id,
+				forked = false
+			WHERE
+				id = @question_id
+		`
+		_, err = tx.Exec(ctx, query, pgx.NamedArgs{
+			"question_id":         questionId,
+			"library_question_id": id,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("db save question to library error: %w", err)
+	}
+	return id, nil
+}
+
+// ForkQuestion stops the game question from following its library question.
+func (s *storage) ForkQuestion(ctx context.Context, id int) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			questions
+		SET
+			forked = true
+		WHERE
+			id = @id
+			AND library_question_id IS NOT NULL
+			AND deleted_at IS NULL
+		RETURNING id
+	`
+	err := s.db.QueryRow(ctx, query, pgx.NamedArgs{"id": id}).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db fork question error: %w", err)
+	}
+	return res, nil
+}
+
+// LibraryQuestionStats sums up the results of the library question over all lobbies. Results are
+// attributed through the snapshot the lobby played, or the live question for older lobbies.
+// Forked copies have their own contents and are not counted.
+func (s *storage) LibraryQuestionStats(ctx context.Context, id int) (model.LibraryQuestionStats, error) {
+	var res model.LibraryQuestionStats
+	query := `
+		WITH results AS (
+			SELECT
+				pr.lobby_uuid,
+				pr.score
+			FROM player_results pr
+			JOIN lobbies l ON l.uuid = pr.lobby_uuid
+			LEFT JOIN snapshot_questions sq ON sq.snapshot_id = l.snapshot_id AND sq.question_id = pr.question_id
+			LEFT JOIN questions q ON q.id = pr.question_id AND l.snapshot_id IS NULL AND NOT q.forked
+			WHERE
+				COALESCE(sq.library_question_id, q.library_question_id) = @id
+		)
+		SELECT
+			@id::int AS library_question_id,
+			(
+				SELECT COUNT(DISTINCT q.game_id)
+				FROM questions q
+				JOIN games g ON g.id = q.game_id
+				WHERE
+					q.library_question_id = @id
+					AND NOT q.forked
+					AND q.deleted_at IS NULL
+					AND g.deleted_at IS NULL
+			) AS games,
+			(SELECT COUNT(DISTINCT lobby_uuid) FROM results) AS lobbies,
+			(SELECT COUNT(*) FROM results) AS answers,
+			(SELECT COUNT(*) FROM results WHERE score > 0) AS correct,
+			COALESCE((SELECT AVG(CASE WHEN score > 0 THEN 1 ELSE 0 END) FROM results), 0)::float8 AS correct_rate,
+			COALESCE((SELECT AVG(score) FROM results), 0)::float8 AS average_score
+	`
+	rows, err := s.db.Query(ctx, query, pgx.NamedArgs{"id": id})
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db library question stats error: %v", err)
+	}
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.LibraryQuestionStats])
+	if err != nil {
+		return res, fmt.Errorf("db library question stats error: %v", err)
+	}
+	return res, nil
+}
@@ -17,21 +17,30 @@ func (s *storage) CreateLobby(ctx context.Context, data model.Lobby) error {
 			lobbies (
 				uuid,
 				game_id,
-				is_started
+				is_started,
+				org_id,
+				shuffle_questions,
+				shuffle_options
 			)
 		VALUES
 			(
 			@uuid,
 			@game_id,
-			@is_started
+			@is_started,
+			@org_id,
+			@shuffle_questions,
+			@shuffle_options
 		)
 		RETURNING
 			uuid
 	`
 	args := pgx.NamedArgs{
-		"uuid":       data.UUID,
-		"game_id":    data.GameId,
-		"is_started": data.IsStarted,
+		"uuid":              data.UUID,
+		"game_id":           data.GameId,
+		"is_started":        data.IsStarted,
+		"org_id":            data.OrgId,
+		"shuffle_questions": data.ShuffleQuestions,
+		"shuffle_options":   data.ShuffleOptions,
 	}
 	err := s.db.QueryRow(ctx, query, args).Scan(&id)
 	if err != nil {
@@ -40,13 +49,21 @@ func (s *storage) CreateLobby(ctx context.Context, data model.Lobby) error {
 	return nil
 }
 
+// LobbyLoadByUUID loads the lobby by its uuid alone. The uuid is what players join with and what
+// lobby tokens are bound to, so this is where the organization of a lobby is resolved; every
+// query made on behalf of the lobby afterwards is scoped by its OrgId.
 func (s *storage) LobbyLoadByUUID(ctx context.Context, uuid uuid.UUID) (model.Lobby, error) {
 	var res model.Lobby
 	query := `
 		SELECT
 			uuid,
 			game_id,
-			is_started
+			is_started,
+			org_id,
+			snapshot_id,
+			shuffle_questions,
+			shuffle_options,
+			question_order
 		FROM lobbies 
 		WHERE uuid = @uuid
 	`
@@ -71,17 +88,25 @@ func (s *storage) LobbyLoadByUUID(ctx context.Context, uuid uuid.UUID) (model.Lo
 	return res, nil
 }
 
-func (s *storage) LobbyList(ctx context.Context) ([]model.Lobby, error) {
+func (s *storage) LobbyList(ctx context.Context, orgId int) ([]model.Lobby, error) {
 	res := []model.Lobby{}
 	query := `
 		SELECT
 			uuid,
 			game_id,
-			is_started
+			is_started,
+			org_id,
+			snapshot_id,
+			shuffle_questions,
+			shuffle_options,
+			question_order
 		FROM lobbies
-		WHERE is_started = false
+		WHERE is_started = false AND org_id = @org_id
 	`
-	rows, err := s.db.Query(ctx, query)
+	args := pgx.NamedArgs{
+		"org_id": orgId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
 	defer rows.Close()
 	if err != nil {
 		return res, err
@@ -93,21 +118,152 @@ func (s *storage) LobbyList(ctx context.Context) ([]model.Lobby, error) {
 	return res, nil
 }
 
-func (s *storage) UpdateLobby(ctx context.Context, lobbyUUID uuid.UUID) error {
-	log.Println("db update, uuid:", lobbyUUID)
+// StartLobby marks the lobby started and pins it to a snapshot of its game in one transaction.
+// The latest snapshot is reused while the game has not changed since, a lobby that is already
+// pinned keeps its snapshot. Lobbies shuffling questions get their question order here, questions
+// are shuffled within their rounds and rounds keep their place. It returns the id of the snapshot.
+func (s *storage) StartLobby(ctx context.Context, lobbyUUID uuid.UUID) (int, error) {
+	log.Println("db start lobby, uuid:", lobbyUUID)
+	var snapshotId int
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var (
+			gameId int
+			pinned *int
+		)
+		query := `
+			SELECT
+				game_id,
+				snapshot_id
+			FROM lobbies
+			WHERE uuid = @uuid
+			FOR UPDATE
+		`
+		err := tx.QueryRow(ctx, query, pgx.NamedArgs{"uuid": lobbyUUID}).Scan(&gameId, &pinned)
+		if err != nil {
+			return err
+		}
+
+		if pinned != nil {
+			snapshotId = *pinned
+		} else {
+			snapshotId, err = snapshotGame(ctx, tx, gameId)
+			if err != nil {
+				return err
+			}
+		}
+
+		query = `
+			UPDATE
+				lobbies
+			SET
+				is_started = true,
+				snapshot_id = @snapshot_id,
+				question_order = CASE
+					WHEN shuffle_questions AND question_order IS NULL THEN (
+						SELECT array_agg(number ORDER BY first_number, random())
+						FROM (
+							SELECT
+								number,
+								MIN(number) OVER (PARTITION BY round_id) AS first_number
+							FROM snapshot_questions
+							WHERE snapshot_id = @snapshot_id
+						) sq
+					)
+					ELSE question_order
+				END
+			WHERE uuid = @uuid
+		`
+		_, err = tx.Exec(ctx, query, pgx.NamedArgs{
+			"uuid":        lobbyUUID,
+			"snapshot_id": snapshotId,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("db start lobby error: %w", err)
+	}
+	return snapshotId, nil
+}
+
+func (s *storage) PlayerExists(ctx context.Context, playerUUID uuid.UUID) (bool, error) {
+	res := false
 	query := `
-		UPDATE
-			lobbies
-		SET
-			is_started = true
-		WHERE uuid = @lobbyUUID
+		SELECT EXISTS (
+			SELECT 1 FROM players WHERE uuid = @uuid
+		)
 	`
 	args := pgx.NamedArgs{
-		"lobbyUUID": lobbyUUID,
+		"uuid": playerUUID,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db player exists error: %v", err)
+	}
+	return res, nil
+}
+
+// OptionOrders loads the option orders of the player in the lobby, a nil playerUUID loads those of every player.
+func (s *storage) OptionOrders(ctx context.Context, lobbyUUID uuid.UUID, playerUUID uuid.UUID) ([]model.OptionOrder, error) {
+	res := []model.OptionOrder{}
+	query := `
+		SELECT
+			lobby_uuid,
+			player_uuid,
+			question_id,
+			option_order
+		FROM player_option_orders
+		WHERE
+			lobby_uuid = @lobby_uuid
+			AND (@all_players OR player_uuid = @player_uuid)
+	`
+	args := pgx.NamedArgs{
+		"lobby_uuid":  lobbyUUID,
+		"player_uuid": playerUUID,
+		"all_players": playerUUID == uuid.Nil,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db option orders error: %v", err)
+	}
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.OptionOrder])
+	if err != nil {
+		return res, fmt.Errorf("db option orders error: %v", err)
+	}
+	return res, nil
+}
+
+// SaveOptionOrders stores the option orders in a single batch. Orders that are already stored
+// are kept, so a player always sees the options the way they were graded.
+func (s *storage) SaveOptionOrders(ctx context.Context, orders []model.OptionOrder) error {
+	batch := &pgx.Batch{}
+	for _, o := range orders {
+		batch.Queue(`
+			INSERT INTO
+				player_option_orders (
+					lobby_uuid,
+					player_uuid,
+					question_id,
+					option_order
+				)
+			VALUES
+				(
+				@lobby_uuid,
+				@player_uuid,
+				@question_id,
+				@option_order
+			)
+			ON CONFLICT DO NOTHING
+		`, pgx.NamedArgs{
+			"lobby_uuid":   o.LobbyUUID,
+			"player_uuid":  o.PlayerUUID,
+			"question_id":  o.QuestionId,
+			"option_order": o.Order,
+		})
 	}
-	_, err := s.db.Exec(ctx, query, args)
+	err := s.db.SendBatch(ctx, batch).Close()
 	if err != nil {
-		return fmt.Errorf("db update lobby error: %v", err)
+		return fmt.Errorf("db save option orders error: %v", err)
 	}
 	return nil
 }
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *storage) LoginAttempt(ctx context.Context, scope string, key string) (model.LoginAttempt, error) {
+	var res model.LoginAttempt
+	query := `
+		SELECT
+			scope,
+			key,
+			failures,
+			locked_until,
+			last_failure_at
+		FROM login_attempts
+		WHERE
+			scope = @scope
+			AND key = @key
+	`
+	args := pgx.NamedArgs{
+		"scope": scope,
+		"key":   key,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.LoginAttempt])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// RegisterLoginFailure increments the failure counter and returns the updated row.
+// Counters whose last failure is older than the window start over from one.
+func (s *storage) RegisterLoginFailure(ctx context.Context, scope string, key string, window time.Duration) (model.LoginAttempt, error) {
+	var res model.LoginAttempt
+	query := `
+		INSERT INTO
+			login_attempts (
+				scope,
+				key,
+				failures,
+				last_failure_at
+			)
+		VALUES
+			(
+			@scope,
+			@key,
+			1,
+			CURRENT_TIMESTAMP
+		)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < CURRENT_TIMESTAMP - make_interval(secs => @window) THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = CURRENT_TIMESTAMP
+		RETURNING
+			scope,
+			key,
+			failures,
+			locked_until,
+			last_failure_at
+	`
+	args := pgx.NamedArgs{
+		"scope":  scope,
+		"key":    key,
+		"window": window.Seconds(),
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, fmt.Errorf("db register login failure error: %v", err)
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.LoginAttempt])
+
+	if err != nil {
+		return res, fmt.Errorf("db register login failure error: %v", err)
+	}
+
+	return res, nil
+}
+
+func (s *storage) LockLogin(ctx context.Context, scope string, key string, until time.Time) error {
+	query := `
+		UPDATE
+			login_attempts
+		SET
+			locked_until = @locked_until
+		WHERE
+			scope = @scope
+			AND key = @key
+	`
+	args := pgx.NamedArgs{
+		"scope":        scope,
+		"key":          key,
+		"locked_until": until,
+	}
+	_, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db lock login error: %v", err)
+	}
+	return nil
+}
+
+func (s *storage) ResetLoginAttempts(ctx context.Context, scope string, key string) error {
+	query := `
+		DELETE FROM
+			login_attempts
+		WHERE
+			scope = @scope
+			AND key = @key
+	`
+	args := pgx.NamedArgs{
+		"scope": scope,
+		"key":   key,
+	}
+	_, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db reset login attempts error: %v", err)
+	}
+	return nil
+}
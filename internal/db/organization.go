@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *storage) CreateOrganization(ctx context.Context, name string) (int, error) {
+	var id int
+	query := `
+		INSERT INTO
+			organizations (
+				name
+			)
+		VALUES
+			(
+			@name
+		)
+		RETURNING
+			id
+	`
+	args := pgx.NamedArgs{
+		"name": name,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&id)
+	if err != nil {
+		return id, fmt.Errorf("db create organization error: %w", err)
+	}
+	return id, nil
+}
+
+func (s *storage) OrganizationByName(ctx context.Context, name string) (model.Organization, error) {
+	var res model.Organization
+	query := `
+		SELECT
+			id,
+			name,
+			created_at
+		FROM organizations
+		WHERE name = @name
+	`
+	args := pgx.NamedArgs{
+		"name": name,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Organization])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *storage) OrganizationList(ctx context.Context) ([]model.Organization, error) {
+	res := []model.Organization{}
+	query := `
+		SELECT
+			id,
+			name,
+			created_at
+		FROM organizations
+		ORDER BY id
+	`
+	rows, err := s.db.Query(ctx, query)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.Organization])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// OrganizationsByUser returns the organizations the user is a member of, oldest first.
+func (s *storage) OrganizationsByUser(ctx context.Context, userId int) ([]model.Organization, error) {
+	res := []model.Organization{}
+	query := `
+		SELECT
+			o.id,
+			o.name,
+			o.created_at
+		FROM organizations o
+		JOIN organization_members m on m.org_id = o.id
+		WHERE m.user_id = @user_id
+		ORDER BY o.id
+	`
+	args := pgx.NamedArgs{
+		"user_id": userId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.Organization])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// OrganizationRole returns the role of the user in the organization.
+// pgx.ErrNoRows is returned when the user is not a member.
+func (s *storage) OrganizationRole(ctx context.Context, orgId int, userId int) (string, error) {
+	var res string
+	query := `
+		SELECT
+			role
+		FROM organization_members
+		WHERE org_id = @org_id AND user_id = @user_id
+	`
+	args := pgx.NamedArgs{
+		"org_id":  orgId,
+		"user_id": userId,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *storage) OrganizationMembers(ctx context.Context, orgId int) ([]model.OrganizationMember, error) {
+	res := []model.OrganizationMember{}
+	query := `
+		SELECT
+			m.org_id,
+			m.user_id,
+			u.login,
+			m.role,
+			m.created_at
+		FROM organization_members m
+		JOIN users u on u.id = m.user_id
+		WHERE m.org_id = @org_id
+		ORDER BY u.login
+	`
+	args := pgx.NamedArgs{
+		"org_id": orgId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.OrganizationMember])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// SaveOrganizationMember adds the user to the organization or changes the role of an existing member.
+func (s *storage) SaveOrganizationMember(ctx context.Context, orgId int, userId int, role string) error {
+	query := `
+		INSERT INTO
+			organization_members (
+				org_id,
+				user_id,
+				role
+			)
+		VALUES
+			(
+			@org_id,
+			@user_id,
+			@role
+		)
+		ON CONFLICT (org_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`
+	args := pgx.NamedArgs{
+		"org_id":  orgId,
+		"user_id": userId,
+		"role":    role,
+	}
+	_, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db save organization member error: %v", err)
+	}
+	return nil
+}
+
+func (s *storage) DeleteOrganizationMember(ctx context.Context, orgId int, userId int) (int, error) {
+	res := 0
+	query := `
+		DELETE FROM
+			organization_members
+		WHERE
+			org_id = @org_id AND user_id = @user_id
+		RETURNING user_id
+	`
+	args := pgx.NamedArgs{
+		"org_id":  orgId,
+		"user_id": userId,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
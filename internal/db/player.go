@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"quizer_server/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ClaimPlayer links the player to the user account. It reports false when the player
+// does not exist or is already linked to another account.
+func (s *storage) ClaimPlayer(ctx context.Context, playerUUID uuid.UUID, userId int) (bool, error) {
+	var id uuid.UUID
+	query := `
+		UPDATE
+			players
+		SET
+			user_id = @user_id
+		WHERE
+			uuid = @uuid AND (user_id IS NULL OR user_id = @user_id)
+		RETURNING uuid
+	`
+	args := pgx.NamedArgs{
+		"uuid":    playerUUID,
+		"user_id": userId,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("db claim player error: %v", err)
+	}
+	return true, nil
+}
+
+// PlayerHistory returns the lobbies the user played in, newest first, with the total score
+// and the rank of the user's player among all players of the lobby.
+func (s *storage) PlayerHistory(ctx context.Context, userId int, limit int, offset int) ([]model.PlayerHistoryEntry, error) {
+	res := []model.PlayerHistoryEntry{}
+	query := `
+		WITH scores AS (
+			SELECT
+				p.lobby_id,
+				p.uuid,
+				p.user_id,
+				p.user_name,
+				COALESCE(SUM(r.score), 0) AS score
+			FROM players p
+			LEFT JOIN player_results r ON r.lobby_uuid = p.lobby_id AND r.player_uuid = p.uuid
+			WHERE
+				p.is_admin = false
+				AND p.lobby_id IN (SELECT lobby_id FROM players WHERE user_id = @user_id)
+			GROUP BY p.lobby_id, p.uuid, p.user_id, p.user_name
+		), ranked AS (
+			SELECT
+				*,
+				RANK() OVER (PARTITION BY lobby_id ORDER BY score DESC) AS rank,
+				COUNT(*) OVER (PARTITION BY lobby_id) AS players_count
+			FROM scores
+		)
+		SELECT
+			r.lobby_id AS lobby_uuid,
+			l.game_id,
+			COALESCE(g.description, '') AS description,
+			l.created_at AS played_at,
+			r.uuid AS player_uuid,
+			r.user_name,
+			r.score,
+			r.rank,
+			r.players_count
+		FROM ranked r
+		JOIN lobbies l ON l.uuid = r.lobby_id
+		JOIN games g ON g.id = l.game_id
+		WHERE r.user_id = @user_id
+		ORDER BY l.created_at DESC, r.lobby_id
+		LIMIT @limit
+		OFFSET @offset
+	`
+	args := pgx.NamedArgs{
+		"user_id": userId,
+		"limit":   limit,
+		"offset":  offset,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.PlayerHistoryEntry])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
@@ -4,28 +4,112 @@ import (
 	"context"
 	"quizer_server/internal/dto"
 	"quizer_server/internal/model"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Storage interface {
+	CreateUser(ctx context.Context, data dto.CreateUser) (int, error)
 	UserByLogin(ctx context.Context, login string) (model.User, error)
+	UserById(ctx context.Context, id int) (model.User, error)
+	UpdatePassword(ctx context.Context, id int, hash string) error
+	UpdateProfile(ctx context.Context, id int, data dto.UpdateProfileRequest) (int, error)
+	UpdateRoles(ctx context.Context, id int, roles []string) (int, error)
+	DeleteUser(ctx context.Context, id int) (int, error)
+	GamesCountByOwner(ctx context.Context, ownerId int) (int, error)
+
+	UserByIdentity(ctx context.Context, issuer string, subject string) (model.User, error)
+	CreateUserWithIdentity(ctx context.Context, data dto.CreateUser, issuer string, subject string) (int, error)
+	CreateOidcState(ctx context.Context, data model.OidcState) error
+	TakeOidcState(ctx context.Context, state string) (model.OidcState, error)
+
+	LoginAttempt(ctx context.Context, scope string, key string) (model.LoginAttempt, error)
+	RegisterLoginFailure(ctx context.Context, scope string, key string, window time.Duration) (model.LoginAttempt, error)
+	LockLogin(ctx context.Context, scope string, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, scope string, key string) error
+
+	CreateApiKey(ctx context.Context, data model.ApiKey) (int, error)
+	ApiKeyByPrefix(ctx context.Context, prefix string) (model.ApiKey, error)
+	ApiKeysByUser(ctx context.Context, userId int) ([]model.ApiKey, error)
+	RevokeApiKey(ctx context.Context, userId int, id int) (int, error)
+	TouchApiKey(ctx context.Context, id int) error
+
+	CreateOrganization(ctx context.Context, name string) (int, error)
+	OrganizationByName(ctx context.Context, name string) (model.Organization, error)
+	OrganizationList(ctx context.Context) ([]model.Organization, error)
+	OrganizationsByUser(ctx context.Context, userId int) ([]model.Organization, error)
+	OrganizationRole(ctx context.Context, orgId int, userId int) (string, error)
+	OrganizationMembers(ctx context.Context, orgId int) ([]model.OrganizationMember, error)
+	SaveOrganizationMember(ctx context.Context, orgId int, userId int, role string) error
+	DeleteOrganizationMember(ctx context.Context, orgId int, userId int) (int, error)
+
+	GameSnapshots(ctx context.Context, gameId int) ([]model.GameSnapshot, error)
+	SnapshotQuestions(ctx context.Context, snapshotId int) ([]model.Question, error)
+	SnapshotQuestionByNumber(ctx context.Context, snapshotId int, number int) (model.Question, error)
+	SnapshotRounds(ctx context.Context, snapshotId int) ([]model.Round, error)
+
+	RoundsByGameId(ctx context.Context, gameId int) ([]model.Round, error)
+	RoundLoad(ctx context.Context, orgId int, id int) (model.Round, error)
+	CreateRound(ctx context.Context, gameId int, data dto.RoundRequest) (int, error)
+	UpdateRound(ctx context.Context, updated model.Round) (int, error)
+	DeleteRound(ctx context.Context, id int) (int, error)
+	CalculateRoundResults(ctx context.Context, lobbyUUID uuid.UUID) ([]model.PlayerRoundScore, error)
+
+	LibraryQuestions(ctx context.Context, filter dto.LibraryFilter) ([]model.LibraryQuestion, error)
+	LibraryQuestionLoad(ctx context.Context, orgId int, id int) (model.LibraryQuestion, error)
+	CreateLibraryQuestion(ctx context.Context, orgId int, ownerId int, data dto.LibraryQuestionRequest) (int, error)
+	UpdateLibraryQuestion(ctx context.Context, id int, data dto.LibraryQuestionRequest) (int, error)
+	LibraryQuestionPublishedGames(ctx context.Context, orgId int, id int) ([]int, error)
+	DeleteLibraryQuestion(ctx context.Context, id int) (int, error)
+	AddLibraryQuestion(ctx context.Context, gameId int, libraryQuestionId int, number int, roundId *int) (int, error)
+	SaveQuestionToLibrary(ctx context.Context, questionId int, ownerId int, visibility string) (int, error)
+	ForkQuestion(ctx context.Context, id int) (int, error)
+	LibraryQuestionStats(ctx context.Context, id int) (model.LibraryQuestionStats, error)
+
+	CreateAuditRecord(ctx context.Context, data model.AuditRecord) error
+	AuditList(ctx context.Context, filter dto.AuditFilter) ([]model.AuditRecord, int, error)
+
+	CreateRefreshToken(ctx context.Context, data model.RefreshToken) (int, error)
+	RefreshTokenByHash(ctx context.Context, hash string) (model.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, id int) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyId uuid.UUID) error
 
 	CreateGame(ctx context.Context, data dto.CreateNewGame) (int, error)
-	GameList(ctx context.Context) ([]model.Game, error)
-	GameLoad(ctx context.Context, id int) (model.Game, error)
+	CreateGameWithQuestions(ctx context.Context, data dto.CreateNewGame, rounds []dto.RoundRequest, questions []dto.CreateNewQuestionRequest) (int, error)
+	CloneGame(ctx context.Context, sourceId int, data dto.CreateNewGame) (int, error)
+	GameList(ctx context.Context, filter dto.GameListFilter) ([]model.Game, error)
+	GameLoad(ctx context.Context, orgId int, id int) (model.Game, error)
 	UpdateGame(ctx context.Context, updated model.Game) (int, error)
+	UpdateGameStatus(ctx context.Context, id int, status string) (int, error)
+	UpdateGameLabels(ctx context.Context, id int, category string, tags []string) (int, error)
+	SearchGames(ctx context.Context, filter dto.GameListFilter, query string, limit int) ([]model.GameSearchResult, error)
+	GameTags(ctx context.Context, filter dto.GameListFilter) ([]model.LabelCount, error)
+	GameCategories(ctx context.Context, filter dto.GameListFilter) ([]model.LabelCount, error)
 	UpdateFilePath(ctx context.Context, gameId int, path string) (int, error)
 	DeleteGame(ctx context.Context, id int) (int, error)
+	TrashedGameLoad(ctx context.Context, orgId int, id int) (model.Game, error)
+	RestoreGame(ctx context.Context, id int) (int, error)
+	PurgeDeletedGames(ctx context.Context, before time.Time) ([]int, []string, error)
+
+	CollaboratorPermission(ctx context.Context, gameId int, userId int) (string, error)
+	CollaboratorsByGame(ctx context.Context, gameId int) ([]model.Collaborator, error)
+	SaveCollaborator(ctx context.Context, gameId int, userId int, permission string) error
+	DeleteCollaborator(ctx context.Context, gameId int, userId int) (int, error)
 
 	CreateLobby(ctx context.Context, data model.Lobby) error
 	LobbyLoadByUUID(ctx context.Context, uuid uuid.UUID) (model.Lobby, error)
-	UpdateLobby(ctx context.Context, lobbyUUID uuid.UUID) error
-	LobbyList(ctx context.Context) ([]model.Lobby, error)
+	StartLobby(ctx context.Context, lobbyUUID uuid.UUID) (int, error)
+	OptionOrders(ctx context.Context, lobbyUUID uuid.UUID, playerUUID uuid.UUID) ([]model.OptionOrder, error)
+	SaveOptionOrders(ctx context.Context, orders []model.OptionOrder) error
+	LobbyList(ctx context.Context, orgId int) ([]model.Lobby, error)
 
-	PlayersByGameUUID(ctx context.Context, gameUUID uuid.UUID) ([]model.Player, error)
+	PlayersByGameUUID(ctx context.Context, orgId int, gameUUID uuid.UUID) ([]model.Player, error)
+	ClaimPlayer(ctx context.Context, playerUUID uuid.UUID, userId int) (bool, error)
+	PlayerHistory(ctx context.Context, userId int, limit int, offset int) ([]model.PlayerHistoryEntry, error)
 	SavePlayer(ctx context.Context, newPlayer model.Player) error
+	PlayerExists(ctx context.Context, playerUUID uuid.UUID) (bool, error)
 
 	SaveAnswer(ctx context.Context, data model.Answer) error
 	LoadAnswersByLobbyUUID(ctx context.Context, lobbyUUID uuid.UUID) ([]model.Answer, error)
@@ -38,12 +122,18 @@ type Storage interface {
 	CalculateResults(ctx context.Context, lobbyUUID uuid.UUID) []model.CalcResult
 
 	CreateQuestion(ctx context.Context, data dto.CreateNewQuestionRequest) (int, error)
-	QuestionLoad(ctx context.Context, id int) (model.Question, error)
-	QuestionLoadByNumber(ctx context.Context, gameId int, number int) (model.Question, error)
+	QuestionLoad(ctx context.Context, orgId int, id int) (model.Question, error)
+	QuestionLoadByNumber(ctx context.Context, orgId int, gameId int, number int) (model.Question, error)
 
-	QuestionsByGameId(ctx context.Context, gameId int) ([]model.Question, error)
+	QuestionsByGameId(ctx context.Context, orgId int, gameId int) ([]model.Question, error)
+	SearchQuestions(ctx context.Context, filter dto.QuestionSearchFilter) ([]model.QuestionSearchResult, error)
+	CreateQuestions(ctx context.Context, gameId int, questions []dto.CreateNewQuestionRequest) error
 	UpdateQuestion(ctx context.Context, updated model.Question) (int, error)
 	DeleteQuestion(ctx context.Context, id int) (int, error)
+	DeletedQuestions(ctx context.Context, gameId int) ([]model.DeletedQuestion, error)
+	DeletedQuestionLoad(ctx context.Context, orgId int, id int) (model.Question, error)
+	RestoreQuestion(ctx context.Context, id int) (int, error)
+	PurgeDeletedQuestions(ctx context.Context, before time.Time) (int, error)
 }
 
 type storage struct {
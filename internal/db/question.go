@@ -19,7 +19,9 @@ func (s *storage) CreateQuestion(ctx context.Context, data dto.CreateNewQuestion
  				game_id,
  				answer,
  				answer_text,
- 				cost
+ 				cost,
+ 				options,
+ 				round_id
 			)
 		VALUES
 			(
@@ -28,7 +30,9 @@ func (s *storage) CreateQuestion(ctx context.Context, data dto.CreateNewQuestion
  			@game_id,
  			@answer,
  			@answer_text,
- 			@cost
+ 			@cost,
+ 			COALESCE(@options::text[], '{}'),
+ 			@round_id
 		)
 		RETURNING
 			id
@@ -40,6 +44,8 @@ func (s *storage) CreateQuestion(ctx context.Context, data dto.CreateNewQuestion
 		"answer":      data.AnswerNum,
 		"answer_text": data.AnswerText,
 		"cost":        data.Cost,
+		"options":     data.Options,
+		"round_id":    data.RoundId,
 	}
 	row := s.db.QueryRow(ctx, query, args)
 	err := row.Scan(&id)
@@ -49,25 +55,34 @@ func (s *storage) CreateQuestion(ctx context.Context, data dto.CreateNewQuestion
 	return id, nil
 }
 
-func (s *storage) QuestionsByGameId(ctx context.Context, gameId int) ([]model.Question, error) {
+// QuestionsByGameId lists the questions of a game of the organization orgId.
+func (s *storage) QuestionsByGameId(ctx context.Context, orgId int, gameId int) ([]model.Question, error) {
 	var res []model.Question
 	query := `
 		SELECT
-			id,
-			number,
-			description,
-			game_id,
-			answer,
-			answer_text,
-			cost
-		FROM questions
+			q.id,
+			q.number,
+			q.description,
+			q.game_id,
+			q.answer,
+			q.answer_text,
+			q.cost,
+			q.options,
+			q.round_id,
+			q.library_question_id,
+			q.forked
+		FROM questions q
+		JOIN games g ON g.id = q.game_id
 		WHERE
-			game_id = @game_id
-		ORDER BY number
+			q.game_id = @game_id
+			AND g.org_id = @org_id
+			AND q.deleted_at IS NULL
+		ORDER BY q.number
 	`
 
 	args := pgx.NamedArgs{
 		"game_id": gameId,
+		"org_id":  orgId,
 	}
 	rows, err := s.db.Query(ctx, query, args)
 	defer rows.Close()
@@ -85,24 +100,33 @@ func (s *storage) QuestionsByGameId(ctx context.Context, gameId int) ([]model.Qu
 	return res, nil
 }
 
-func (s *storage) QuestionLoad(ctx context.Context, id int) (model.Question, error) {
+// QuestionLoad loads the question of a game of the organization orgId.
+func (s *storage) QuestionLoad(ctx context.Context, orgId int, id int) (model.Question, error) {
 	var res model.Question
 	query := `
 		SELECT
-			id,
-			number,
-			description,
-			game_id,
-			answer,
-			answer_text,
-			cost
-		FROM questions
+			q.id,
+			q.number,
+			q.description,
+			q.game_id,
+			q.answer,
+			q.answer_text,
+			q.cost,
+			q.options,
+			q.round_id,
+			q.library_question_id,
+			q.forked
+		FROM questions q
+		JOIN games g on g.id = q.game_id
 		WHERE
-			id = @id
+			q.id = @id AND g.org_id = @org_id
+			AND q.deleted_at IS NULL
+			AND g.deleted_at IS NULL
 	`
 
 	args := pgx.NamedArgs{
-		"id": id,
+		"id":     id,
+		"org_id": orgId,
 	}
 	rows, err := s.db.Query(ctx, query, args)
 	defer rows.Close()
@@ -120,26 +144,34 @@ func (s *storage) QuestionLoad(ctx context.Context, id int) (model.Question, err
 	return res, nil
 }
 
-func (s *storage) QuestionLoadByNumber(ctx context.Context, gameId int, number int) (model.Question, error) {
+// QuestionLoadByNumber loads the question with the number of a game of the organization orgId.
+func (s *storage) QuestionLoadByNumber(ctx context.Context, orgId int, gameId int, number int) (model.Question, error) {
 	var res model.Question
 	query := `
 		SELECT
-			id,
-			number,
-			description,
-			game_id,
-			answer,
-			answer_text,
-			cost
-		FROM questions
+			q.id,
+			q.number,
+			q.description,
+			q.game_id,
+			q.answer,
+			q.answer_text,
+			q.cost,
+			q.options,
+			q.round_id,
+			q.library_question_id,
+			q.forked
+		FROM questions q
+		JOIN games g ON g.id = q.game_id
 		WHERE
-			game_id = @game_id
-			AND
-			number = @number
+			q.game_id = @game_id
+			AND g.org_id = @org_id
+			AND q.number = @number
+			AND q.deleted_at IS NULL
 	`
 
 	args := pgx.NamedArgs{
 		"game_id": gameId,
+		"org_id":  orgId,
 		"number":  number,
 	}
 	rows, err := s.db.Query(ctx, query, args)
@@ -169,9 +201,17 @@ func (s *storage) UpdateQuestion(ctx context.Context, updated model.Question) (i
 			game_id = @game_id,
 			answer = @answer,
 			answer_text = @answer_text,
-			cost = @cost
+			cost = @cost,
+			options = COALESCE(@options::text[], '{}'),
+			round_id = @round_id,
+			forked = forked OR (
+				library_question_id IS NOT NULL
+				AND (description, answer, answer_text, cost, options)
+					IS DISTINCT FROM (@description, @answer, @answer_text, @cost, COALESCE(@options::text[], '{}'))
+			)
 		WHERE
 			id = @id
+			AND deleted_at IS NULL
 		RETURNING id
 	`
 	args := pgx.NamedArgs{
@@ -182,6 +222,8 @@ func (s *storage) UpdateQuestion(ctx context.Context, updated model.Question) (i
 		"answer":      updated.AnswerNum,
 		"answer_text": updated.AnswerText,
 		"cost":        updated.Cost,
+		"options":     updated.Options,
+		"round_id":    updated.RoundId,
 	}
 	row := s.db.QueryRow(ctx, query, args)
 
@@ -194,13 +236,17 @@ func (s *storage) UpdateQuestion(ctx context.Context, updated model.Question) (i
 	return res, nil
 }
 
+// DeleteQuestion moves the question to the trash, see PurgeDeletedQuestions.
 func (s *storage) DeleteQuestion(ctx context.Context, id int) (int, error) {
 	res := 0
 	query := `
-		DELETE FROM
+		UPDATE
 			questions
+		SET
+			deleted_at = now()
 		WHERE
 			id = @id
+			AND deleted_at IS NULL
 		RETURNING id
 	`
 	args := pgx.NamedArgs{
@@ -216,3 +262,56 @@ func (s *storage) DeleteQuestion(ctx context.Context, id int) (int, error) {
 
 	return res, nil
 }
+
+// CreateQuestions adds the questions to the game in one transaction.
+// The GameId of the questions is ignored.
+func (s *storage) CreateQuestions(ctx context.Context, gameId int, questions []dto.CreateNewQuestionRequest) error {
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		return insertQuestions(ctx, tx, gameId, questions)
+	})
+	if err != nil {
+		return fmt.Errorf("db create questions error: %v", err)
+	}
+	return nil
+}
+
+// insertQuestions inserts the questions of the game within the transaction using a single batch.
+func insertQuestions(ctx context.Context, tx pgx.Tx, gameId int, questions []dto.CreateNewQuestionRequest) error {
+	batch := &pgx.Batch{}
+	for _, q := range questions {
+		batch.Queue(`
+			INSERT INTO
+				questions (
+					number,
+					description,
+					game_id,
+					answer,
+					answer_text,
+					cost,
+					options,
+					round_id
+				)
+			VALUES
+				(
+				@number,
+				@description,
+				@game_id,
+				@answer,
+				@answer_text,
+				@cost,
+				COALESCE(@options::text[], '{}'),
+				@round_id
+			)
+		`, pgx.NamedArgs{
+			"number":      q.Number,
+			"description": q.Description,
+			"game_id":     gameId,
+			"answer":      q.AnswerNum,
+			"answer_text": q.AnswerText,
+			"cost":        q.Cost,
+			"options":     q.Options,
+			"round_id":    q.RoundId,
+		})
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func gameRounds(ctx context.Context, q querier, gameId int) ([]model.Round, error) {
+	query := `
+		SELECT
+			id,
+			game_id,
+			position,
+			title,
+			multiplier,
+			intermission_text,
+			intermission_slide
+		FROM rounds
+		WHERE
+			game_id = @game_id
+		ORDER BY position, id
+	`
+	rows, err := q.Query(ctx, query, pgx.NamedArgs{"game_id": gameId})
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.Round])
+}
+
+// RoundsByGameId returns the rounds of the game in their order.
+func (s *storage) RoundsByGameId(ctx context.Context, gameId int) ([]model.Round, error) {
+	res, err := gameRounds(ctx, s.db, gameId)
+	if err != nil {
+		return res, fmt.Errorf("db rounds by game error: %v", err)
+	}
+	return res, nil
+}
+
+// RoundLoad loads the round of a game of the organization orgId.
+func (s *storage) RoundLoad(ctx context.Context, orgId int, id int) (model.Round, error) {
+	var res model.Round
+	query := `
+		SELECT
+			r.id,
+			r.game_id,
+			r.position,
+			r.title,
+			r.multiplier,
+			r.intermission_text,
+			r.intermission_slide
+		FROM rounds r
+		JOIN games g ON g.id = r.game_id
+		WHERE
+			r.id = @id
+			AND g.org_id = @org_id
+			AND g.deleted_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"id":     id,
+		"org_id": orgId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+	if err != nil {
+		return res, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Round])
+}
+
+// CreateRound adds the round to the game, a zero position puts it after the other rounds.
+// Unique violations of the position are wrapped with %w so they can be detected with IsUniqueViolation.
+func (s *storage) CreateRound(ctx context.Context, gameId int, data dto.RoundRequest) (int, error) {
+	var id int
+	query := `
+		INSERT INTO
+			rounds (
+				game_id,
+				position,
+				title,
+				multiplier,
+				intermission_text,
+				intermission_slide
+			)
+		VALUES
+			(
+			@game_id,
+			CASE
+				WHEN @position > 0 THEN @position
+				ELSE COALESCE((SELECT MAX(position) FROM rounds WHERE game_id = @game_id), 0) + 1
+			END,
+			@title,
+			@multiplier,
+			@intermission_text,
+			@intermission_slide
+		)
+		RETURNING
+			id
+	`
+	args := pgx.NamedArgs{
+		"game_id":            gameId,
+		"position":           data.Position,
+		"title":              data.Title,
+		"multiplier":         data.Multiplier,
+		"intermission_text":  data.IntermissionText,
+		"intermission_slide": data.IntermissionSlide,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&id)
+	if err != nil {
+		return id, fmt.Errorf("db create round error: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateRound saves the round, the game of the round is never changed.
+func (s *storage) UpdateRound(ctx context.Context, updated model.Round) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			rounds
+		SET
+			position = @position,
+			title = @title,
+			multiplier = @multiplier,
+			intermission_text = @intermission_text,
+			intermission_slide = @intermission_slide
+		WHERE
+			id = @id
+		RETURNING id
+	`
+	args := pgx.NamedArgs{
+		"id":                 updated.Id,
+		"position":           updated.Position,
+		"title":              updated.Title,
+		"multiplier":         updated.Multiplier,
+		"intermission_text":  updated.IntermissionText,
+		"intermission_slide": updated.IntermissionSlide,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db update round error: %w", err)
+	}
+	return res, nil
+}
+
+// DeleteRound removes the round, its questions stay in the game outside of any round.
+func (s *storage) DeleteRound(ctx context.Context, id int) (int, error) {
+	res := 0
+	query := `
+		DELETE FROM
+			rounds
+		WHERE
+			id = @id
+		RETURNING id
+	`
+	err := s.db.QueryRow(ctx, query, pgx.NamedArgs{"id": id}).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db delete round error: %w", err)
+	}
+	return res, nil
+}
+
+// CalculateRoundResults sums the scores of the lobby per player and round. The round of a
+// result is taken from the snapshot the lobby played, or from the live question for older lobbies.
+func (s *storage) CalculateRoundResults(ctx context.Context, lobbyUUID uuid.UUID) ([]model.PlayerRoundScore, error) {
+	res := []model.PlayerRoundScore{}
+	query := `
+		SELECT
+			p.user_name,
+			COALESCE(sq.round_id, q.round_id, 0) AS round_id,
+			SUM(pr.score) AS score
+		FROM player_results pr
+		JOIN players p ON p.uuid = pr.player_uuid
+		JOIN lobbies l ON l.uuid = pr.lobby_uuid
+		LEFT JOIN snapshot_questions sq ON sq.snapshot_id = l.snapshot_id AND sq.question_id = pr.question_id
+		LEFT JOIN questions q ON q.id = pr.question_id AND l.snapshot_id IS NULL
+		WHERE pr.lobby_uuid = @lobby_uuid
+		GROUP BY p.user_name, COALESCE(sq.round_id, q.round_id, 0)
+	`
+	rows, err := s.db.Query(ctx, query, pgx.NamedArgs{"lobby_uuid": lobbyUUID})
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db calculate round results error: %v", err)
+	}
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.PlayerRoundScore])
+	if err != nil {
+		return res, fmt.Errorf("db calculate round results error: %v", err)
+	}
+	return res, nil
+}
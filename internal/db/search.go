@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Matches are marked with control characters that can not be confused with markup,
+// highlight turns them into <b> tags once the rest of the text is escaped.
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+// headlineOptions keep highlighted snippets short enough for a result list.
+const headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxFragments=2, MaxWords=20, MinWords=5"
+
+var headlineTags = strings.NewReplacer(headlineStart, "<b>", headlineStop, "</b>")
+
+// highlight escapes the headline, descriptions are written by users and may contain HTML,
+// and wraps the matches in <b> tags.
+func highlight(headline string) string {
+	return headlineTags.Replace(html.EscapeString(headline))
+}
+
+// SearchGames finds the games matching the query in their description or in the description of
+// their questions, best matches first. A game found only by a question is ranked lower and its
+// headline is taken from the best matching question.
+func (s *storage) SearchGames(ctx context.Context, filter dto.GameListFilter, query string, limit int) ([]model.GameSearchResult, error) {
+	res := []model.GameSearchResult{}
+	sql := `
+		WITH tsq AS (
+			SELECT websearch_to_tsquery('russian', @query) AS q
+		)
+		SELECT
+			g.id,
+			g.description,
+			g.owner_id,
+			g.org_id,
+			login,
+			g.created_at,
+			link,
+			g.status,
+			g.category,
+			g.tags,
+			g.deleted_at,
+			(ts_rank(g.search, tsq.q) + 0.5 * COALESCE(qm.rank, 0))::real AS rank,
+			ts_headline(
+				'russian',
+				CASE WHEN g.search @@ tsq.q THEN COALESCE(g.description, '') ELSE qm.description END,
+				tsq.q,
+				@headline_options
+			) AS headline
+		FROM games g
+		JOIN users u on u.id = g.owner_id
+		CROSS JOIN tsq
+		LEFT JOIN LATERAL (
+			SELECT
+				COALESCE(qs.description, '') AS description,
+				ts_rank(qs.search, tsq.q) AS rank
+			FROM questions qs
+			WHERE qs.game_id = g.id AND qs.search @@ tsq.q AND qs.deleted_at IS NULL
+			ORDER BY rank DESC
+			LIMIT 1
+		) qm ON true
+		WHERE ` + gameListConditions + `
+			AND (g.search @@ tsq.q OR qm.rank IS NOT NULL)
+		ORDER BY rank DESC, g.id DESC
+		LIMIT @limit
+	`
+	args := gameListArgs(filter)
+	args["query"] = query
+	args["limit"] = limit
+	args["headline_options"] = headlineOptions
+
+	rows, err := s.db.Query(ctx, sql, args)
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db search games error: %v", err)
+	}
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.GameSearchResult])
+	if err != nil {
+		return res, fmt.Errorf("db search games error: %v", err)
+	}
+	for i := range res {
+		res[i].Headline = highlight(res[i].Headline)
+	}
+	return res, nil
+}
+
+// SearchQuestions finds the questions matching the query, best matches first.
+func (s *storage) SearchQuestions(ctx context.Context, filter dto.QuestionSearchFilter) ([]model.QuestionSearchResult, error) {
+	res := []model.QuestionSearchResult{}
+	sql := `
+		WITH tsq AS (
+			SELECT websearch_to_tsquery('russian', @query) AS q
+		)
+		SELECT
+			q.id,
+			q.number,
+			q.description,
+			q.game_id,
+			q.answer,
+			q.answer_text,
+			q.cost,
+			q.options,
+			q.round_id,
+			q.library_question_id,
+			q.forked,
+			COALESCE(g.description, '') AS game_description,
+			ts_rank(q.search, tsq.q) AS rank,
+			ts_headline('russian', COALESCE(q.description, ''), tsq.q, @headline_options) AS headline
+		FROM questions q
+		JOIN games g ON g.id = q.game_id
+		CROSS JOIN tsq
+		WHERE
+			q.search @@ tsq.q
+			AND q.deleted_at IS NULL
+			AND g.deleted_at IS NULL
+			AND g.org_id = @org_id
+			AND (@game_id = 0 OR q.game_id = @game_id)
+			AND (@member_id = 0 OR g.owner_id = @member_id OR EXISTS (
+				SELECT 1 FROM game_collaborators gc
+				WHERE gc.game_id = g.id AND gc.user_id = @member_id
+			))
+		ORDER BY rank DESC, q.id
+		LIMIT @limit
+	`
+	args := pgx.NamedArgs{
+		"query":            filter.Query,
+		"org_id":           filter.OrgId,
+		"game_id":          filter.GameId,
+		"member_id":        filter.MemberId,
+		"limit":            filter.Limit,
+		"headline_options": headlineOptions,
+	}
+
+	rows, err := s.db.Query(ctx, sql, args)
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db search questions error: %v", err)
+	}
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.QuestionSearchResult])
+	if err != nil {
+		return res, fmt.Errorf("db search questions error: %v", err)
+	}
+	for i := range res {
+		res[i].Headline = highlight(res[i].Headline)
+	}
+	return res, nil
+}
+
+// GameTags counts the games of the filter per tag, the most used tags first.
+func (s *storage) GameTags(ctx context.Context, filter dto.GameListFilter) ([]model.LabelCount, error) {
+	return s.gameLabels(ctx, filter, `
+		SELECT
+			t AS name,
+			COUNT(*) AS games
+		FROM games g
+		CROSS JOIN unnest(g.tags) t
+		WHERE `+gameListConditions+`
+		GROUP BY t
+		ORDER BY games DESC, name
+	`)
+}
+
+// GameCategories counts the games of the filter per category, ordered by name.
+func (s *storage) GameCategories(ctx context.Context, filter dto.GameListFilter) ([]model.LabelCount, error) {
+	return s.gameLabels(ctx, filter, `
+		SELECT
+			g.category AS name,
+			COUNT(*) AS games
+		FROM games g
+		WHERE `+gameListConditions+`
+			AND g.category <> ''
+		GROUP BY g.category
+		ORDER BY name
+	`)
+}
+
+func (s *storage) gameLabels(ctx context.Context, filter dto.GameListFilter, sql string) ([]model.LabelCount, error) {
+	res := []model.LabelCount{}
+	rows, err := s.db.Query(ctx, sql, gameListArgs(filter))
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db game labels error: %v", err)
+	}
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.LabelCount])
+	if err != nil {
+		return res, fmt.Errorf("db game labels error: %v", err)
+	}
+	return res, nil
+}
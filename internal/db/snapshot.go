@@ -0,0 +1,377 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"quizer_server/internal/model"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// snapshotGame returns the latest snapshot of the game when it still matches the game,
+// otherwise it saves the game with its rounds and questions as the next version. The game row stays
+// locked until the transaction ends, so concurrent starts do not create the same version twice.
+func snapshotGame(ctx context.Context, tx pgx.Tx, gameId int) (int, error) {
+	var description, link string
+	query := `
+		SELECT
+			COALESCE(description, ''),
+			COALESCE(link, '')
+		FROM games
+		WHERE id = @game_id
+		FOR UPDATE
+	`
+	err := tx.QueryRow(ctx, query, pgx.NamedArgs{"game_id": gameId}).Scan(&description, &link)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `
+		SELECT
+			id,
+			COALESCE(number, 0) AS number,
+			COALESCE(description, '') AS description,
+			game_id,
+			COALESCE(answer, 0) AS answer,
+			COALESCE(answer_text, '') AS answer_text,
+			COALESCE(cost, 0) AS cost,
+			options,
+			round_id,
+			library_question_id,
+			forked
+		FROM questions
+		WHERE
+			game_id = @game_id
+			AND deleted_at IS NULL
+		ORDER BY number, id
+	`
+	rows, err := tx.Query(ctx, query, pgx.NamedArgs{"game_id": gameId})
+	if err != nil {
+		return 0, err
+	}
+	questions, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Question])
+	if err != nil {
+		return 0, err
+	}
+	// forked copies no longer follow the library question and are not played as it,
+	// cleared here so the comparison with the latest snapshot sees what is stored
+	for i, q := range questions {
+		if q.Forked {
+			questions[i].LibraryQuestionId = nil
+		}
+	}
+	rounds, err := gameRounds(ctx, tx, gameId)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		latestId                      int
+		latestDescription, latestLink string
+	)
+	query = `
+		SELECT
+			id,
+			description,
+			link
+		FROM game_snapshots
+		WHERE game_id = @game_id
+		ORDER BY version DESC
+		LIMIT 1
+	`
+	err = tx.QueryRow(ctx, query, pgx.NamedArgs{"game_id": gameId}).Scan(&latestId, &latestDescription, &latestLink)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	if err == nil && latestDescription == description && latestLink == link {
+		latest, err := snapshotQuestions(ctx, tx, latestId)
+		if err != nil {
+			return 0, err
+		}
+		latestRounds, err := snapshotRounds(ctx, tx, latestId)
+		if err != nil {
+			return 0, err
+		}
+		if slices.EqualFunc(latest, questions, sameQuestion) && slices.Equal(latestRounds, rounds) {
+			return latestId, nil
+		}
+	}
+
+	var id int
+	query = `
+		INSERT INTO
+			game_snapshots (
+				game_id,
+				version,
+				description,
+				link
+			)
+		VALUES
+			(
+			@game_id,
+			COALESCE((SELECT MAX(version) FROM game_snapshots WHERE game_id = @game_id), 0) + 1,
+			@description,
+			@link
+		)
+		RETURNING
+			id
+	`
+	err = tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"game_id":     gameId,
+		"description": description,
+		"link":        link,
+	}).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := &pgx.Batch{}
+	for _, r := range rounds {
+		batch.Queue(`
+			INSERT INTO
+				snapshot_rounds (
+					snapshot_id,
+					round_id,
+					position,
+					title,
+					multiplier,
+					intermission_text,
+					intermission_slide
+				)
+			VALUES
+				(
+				@snapshot_id,
+				@round_id,
+				@position,
+				@title,
+				@multiplier,
+				@intermission_text,
+				@intermission_slide
+			)
+		`, pgx.NamedArgs{
+			"snapshot_id":        id,
+			"round_id":           r.Id,
+			"position":           r.Position,
+			"title":              r.Title,
+			"multiplier":         r.Multiplier,
+			"intermission_text":  r.IntermissionText,
+			"intermission_slide": r.IntermissionSlide,
+		})
+	}
+	for _, q := range questions {
+		batch.Queue(`
+			INSERT INTO
+				snapshot_questions (
+					snapshot_id,
+					question_id,
+					number,
+					description,
+					answer,
+					answer_text,
+					cost,
+					options,
+					round_id,
+					library_question_id
+				)
+			VALUES
+				(
+				@snapshot_id,
+				@question_id,
+				@number,
+				@description,
+				@answer,
+				@answer_text,
+				@cost,
+				COALESCE(@options::text[], '{}'),
+				@round_id,
+				@library_question_id
+			)
+		`, pgx.NamedArgs{
+			"snapshot_id":         id,
+			"question_id":         q.Id,
+			"number":              q.Number,
+			"description":         q.Description,
+			"answer":              q.AnswerNum,
+			"answer_text":         q.AnswerText,
+			"cost":                q.Cost,
+			"options":             q.Options,
+			"round_id":            q.RoundId,
+			"library_question_id": q.LibraryQuestionId,
+		})
+	}
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// sameQuestion compares the contents of two questions, the game is ignored.
+func sameQuestion(a, b model.Question) bool {
+	return a.Id == b.Id &&
+		a.Number == b.Number &&
+		a.Description == b.Description &&
+		a.AnswerNum == b.AnswerNum &&
+		a.AnswerText == b.AnswerText &&
+		a.Cost == b.Cost &&
+		slices.Equal(a.Options, b.Options) &&
+		sameId(a.RoundId, b.RoundId) &&
+		sameId(a.LibraryQuestionId, b.LibraryQuestionId)
+}
+
+// sameId compares two optional ids.
+func sameId(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// querier runs queries on the pool or within a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func snapshotQuestions(ctx context.Context, q querier, snapshotId int) ([]model.Question, error) {
+	query := `
+		SELECT
+			sq.question_id AS id,
+			sq.number,
+			sq.description,
+			gs.game_id,
+			sq.answer,
+			sq.answer_text,
+			sq.cost,
+			sq.options,
+			sq.round_id,
+			sq.library_question_id,
+			TRUE AS forked
+		FROM snapshot_questions sq
+		JOIN game_snapshots gs ON gs.id = sq.snapshot_id
+		WHERE
+			sq.snapshot_id = @snapshot_id
+		ORDER BY sq.number, sq.question_id
+	`
+	rows, err := q.Query(ctx, query, pgx.NamedArgs{"snapshot_id": snapshotId})
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.Question])
+}
+
+func snapshotRounds(ctx context.Context, q querier, snapshotId int) ([]model.Round, error) {
+	query := `
+		SELECT
+			sr.round_id AS id,
+			gs.game_id,
+			sr.position,
+			sr.title,
+			sr.multiplier,
+			sr.intermission_text,
+			sr.intermission_slide
+		FROM snapshot_rounds sr
+		JOIN game_snapshots gs ON gs.id = sr.snapshot_id
+		WHERE
+			sr.snapshot_id = @snapshot_id
+		ORDER BY sr.position, sr.round_id
+	`
+	rows, err := q.Query(ctx, query, pgx.NamedArgs{"snapshot_id": snapshotId})
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.Round])
+}
+
+// SnapshotRounds returns the rounds of the snapshot in their order.
+func (s *storage) SnapshotRounds(ctx context.Context, snapshotId int) ([]model.Round, error) {
+	res, err := snapshotRounds(ctx, s.db, snapshotId)
+	if err != nil {
+		return res, fmt.Errorf("db snapshot rounds error: %v", err)
+	}
+	return res, nil
+}
+
+// GameSnapshots lists the versions of the game, the newest first.
+func (s *storage) GameSnapshots(ctx context.Context, gameId int) ([]model.GameSnapshot, error) {
+	res := []model.GameSnapshot{}
+	query := `
+		SELECT
+			gs.id,
+			gs.game_id,
+			gs.version,
+			gs.description,
+			gs.link,
+			(SELECT COUNT(*) FROM snapshot_questions sq WHERE sq.snapshot_id = gs.id) AS questions,
+			gs.created_at
+		FROM game_snapshots gs
+		WHERE
+			gs.game_id = @game_id
+		ORDER BY gs.version DESC
+	`
+	rows, err := s.db.Query(ctx, query, pgx.NamedArgs{"game_id": gameId})
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db game snapshots error: %v", err)
+	}
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.GameSnapshot])
+	if err != nil {
+		return res, fmt.Errorf("db game snapshots error: %v", err)
+	}
+	return res, nil
+}
+
+// SnapshotQuestions returns the questions of the snapshot ordered by number.
+func (s *storage) SnapshotQuestions(ctx context.Context, snapshotId int) ([]model.Question, error) {
+	res, err := snapshotQuestions(ctx, s.db, snapshotId)
+	if err != nil {
+		return res, fmt.Errorf("db snapshot questions error: %v", err)
+	}
+	return res, nil
+}
+
+// SnapshotQuestionByNumber loads the question with the number from the snapshot.
+func (s *storage) SnapshotQuestionByNumber(ctx context.Context, snapshotId int, number int) (model.Question, error) {
+	var res model.Question
+	query := `
+		SELECT
+			sq.question_id AS id,
+			sq.number,
+			sq.description,
+			gs.game_id,
+			sq.answer,
+			sq.answer_text,
+			sq.cost,
+			sq.options,
+			sq.round_id,
+			sq.library_question_id,
+			TRUE AS forked
+		FROM snapshot_questions sq
+		JOIN game_snapshots gs ON gs.id = sq.snapshot_id
+		WHERE
+			sq.snapshot_id = @snapshot_id
+			AND
+			sq.number = @number
+		ORDER BY sq.question_id
+		LIMIT 1
+	`
+	args := pgx.NamedArgs{
+		"snapshot_id": snapshotId,
+		"number":      number,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Question])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *storage) CreateRefreshToken(ctx context.Context, data model.RefreshToken) (int, error) {
+	var id int
+	query := `
+		INSERT INTO
+			refresh_tokens (
+				token_hash,
+				user_id,
+				family_id,
+				expires_at
+			)
+		VALUES
+			(
+			@token_hash,
+			@user_id,
+			@family_id,
+			@expires_at
+		)
+		RETURNING
+			id
+	`
+	args := pgx.NamedArgs{
+		"token_hash": data.TokenHash,
+		"user_id":    data.UserId,
+		"family_id":  data.FamilyId,
+		"expires_at": data.ExpiresAt,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&id)
+	if err != nil {
+		return id, fmt.Errorf("db create refresh token error: %v", err)
+	}
+	return id, nil
+}
+
+func (s *storage) RefreshTokenByHash(ctx context.Context, hash string) (model.RefreshToken, error) {
+	var res model.RefreshToken
+	query := `
+		SELECT
+			id,
+			token_hash,
+			user_id,
+			family_id,
+			expires_at,
+			created_at,
+			revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = @token_hash
+	`
+	args := pgx.NamedArgs{
+		"token_hash": hash,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.RefreshToken])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// RevokeRefreshToken marks the token as revoked. It returns false if the token
+// had already been revoked, which lets the caller detect concurrent reuse.
+func (s *storage) RevokeRefreshToken(ctx context.Context, id int) (bool, error) {
+	query := `
+		UPDATE
+			refresh_tokens
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			id = @id
+			AND revoked_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+	tag, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return false, fmt.Errorf("db revoke refresh token error: %v", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *storage) RevokeRefreshTokenFamily(ctx context.Context, familyId uuid.UUID) error {
+	query := `
+		UPDATE
+			refresh_tokens
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			family_id = @family_id
+			AND revoked_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"family_id": familyId,
+	}
+	_, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db revoke refresh token family error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"quizer_server/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TrashedGameLoad loads the game from the trash of the organization orgId.
+func (s *storage) TrashedGameLoad(ctx context.Context, orgId int, id int) (model.Game, error) {
+	var res model.Game
+	query := `
+		SELECT
+			g.id,
+			description,
+			g.owner_id,
+			g.org_id,
+			login,
+			g.created_at,
+			link,
+			g.status,
+			g.category,
+			g.tags,
+			g.deleted_at
+		FROM games g
+		JOIN users u on u.id = g.owner_id
+		WHERE g.id = @id AND g.org_id = @org_id
+			AND g.deleted_at IS NOT NULL
+	`
+	args := pgx.NamedArgs{
+		"id":     id,
+		"org_id": orgId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+	if err != nil {
+		return res, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Game])
+}
+
+// RestoreGame takes the game out of the trash.
+func (s *storage) RestoreGame(ctx context.Context, id int) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			games
+		SET
+			deleted_at = NULL
+		WHERE
+			id = @id
+			AND deleted_at IS NOT NULL
+		RETURNING id
+	`
+	err := s.db.QueryRow(ctx, query, pgx.NamedArgs{"id": id}).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db restore game error: %w", err)
+	}
+	return res, nil
+}
+
+// DeletedQuestions lists the questions of the game in the trash, the latest deleted first.
+func (s *storage) DeletedQuestions(ctx context.Context, gameId int) ([]model.DeletedQuestion, error) {
+	res := []model.DeletedQuestion{}
+	query := `
+		SELECT
+			id,
+			number,
+			description,
+			game_id,
+			answer,
+			answer_text,
+			cost,
+			options,
+			round_id,
+			library_question_id,
+			forked,
+			deleted_at
+		FROM questions
+		WHERE
+			game_id = @game_id
+			AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+	`
+	rows, err := s.db.Query(ctx, query, pgx.NamedArgs{"game_id": gameId})
+	defer rows.Close()
+	if err != nil {
+		return res, fmt.Errorf("db deleted questions error: %v", err)
+	}
+	res, err = pgx.CollectRows(rows, pgx.RowToStructByName[model.DeletedQuestion])
+	if err != nil {
+		return res, fmt.Errorf("db deleted questions error: %v", err)
+	}
+	return res, nil
+}
+
+// DeletedQuestionLoad loads the question from the trash. The game of the question must not be
+// in the trash itself and must belong to the organization orgId.
+func (s *storage) DeletedQuestionLoad(ctx context.Context, orgId int, id int) (model.Question, error) {
+	var res model.Question
+	query := `
+		SELECT
+			q.id,
+			q.number,
+			q.description,
+			q.game_id,
+			q.answer,
+			q.answer_text,
+			q.cost,
+			q.options,
+			q.round_id,
+			q.library_question_id,
+			q.forked
+		FROM questions q
+		JOIN games g on g.id = q.game_id
+		WHERE
+			q.id = @id AND g.org_id = @org_id
+			AND q.deleted_at IS NOT NULL
+			AND g.deleted_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"id":     id,
+		"org_id": orgId,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+	if err != nil {
+		return res, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.Question])
+}
+
+// RestoreQuestion takes the question out of the trash.
+func (s *storage) RestoreQuestion(ctx context.Context, id int) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			questions
+		SET
+			deleted_at = NULL
+		WHERE
+			id = @id
+			AND deleted_at IS NOT NULL
+		RETURNING id
+	`
+	err := s.db.QueryRow(ctx, query, pgx.NamedArgs{"id": id}).Scan(&res)
+	if err != nil {
+		return res, fmt.Errorf("db restore question error: %w", err)
+	}
+	return res, nil
+}
+
+// PurgeDeletedGames removes the games deleted before the time together with their questions,
+// snapshots, lobbies and everything the players of those lobbies saved. It returns the ids of the
+// removed games and the presentation files that no remaining game refers to, for the caller to delete.
+func (s *storage) PurgeDeletedGames(ctx context.Context, before time.Time) ([]int, []string, error) {
+	ids := []int{}
+	links := []string{}
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		query := `
+			SELECT
+				id
+			FROM games
+			WHERE
+				deleted_at < @before
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+		`
+		rows, err := tx.Query(ctx, query, pgx.NamedArgs{"before": before})
+		if err != nil {
+			return err
+		}
+		ids, err = pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		args := pgx.NamedArgs{"ids": ids}
+		batch := &pgx.Batch{}
+		for _, query := range []string{
+			`DELETE FROM player_results WHERE lobby_uuid IN (SELECT uuid FROM lobbies WHERE game_id = ANY(@ids::int[]))`,
+			`DELETE FROM player_answers WHERE lobby_uuid IN (SELECT uuid FROM lobbies WHERE game_id = ANY(@ids::int[]))`,
+			`DELETE FROM players WHERE lobby_id IN (SELECT uuid FROM lobbies WHERE game_id = ANY(@ids::int[]))`,
+			`DELETE FROM lobbies WHERE game_id = ANY(@ids::int[])`,
+			`DELETE FROM game_snapshots WHERE game_id = ANY(@ids::int[])`,
+			`DELETE FROM questions WHERE game_id = ANY(@ids::int[])`,
+		} {
+			batch.Queue(query, args)
+		}
+		err = tx.SendBatch(ctx, batch).Close()
+		if err != nil {
+			return err
+		}
+
+		// a presentation file is only handed out when no other game refers to it
+		query = `
+			WITH purged AS (
+				DELETE FROM games
+				WHERE id = ANY(@ids::int[])
+				RETURNING link
+			)
+			SELECT DISTINCT
+				p.link
+			FROM purged p
+			WHERE
+				COALESCE(p.link, '') <> ''
+				AND NOT EXISTS (SELECT 1 FROM games g WHERE g.link = p.link AND NOT (g.id = ANY(@ids::int[])))
+		`
+		rows, err = tx.Query(ctx, query, args)
+		if err != nil {
+			return err
+		}
+		links, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("db purge deleted games error: %v", err)
+	}
+	return ids, links, nil
+}
+
+// PurgeDeletedQuestions removes the questions deleted before the time. Snapshots keep
+// their own copies, so lobbies that played the questions are not affected.
+func (s *storage) PurgeDeletedQuestions(ctx context.Context, before time.Time) (int, error) {
+	query := `
+		DELETE FROM
+			questions
+		WHERE
+			deleted_at < @before
+	`
+	tag, err := s.db.Exec(ctx, query, pgx.NamedArgs{"before": before})
+	if err != nil {
+		return 0, fmt.Errorf("db purge deleted questions error: %v", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
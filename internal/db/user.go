@@ -2,18 +2,68 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"quizer_server/internal/dto"
 	"quizer_server/internal/model"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// IsUniqueViolation reports whether err was caused by a unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (s *storage) CreateUser(ctx context.Context, data dto.CreateUser) (int, error) {
+	var id int
+	query := `
+		INSERT INTO
+			users (
+				login,
+				password,
+				display_name,
+				email,
+				roles
+			)
+		VALUES
+			(
+			@login,
+			@password,
+			@display_name,
+			@email,
+			@roles
+		)
+		RETURNING
+			id
+	`
+	args := pgx.NamedArgs{
+		"login":        data.Login,
+		"password":     data.Password,
+		"display_name": data.DisplayName,
+		"email":        data.Email,
+		"roles":        data.Roles,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&id)
+	if err != nil {
+		return id, fmt.Errorf("db create user error: %w", err)
+	}
+	return id, nil
+}
+
 func (s *storage) UserByLogin(ctx context.Context, login string) (model.User, error) {
 	var res model.User
 	query := `
 		SELECT
 			id,
 			login,
-			password
+			password,
+			display_name,
+			email,
+			roles,
+			created_at
 		FROM
 			users
 		WHERE
@@ -37,3 +87,143 @@ func (s *storage) UserByLogin(ctx context.Context, login string) (model.User, er
 
 	return res, nil
 }
+
+func (s *storage) UserById(ctx context.Context, id int) (model.User, error) {
+	var res model.User
+	query := `
+		SELECT
+			id,
+			login,
+			password,
+			display_name,
+			email,
+			roles,
+			created_at
+		FROM
+			users
+		WHERE
+			id = @id
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+	rows, err := s.db.Query(ctx, query, args)
+	defer rows.Close()
+
+	if err != nil {
+		return res, err
+	}
+
+	res, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[model.User])
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *storage) UpdatePassword(ctx context.Context, id int, hash string) error {
+	query := `
+		UPDATE
+			users
+		SET
+			password = @password
+		WHERE
+			id = @id
+	`
+	args := pgx.NamedArgs{
+		"id":       id,
+		"password": hash,
+	}
+	_, err := s.db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("db update password error: %v", err)
+	}
+	return nil
+}
+
+func (s *storage) UpdateProfile(ctx context.Context, id int, data dto.UpdateProfileRequest) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			users
+		SET
+			display_name = @display_name,
+			email = @email
+		WHERE
+			id = @id
+		RETURNING id
+	`
+	args := pgx.NamedArgs{
+		"id":           id,
+		"display_name": data.DisplayName,
+		"email":        data.Email,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *storage) UpdateRoles(ctx context.Context, id int, roles []string) (int, error) {
+	res := 0
+	query := `
+		UPDATE
+			users
+		SET
+			roles = @roles
+		WHERE
+			id = @id
+		RETURNING id
+	`
+	args := pgx.NamedArgs{
+		"id":    id,
+		"roles": roles,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *storage) DeleteUser(ctx context.Context, id int) (int, error) {
+	res := 0
+	query := `
+		DELETE FROM
+			users
+		WHERE
+			id = @id
+		RETURNING id
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *storage) GamesCountByOwner(ctx context.Context, ownerId int) (int, error) {
+	res := 0
+	query := `
+		SELECT
+			COUNT(*)
+		FROM
+			games
+		WHERE
+			owner_id = @owner_id
+	`
+	args := pgx.NamedArgs{
+		"owner_id": ownerId,
+	}
+	err := s.db.QueryRow(ctx, query, args).Scan(&res)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
@@ -1,21 +1,191 @@
 package dto
 
+import "time"
+
 type CreateNewGame struct {
 	OwnerId     int
+	OrgId       int
 	Description string
 	Link        string
+	Category    string
+	Tags        []string
+}
+
+// GameListFilter narrows the game list to the organization OrgId. Zero values disable other conditions:
+// OwnerId keeps games owned by the user, SharedWith keeps games shared with the user
+// and MemberId keeps games either owned by or shared with the user. Statuses keeps games in any of the statuses,
+// Category keeps games of the category and Tags keeps games having all of the tags.
+// Deleted lists the games in the trash instead of the live ones.
+type GameListFilter struct {
+	OrgId      int
+	OwnerId    int
+	SharedWith int
+	MemberId   int
+	Statuses   []string
+	Category   string
+	Tags       []string
+	Deleted    bool
+}
+
+// QuestionSearchFilter is a full-text search over the questions of games visible to the user
+// MemberId, or of all games of the organization when it is zero. GameId limits the search to one game.
+type QuestionSearchFilter struct {
+	OrgId    int
+	MemberId int
+	GameId   int
+	Query    string
+	Limit    int
+}
+
+// SetGameLabelsRequest replaces the category and the tags of a game.
+type SetGameLabelsRequest struct {
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+}
+
+type SetGameStatusRequest struct {
+	Status string `json:"status"`
+}
+
+type AddCollaboratorRequest struct {
+	Login      string `json:"login"`
+	Permission string `json:"permission"`
 }
 
 type CreateNewGameRequest struct {
+	Description string   `json:"description"`
+	Link        string   `json:"link"`
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags"`
+}
+
+// CloneGameRequest names the copy of a game, an empty Description keeps the original one with a suffix.
+type CloneGameRequest struct {
 	Description string `json:"description"`
-	Link        string `json:"link"`
 }
 
 type CreateNewQuestionRequest struct {
-	GameId      int    `json:"game_id" db:"game_id"`
-	Number      int    `json:"number" db:"number"`
-	Cost        int    `json:"cost" db:"cost"`
-	AnswerNum   int    `json:"answer" db:"answer"`
-	AnswerText  string `json:"answer_text" db:"answer_text"`
-	Description string `json:"description" db:"description"`
+	GameId      int      `json:"game_id" db:"game_id"`
+	Number      int      `json:"number" db:"number"`
+	Cost        int      `json:"cost" db:"cost"`
+	AnswerNum   int      `json:"answer" db:"answer"`
+	AnswerText  string   `json:"answer_text" db:"answer_text"`
+	Description string   `json:"description" db:"description"`
+	Options     []string `json:"options" db:"options"`
+	RoundId     *int     `json:"round_id" db:"round_id"`
+}
+
+// LibraryQuestionRequest creates or replaces a library question. An empty Visibility keeps the question private.
+type LibraryQuestionRequest struct {
+	Visibility  string   `json:"visibility"`
+	Description string   `json:"description"`
+	AnswerNum   int      `json:"answer"`
+	AnswerText  string   `json:"answer_text"`
+	Cost        int      `json:"cost"`
+	Options     []string `json:"options"`
+}
+
+// LibraryFilter lists the library questions of the organization OrgId visible to the user UserId,
+// all of them when UserId is zero. Mine keeps the user's own questions, Query runs a full-text search.
+type LibraryFilter struct {
+	OrgId  int
+	UserId int
+	Mine   bool
+	Query  string
+	Limit  int
+}
+
+// AddLibraryQuestionRequest puts a library question into a game. A zero Number appends the question.
+type AddLibraryQuestionRequest struct {
+	LibraryQuestionId int  `json:"library_question_id"`
+	Number            int  `json:"number"`
+	RoundId           *int `json:"round_id"`
+}
+
+// RoundRequest creates or replaces a round. A zero Position puts a new round after the others,
+// a zero Multiplier counts the questions at their cost.
+type RoundRequest struct {
+	Position          int     `json:"position"`
+	Title             string  `json:"title"`
+	Multiplier        float64 `json:"multiplier"`
+	IntermissionText  string  `json:"intermission_text"`
+	IntermissionSlide int     `json:"intermission_slide"`
+}
+
+type CreateUser struct {
+	Login       string
+	Password    string
+	DisplayName string
+	Email       string
+	Roles       []string
+}
+
+type LoginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type CreateUserRequest struct {
+	Login       string `json:"login"`
+	Password    string `json:"password"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+}
+
+type UpdateProfileRequest struct {
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type DeleteUserRequest struct {
+	Password string `json:"password"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type UpdateRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+type CreateApiKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type AuditFilter struct {
+	ActorId    int
+	TargetType string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type AddMemberRequest struct {
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// ExternalIdentity is a user authenticated by an external identity provider.
+type ExternalIdentity struct {
+	Issuer            string
+	Subject           string
+	PreferredUsername string
+	Email             string
+	Name              string
+}
+
+type ClaimPlayerRequest struct {
+	PlayerToken string `json:"player_token"`
 }
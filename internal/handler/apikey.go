@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/service/apikey"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) CreateApiKey(c *gin.Context) {
+	req := dto.CreateApiKeyRequest{}
+	err := c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	key, plain, err := h.apiKeySvc.Create(c.Request.Context(), actorFromContext(c), req)
+	if err != nil {
+		if errors.Is(err, apikey.ErrValidation) {
+			sendError(c, http.StatusBadRequest, err)
+			return
+		}
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, gin.H{
+		"api_key": key,
+		"key":     plain,
+	})
+}
+
+func (h *handler) ApiKeyList(c *gin.Context) {
+	res, err := h.apiKeySvc.List(c.Request.Context(), actorFromContext(c))
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+func (h *handler) RevokeApiKey(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "api key id is required")
+		return
+	}
+
+	id, err = h.apiKeySvc.Revoke(c.Request.Context(), actorFromContext(c), id)
+	if err != nil {
+		sendServiceError(c, err, "api key not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
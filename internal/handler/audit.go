@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"quizer_server/internal/dto"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditList returns a page of the audit log.
+// Supported query params: actor_id, target_type, from, to (RFC3339), limit, offset.
+func (h *handler) AuditList(c *gin.Context) {
+	filter := dto.AuditFilter{
+		TargetType: c.Query("target_type"),
+	}
+
+	ints := map[string]*int{
+		"actor_id": &filter.ActorId,
+		"limit":    &filter.Limit,
+		"offset":   &filter.Offset,
+	}
+	for name, dst := range ints {
+		val := c.Query(name)
+		if val == "" {
+			continue
+		}
+		_, err := fmt.Sscanf(val, "%d", dst)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "incorrect "+name)
+			return
+		}
+	}
+
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, dst := range times {
+		val := c.Query(name)
+		if val == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "incorrect "+name+", RFC3339 expected")
+			return
+		}
+		*dst = &t
+	}
+
+	page, err := h.auditSvc.List(c.Request.Context(), filter)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, page)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/service/game"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) CollaboratorList(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	gameId := 0
+	_, err := fmt.Sscanf(idStr, "%d", &gameId)
+	if err != nil || gameId == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect game_id")
+		return
+	}
+
+	res, err := h.gameSvc.Collaborators(c.Request.Context(), actorFromContext(c), gameId)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+func (h *handler) AddCollaborator(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	gameId := 0
+	_, err := fmt.Sscanf(idStr, "%d", &gameId)
+	if err != nil || gameId == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect game_id")
+		return
+	}
+
+	req := dto.AddCollaboratorRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	err = h.gameSvc.AddCollaborator(c.Request.Context(), actorFromContext(c), gameId, req)
+	if err != nil {
+		if errors.Is(err, game.ErrValidation) {
+			sendError(c, http.StatusBadRequest, err)
+			return
+		}
+		sendServiceError(c, err, "game or user not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, "collaborator saved")
+}
+
+func (h *handler) RemoveCollaborator(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	gameId := 0
+	_, err := fmt.Sscanf(idStr, "%d", &gameId)
+	if err != nil || gameId == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect game_id")
+		return
+	}
+
+	userIdStr := c.Params.ByName("user_id")
+	userId := 0
+	_, err = fmt.Sscanf(userIdStr, "%d", &userId)
+	if err != nil || userId == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect user_id")
+		return
+	}
+
+	id, err := h.gameSvc.RemoveCollaborator(c.Request.Context(), actorFromContext(c), gameId, userId)
+	if err != nil {
+		sendServiceError(c, err, "collaborator not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"quizer_server/internal/dto"
 	"quizer_server/internal/model"
+	"quizer_server/internal/service/game"
+	"quizer_server/internal/service/lobby"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -20,18 +24,35 @@ func (h *handler) CreateLobby(c *gin.Context) {
 		log.Println("create lobby bind json err:", err)
 		return
 	}
-	count, err := h.lobbySvc.Create(c.Request.Context(), req)
+	if req.UUID == uuid.Nil {
+		req.UUID = uuid.New()
+	}
+	count, err := h.lobbySvc.Create(c.Request.Context(), actorFromContext(c), req)
 	if err != nil {
 		log.Println("handler create new lobby err:", err)
+		if errors.Is(err, lobby.ErrGameNotPublished) {
+			sendError(c, http.StatusConflict, "only published games can be played")
+			return
+		}
+		sendServiceError(c, err, "game not found")
+		return
+	}
+	hostToken, err := h.jwtSvc.CreateLobbyToken(model.LobbyTokenHost, req.UUID, req.UUID)
+	if err != nil {
+		log.Println("handler create host token err:", err)
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
 	}
 	sendSuccess(c, http.StatusOK, gin.H{
 		"success":         true,
+		"uuid":            req.UUID,
 		"questions_count": count,
+		"host_token":      hostToken,
 	})
 }
 
 func (h *handler) LobbyList(c *gin.Context) {
-	res, err := h.lobbySvc.List(c.Request.Context())
+	res, err := h.lobbySvc.List(c.Request.Context(), actorFromContext(c))
 	if err != nil {
 		log.Println("handler lobby list err:", err)
 	}
@@ -46,15 +67,23 @@ func (h *handler) CreateGame(c *gin.Context) {
 		return
 	}
 
+	actor := actorFromContext(c)
 	data := dto.CreateNewGame{
-		OwnerId:     h.jwtSvc.IDFromToken(c.Value("access_token").(string)),
+		OwnerId:     actor.Id,
+		OrgId:       actor.OrgId,
 		Description: req.Description,
 		Link:        req.Link,
+		Category:    req.Category,
+		Tags:        req.Tags,
 	}
 
-	id, err := h.gameSvc.CreateNewGame(c.Request.Context(), data)
+	id, err := h.gameSvc.CreateNewGame(c.Request.Context(), actor, data)
 	if err != nil {
-		sendError(c, http.StatusInternalServerError, "internal err")
+		if errors.Is(err, game.ErrValidation) {
+			sendError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		sendServiceError(c, err, "organization not found")
 		return
 	}
 
@@ -65,8 +94,68 @@ func (h *handler) CreateGame(c *gin.Context) {
 	sendSuccess(c, http.StatusOK, resp)
 }
 
+// gameListFilter builds the filter of the game list from the query params.
+// By default users see the games they own or that were shared with them,
+// admins see every game of the organization. ?mine=true and ?shared=true narrow the list.
+// Authors see drafts and published games, hosts only published ones; ?status=draft,archived
+// or ?status=all picks the statuses explicitly. ?category=quiz and ?tags=kino,music keep
+// games of the category having all of the tags.
+func gameListFilter(c *gin.Context) dto.GameListFilter {
+	actor := actorFromContext(c)
+	filter := dto.GameListFilter{
+		OrgId:    actor.OrgId,
+		Category: strings.TrimSpace(c.Query("category")),
+	}
+	switch status := c.Query("status"); {
+	case status == "all":
+	case status != "":
+		filter.Statuses = strings.Split(status, ",")
+	case actor.HasRole(model.RoleAuthor):
+		filter.Statuses = []string{model.GameStatusDraft, model.GameStatusPublished}
+	default:
+		filter.Statuses = []string{model.GameStatusPublished}
+	}
+	switch {
+	case c.Query("mine") == "true":
+		filter.OwnerId = actor.Id
+	case c.Query("shared") == "true":
+		filter.SharedWith = actor.Id
+	case !actor.IsAdmin():
+		filter.MemberId = actor.Id
+	}
+	for _, tag := range strings.Split(c.Query("tags"), ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+	return filter
+}
+
+// GameList lists the games of gameListFilter. With ?q= it runs a full-text search instead
+// and returns the best matches first with a highlighted headline, ?limit= caps the results.
 func (h *handler) GameList(c *gin.Context) {
-	list, err := h.gameSvc.GameList(c.Request.Context())
+	filter := gameListFilter(c)
+
+	if query := c.Query("q"); query != "" {
+		limit := 0
+		if val := c.Query("limit"); val != "" {
+			_, err := fmt.Sscanf(val, "%d", &limit)
+			if err != nil {
+				sendError(c, http.StatusBadRequest, "incorrect limit")
+				return
+			}
+		}
+		res, err := h.gameSvc.Search(c.Request.Context(), filter, query, limit)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, "internal err")
+			return
+		}
+		sendSuccess(c, http.StatusOK, res)
+		return
+	}
+
+	list, err := h.gameSvc.GameList(c.Request.Context(), filter)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -81,6 +170,60 @@ func (h *handler) GameList(c *gin.Context) {
 	sendSuccess(c, http.StatusOK, list)
 }
 
+// GameTags counts the games of gameListFilter per tag.
+func (h *handler) GameTags(c *gin.Context) {
+	res, err := h.gameSvc.Tags(c.Request.Context(), gameListFilter(c))
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+	sendSuccess(c, http.StatusOK, res)
+}
+
+// GameCategories counts the games of gameListFilter per category.
+func (h *handler) GameCategories(c *gin.Context) {
+	res, err := h.gameSvc.Categories(c.Request.Context(), gameListFilter(c))
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+	sendSuccess(c, http.StatusOK, res)
+}
+
+// SetGameLabels replaces the category and the tags of the game.
+func (h *handler) SetGameLabels(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	req := dto.SetGameLabelsRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	err = h.gameSvc.SetLabels(c.Request.Context(), actorFromContext(c), id, req)
+	switch {
+	case errors.Is(err, game.ErrValidation):
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
 func (h *handler) GameLoad(c *gin.Context) {
 	idStr := c.Params.ByName("id")
 	id := 0
@@ -91,16 +234,11 @@ func (h *handler) GameLoad(c *gin.Context) {
 		return
 	}
 
-	res, err := h.gameSvc.GameLoad(c.Request.Context(), id)
+	res, err := h.gameSvc.GameLoad(c.Request.Context(), actorFromContext(c), id)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			sendError(c, http.StatusNotFound, "game not found")
-			return
-		}
-		sendError(c, http.StatusInternalServerError, "internal err")
+		sendServiceError(c, err, "game not found")
 		return
-
 	}
 
 	sendSuccess(c, http.StatusOK, res)
@@ -119,9 +257,11 @@ func (h *handler) UpdateGame(c *gin.Context) {
 		return
 	}
 
-	id, err = h.gameSvc.UpdateGame(c.Request.Context(), req)
+	req.Id = id
+
+	id, err = h.gameSvc.UpdateGame(c.Request.Context(), actorFromContext(c), req)
 	if err != nil || id == 0 {
-		sendError(c, http.StatusInternalServerError, "internal err")
+		sendServiceError(c, err, "game not found")
 		return
 	}
 
@@ -142,11 +282,77 @@ func (h *handler) DeleteGame(c *gin.Context) {
 		return
 	}
 
-	id, err = h.gameSvc.DeleteGame(c.Request.Context(), id)
+	id, err = h.gameSvc.DeleteGame(c.Request.Context(), actorFromContext(c), id)
 	if err != nil || id == 0 {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// GameTrash lists the deleted games of the caller, admins see the whole organization.
+func (h *handler) GameTrash(c *gin.Context) {
+	res, err := h.gameSvc.Trash(c.Request.Context(), actorFromContext(c))
+	if err != nil {
 		sendError(c, http.StatusInternalServerError, "internal err")
 		return
 	}
+	sendSuccess(c, http.StatusOK, res)
+}
+
+// RestoreGame takes the game out of the trash.
+func (h *handler) RestoreGame(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	id, err = h.gameSvc.RestoreGame(c.Request.Context(), actorFromContext(c), id)
+	if err != nil || id == 0 {
+		sendServiceError(c, err, "game not found in the trash")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// CloneGame copies the game with its questions and presentation to a new game of the caller.
+// The body is optional, {"description": "..."} renames the copy.
+func (h *handler) CloneGame(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	req := dto.CloneGameRequest{}
+	if c.Request.ContentLength != 0 {
+		err = c.BindJSON(&req)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "body req err")
+			return
+		}
+	}
+
+	id, err = h.gameSvc.Clone(c.Request.Context(), actorFromContext(c), id, req)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
 
 	resp := map[string]any{
 		"id": id,
@@ -155,6 +361,67 @@ func (h *handler) DeleteGame(c *gin.Context) {
 	sendSuccess(c, http.StatusOK, resp)
 }
 
+// GameSnapshots lists the versions of the game pinned to lobbies when they started.
+func (h *handler) GameSnapshots(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	res, err := h.gameSvc.Snapshots(c.Request.Context(), actorFromContext(c), id)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+// SetGameStatus moves the game to the status in the body. Publishing responds with 422
+// and the list of problems when the game does not pass validation.
+func (h *handler) SetGameStatus(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	req := dto.SetGameStatusRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	issues, err := h.gameSvc.SetStatus(c.Request.Context(), actorFromContext(c), id, req.Status)
+	switch {
+	case errors.Is(err, game.ErrNotPublishable):
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"message": issues,
+		})
+		return
+	case errors.Is(err, game.ErrValidation):
+		sendError(c, http.StatusBadRequest, "status must be draft, published or archived")
+		return
+	case err != nil:
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id":     id,
+		"status": req.Status,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
 func (h *handler) GetTextAnswers(c *gin.Context) {
 	idUUID := c.Params.ByName("uuid")
 	lobbyUUID, err := uuid.Parse(idUUID)
@@ -1,16 +1,26 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"quizer_server/internal/app/services"
 	"quizer_server/internal/config"
 	"quizer_server/internal/middleware"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/apikey"
+	"quizer_server/internal/service/audit"
 	"quizer_server/internal/service/game"
 	"quizer_server/internal/service/jwt"
+	"quizer_server/internal/service/library"
 	"quizer_server/internal/service/lobby"
+	"quizer_server/internal/service/oidc"
+	"quizer_server/internal/service/organization"
+	"quizer_server/internal/service/player"
+	"quizer_server/internal/service/publish"
 	"quizer_server/internal/service/question"
+	"quizer_server/internal/service/throttle"
 	"quizer_server/internal/service/user"
 	"strings"
 	"sync"
@@ -19,6 +29,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
 )
 
 type Handler interface {
@@ -29,8 +40,16 @@ type PlayerData struct {
 	Connection    *websocket.Conn
 	UserName      string
 	IsAdmin       bool
+	UserId        int
 	GameId        int
 	QuestionCount int
+	// Rounds, QuestionRounds (question number to round id) and the current RoundId
+	// are kept on the lobby entry to announce the start and the end of rounds.
+	Rounds         []model.Round
+	QuestionRounds map[int]int
+	RoundId        int
+	// QuestionOrder is the order of a lobby shuffling questions, see model.QuestionNumber.
+	QuestionOrder []int
 }
 
 type GameSessions struct {
@@ -44,7 +63,14 @@ type handler struct {
 	gameSvc     game.Service
 	lobbySvc    lobby.Service
 	questionSvc question.Service
+	librarySvc  library.Service
 	jwtSvc      jwt.Service
+	throttleSvc throttle.Service
+	apiKeySvc   apikey.Service
+	auditSvc    audit.Service
+	orgSvc      organization.Service
+	oidcSvc     oidc.Service
+	playerSvc   player.Service
 	userAuth    middleware.UserAuthenticator
 	updater     websocket.Upgrader
 	sessions    GameSessions
@@ -55,10 +81,17 @@ func New(r *gin.Engine, s services.Services) Handler {
 		router:      r,
 		userSvc:     s.UserSvc,
 		jwtSvc:      s.JwtSvc,
+		throttleSvc: s.ThrottleSvc,
+		apiKeySvc:   s.ApiKeySvc,
+		auditSvc:    s.AuditSvc,
+		orgSvc:      s.OrgSvc,
+		oidcSvc:     s.OidcSvc,
+		playerSvc:   s.PlayerSvc,
 		userAuth:    s.UserAuth,
 		gameSvc:     s.GameSvc,
 		lobbySvc:    s.LobbySvc,
 		questionSvc: s.QuestionSvc,
+		librarySvc:  s.LibrarySvc,
 		updater: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
@@ -79,7 +112,7 @@ func (h *handler) Register() {
 
 	configCORS := cors.DefaultConfig()
 	configCORS.AllowOrigins = cfg.CORS.AllowedOrigins
-	configCORS.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	configCORS.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Organization-Id"}
 	configCORS.AllowCredentials = true
 
 	log.Printf("CORS CONFIG: %+v\n", configCORS)
@@ -87,30 +120,103 @@ func (h *handler) Register() {
 	h.router.Use(cors.New(configCORS))
 
 	protected := h.router.Group("/", h.userAuth.Authorization())
+	sessions := protected.Group("/", h.userAuth.RequireSession())
+	viewers := protected.Group("/",
+		h.userAuth.RequireRoles(model.RoleAuthor, model.RoleHost),
+		h.userAuth.RequireScope(model.ScopeGamesRead))
+	authors := protected.Group("/",
+		h.userAuth.RequireRoles(model.RoleAuthor),
+		h.userAuth.RequireScope(model.ScopeGamesWrite))
+	hosts := protected.Group("/",
+		h.userAuth.RequireRoles(model.RoleHost),
+		h.userAuth.RequireScope(model.ScopeLobbiesHost))
+	admins := sessions.Group("/", h.userAuth.RequireRoles(model.RoleAdmin))
 
 	h.router.GET("/login", h.Login)
+	h.router.POST("/auth/login", h.LoginJSON)
+	h.router.POST("/users", h.RegisterUser)
+	h.router.POST("/auth/refresh", h.RefreshToken)
+	h.router.POST("/auth/logout", h.Logout)
+	h.router.GET("/auth/oidc/login", h.OidcLogin)
+	h.router.GET("/auth/oidc/callback", h.OidcCallback)
 	h.router.GET("/ws", h.wsHandler)
+	h.router.GET("/.well-known/jwks.json", h.JWKS)
 
 	protected.GET("/user/:login", h.UserByLogin)
+	protected.GET("/users/me", h.CurrentUser)
+	sessions.POST("/users/me", h.UpdateProfile)
+	sessions.POST("/users/me/password", h.ChangePassword)
+	sessions.DELETE("/users/me", h.DeleteUser)
 
-	protected.GET("/questions/:id", h.QuestionById)
-	protected.GET("/questions/game/:game_id", h.QuestionsByGameId)
-	protected.POST("/questions", h.CreateQuestion)
-	protected.POST("/questions/:id", h.UpdateQuestion)
-	protected.DELETE("/questions/:id", h.DeleteQuestion)
+	protected.POST("/players/claim", h.ClaimPlayer)
+	protected.GET("/players/me/history", h.PlayerHistory)
 
-	protected.GET("/games", h.GameList)
-	protected.GET("/games/:id", h.GameLoad)
-	protected.POST("/games/:id", h.UpdateGame)
-	protected.POST("/games", h.CreateGame)
-	protected.DELETE("/games/:id", h.DeleteGame)
+	sessions.POST("/api-keys", h.CreateApiKey)
+	sessions.GET("/api-keys", h.ApiKeyList)
+	sessions.DELETE("/api-keys/:id", h.RevokeApiKey)
 
-	protected.POST("/lobby", h.CreateLobby)
-	protected.GET("/lobby", h.LobbyList)
+	admins.POST("/users/:id/roles", h.SetUserRoles)
+	admins.GET("/audit", h.AuditList)
 
-	protected.GET("/lobby/text_answers/:uuid", h.GetTextAnswers)
+	sessions.GET("/organizations", h.OrganizationList)
+	admins.POST("/organizations", h.CreateOrganization)
+	sessions.GET("/organizations/:id/members", h.OrganizationMembers)
+	sessions.POST("/organizations/:id/members", h.AddOrganizationMember)
+	sessions.DELETE("/organizations/:id/members/:user_id", h.RemoveOrganizationMember)
 
-	protected.POST("/upload-presentation", h.UploadPresentation)
+	viewers.GET("/questions", h.SearchQuestions)
+	viewers.GET("/questions/:id", h.QuestionById)
+	viewers.GET("/questions/game/:game_id", h.QuestionsByGameId)
+	authors.POST("/questions", h.CreateQuestion)
+	authors.POST("/questions/:id", h.UpdateQuestion)
+	authors.DELETE("/questions/:id", h.DeleteQuestion)
+	authors.POST("/questions/:id/restore", h.RestoreQuestion)
+	authors.POST("/questions/:id/fork", h.ForkQuestion)
+	authors.POST("/questions/:id/library", h.SaveQuestionToLibrary)
+
+	viewers.GET("/library", h.LibraryList)
+	viewers.GET("/library/:id", h.LibraryQuestionById)
+	viewers.GET("/library/:id/stats", h.LibraryQuestionStats)
+	authors.POST("/library", h.CreateLibraryQuestion)
+	authors.POST("/library/:id", h.UpdateLibraryQuestion)
+	authors.DELETE("/library/:id", h.DeleteLibraryQuestion)
+
+	viewers.GET("/games", h.GameList)
+	viewers.GET("/games/tags", h.GameTags)
+	viewers.GET("/games/categories", h.GameCategories)
+	viewers.GET("/games/:id", h.GameLoad)
+	authors.POST("/games/:id", h.UpdateGame)
+	authors.POST("/games", h.CreateGame)
+	authors.DELETE("/games/:id", h.DeleteGame)
+	authors.GET("/games/trash", h.GameTrash)
+	authors.POST("/games/:id/restore", h.RestoreGame)
+	authors.GET("/games/:id/questions/trash", h.QuestionTrash)
+	authors.POST("/games/:id/questions/library", h.AddLibraryQuestion)
+	viewers.GET("/games/:id/rounds", h.GameRounds)
+	authors.POST("/games/:id/rounds", h.CreateRound)
+	authors.POST("/rounds/:id", h.UpdateRound)
+	authors.DELETE("/rounds/:id", h.DeleteRound)
+	authors.POST("/games/:id/clone", h.CloneGame)
+	authors.POST("/games/:id/status", h.SetGameStatus)
+	authors.POST("/games/:id/tags", h.SetGameLabels)
+	viewers.GET("/games/:id/snapshots", h.GameSnapshots)
+	viewers.GET("/games/:id/export", h.ExportGame)
+	viewers.GET("/games/:id/presentation", h.GamePresentation)
+	authors.POST("/games/import", h.ImportGame)
+	authors.POST("/games/:id/questions/csv", h.ImportQuestionsCSV)
+	viewers.GET("/games/:id/questions/moodle", h.ExportQuestionsMoodle)
+	authors.POST("/games/:id/questions/moodle", h.ImportQuestionsMoodle)
+
+	viewers.GET("/games/:id/collaborators", h.CollaboratorList)
+	authors.POST("/games/:id/collaborators", h.AddCollaborator)
+	authors.DELETE("/games/:id/collaborators/:user_id", h.RemoveCollaborator)
+
+	hosts.POST("/lobby", h.CreateLobby)
+	hosts.GET("/lobby", h.LobbyList)
+
+	hosts.GET("/lobby/text_answers/:uuid", h.GetTextAnswers)
+
+	authors.POST("/upload-presentation", h.UploadPresentation)
 
 	h.router.GET("/get-pdf", h.GetPDF)
 }
@@ -123,6 +229,31 @@ func sendError(c *gin.Context, code int, message any) {
 	})
 }
 
+// sendServiceError maps errors returned by the services to an error response.
+// Changes that would break a published game are answered with the problems found.
+func sendServiceError(c *gin.Context, err error, notFound string) {
+	var notPublishable *publish.Error
+	switch {
+	case errors.As(err, &notPublishable):
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"message": notPublishable.Issues,
+		})
+	case errors.Is(err, model.ErrForbidden):
+		sendError(c, http.StatusForbidden, "access denied")
+	case errors.Is(err, pgx.ErrNoRows):
+		sendError(c, http.StatusNotFound, notFound)
+	default:
+		sendError(c, http.StatusInternalServerError, "internal err")
+	}
+}
+
+// actorFromContext returns the actor stored in the context by the authorization middleware.
+func actorFromContext(c *gin.Context) model.Actor {
+	actor, _ := c.Value("actor").(model.Actor)
+	return actor
+}
+
 // sendSuccess sends a success response to the client with a specified HTTP status code and success message/data.
 func sendSuccess(c *gin.Context, code int, message any) {
 	c.JSON(code, gin.H{
@@ -136,7 +267,7 @@ func parseAuthHeader(header string) (string, error) {
 		return header, fmt.Errorf("access denied, Authorization header required")
 	}
 	headerArr := strings.Split(header, " ")
-	if headerArr[0] != "Basic" {
+	if headerArr[0] != "Basic" || len(headerArr) < 2 {
 		return header, fmt.Errorf("access denied, Basic64 authorization required")
 	}
 	return headerArr[1], nil
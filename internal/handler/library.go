@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/service/library"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LibraryList lists the library questions the caller can see.
+// Supported query params: mine (true to keep the caller's own questions), q (full-text search), limit.
+func (h *handler) LibraryList(c *gin.Context) {
+	filter := dto.LibraryFilter{
+		Mine:  c.Query("mine") == "true",
+		Query: c.Query("q"),
+	}
+	if val := c.Query("limit"); val != "" {
+		_, err := fmt.Sscanf(val, "%d", &filter.Limit)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "incorrect limit")
+			return
+		}
+	}
+
+	res, err := h.librarySvc.List(c.Request.Context(), actorFromContext(c), filter)
+	if err != nil {
+		sendServiceError(c, err, "library not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+func (h *handler) LibraryQuestionById(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "library question id is required")
+		return
+	}
+
+	res, err := h.librarySvc.Load(c.Request.Context(), actorFromContext(c), id)
+	if err != nil {
+		sendServiceError(c, err, "library question not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+// LibraryQuestionStats sums up the results of the library question over all games and lobbies.
+func (h *handler) LibraryQuestionStats(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "library question id is required")
+		return
+	}
+
+	res, err := h.librarySvc.Stats(c.Request.Context(), actorFromContext(c), id)
+	if err != nil {
+		sendServiceError(c, err, "library question not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+func (h *handler) CreateLibraryQuestion(c *gin.Context) {
+	req := dto.LibraryQuestionRequest{}
+	err := c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err := h.librarySvc.Create(c.Request.Context(), actorFromContext(c), req)
+	switch {
+	case errors.Is(err, library.ErrValidation):
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		sendServiceError(c, err, "library not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// UpdateLibraryQuestion replaces the library question, the game questions following it are updated too.
+func (h *handler) UpdateLibraryQuestion(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "library question id is required")
+		return
+	}
+
+	req := dto.LibraryQuestionRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err = h.librarySvc.Update(c.Request.Context(), actorFromContext(c), id, req)
+	switch {
+	case errors.Is(err, library.ErrValidation):
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil || id == 0:
+		sendServiceError(c, err, "library question not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+func (h *handler) DeleteLibraryQuestion(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "library question id is required")
+		return
+	}
+
+	id, err = h.librarySvc.Delete(c.Request.Context(), actorFromContext(c), id)
+	if err != nil || id == 0 {
+		sendServiceError(c, err, "library question not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// AddLibraryQuestion puts a library question into the game.
+func (h *handler) AddLibraryQuestion(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	gameId := 0
+	_, err := fmt.Sscanf(idStr, "%d", &gameId)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	req := dto.AddLibraryQuestionRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err := h.librarySvc.AddToGame(c.Request.Context(), actorFromContext(c), gameId, req)
+	switch {
+	case errors.Is(err, library.ErrValidation):
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		sendServiceError(c, err, "game or library question not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// SaveQuestionToLibrary puts the game question into the library. The body may set the visibility.
+func (h *handler) SaveQuestionToLibrary(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "question id is required")
+		return
+	}
+
+	req := struct {
+		Visibility string `json:"visibility"`
+	}{}
+	if c.Request.ContentLength != 0 {
+		err = c.BindJSON(&req)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "body req err")
+			return
+		}
+	}
+
+	id, err = h.librarySvc.SaveQuestion(c.Request.Context(), actorFromContext(c), id, req.Visibility)
+	switch {
+	case errors.Is(err, library.ErrValidation):
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		sendServiceError(c, err, "question not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// ForkQuestion makes the question a local copy that no longer follows its library question.
+func (h *handler) ForkQuestion(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "question id is required")
+		return
+	}
+
+	id, err = h.librarySvc.Fork(c.Request.Context(), actorFromContext(c), id)
+	switch {
+	case errors.Is(err, library.ErrValidation):
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil || id == 0:
+		sendServiceError(c, err, "question not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
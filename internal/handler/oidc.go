@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/oidc"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie binds the login state to the browser that started the login.
+const oidcStateCookie = "oidc_state"
+
+// OidcLogin redirects the browser to the OpenID Connect provider.
+func (h *handler) OidcLogin(c *gin.Context) {
+	authURL, state, err := h.oidcSvc.AuthURL(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, oidc.ErrDisabled) {
+			sendError(c, http.StatusNotFound, err)
+			return
+		}
+		sendError(c, http.StatusBadGateway, "identity provider is unavailable")
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(h.oidcSvc.StateTTL()/time.Second), "/auth/oidc", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OidcCallback completes the login started by OidcLogin and responds with the usual token pair.
+func (h *handler) OidcCallback(c *gin.Context) {
+	if errParam := c.Query("error"); errParam != "" {
+		sendError(c, http.StatusUnauthorized, "Access denied by identity provider: "+errParam)
+		return
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		sendError(c, http.StatusBadRequest, "code and state are required")
+		return
+	}
+
+	cookie, err := c.Cookie(oidcStateCookie)
+	if err != nil || cookie != state {
+		sendError(c, http.StatusUnauthorized, "Access denied, login state mismatch")
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", "", c.Request.TLS != nil, true)
+
+	ctx := c.Request.Context()
+	user, err := h.oidcSvc.Callback(ctx, model.Actor{IP: c.ClientIP()}, code, state)
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrDisabled):
+			sendError(c, http.StatusNotFound, err)
+		case errors.Is(err, oidc.ErrInvalidState), errors.Is(err, oidc.ErrInvalidToken):
+			sendError(c, http.StatusUnauthorized, "Access denied, invalid login state or id token")
+		case errors.Is(err, oidc.ErrProvider):
+			sendError(c, http.StatusBadGateway, "identity provider is unavailable")
+		default:
+			sendError(c, http.StatusInternalServerError, "internal err")
+		}
+		return
+	}
+
+	tokens, err := h.jwtSvc.CreateToken(ctx, model.JwtRequest{
+		Login: user.Login,
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, tokens)
+}
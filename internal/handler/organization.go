@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/service/organization"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) OrganizationList(c *gin.Context) {
+	res, err := h.orgSvc.List(c.Request.Context(), actorFromContext(c))
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+func (h *handler) CreateOrganization(c *gin.Context) {
+	req := dto.CreateOrganizationRequest{}
+	err := c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err := h.orgSvc.Create(c.Request.Context(), actorFromContext(c), req)
+	if err != nil {
+		sendOrganizationError(c, err, "organization not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+func (h *handler) OrganizationMembers(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	orgId := 0
+	_, err := fmt.Sscanf(idStr, "%d", &orgId)
+	if err != nil || orgId == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect organization id")
+		return
+	}
+
+	res, err := h.orgSvc.Members(c.Request.Context(), actorFromContext(c), orgId)
+	if err != nil {
+		sendOrganizationError(c, err, "organization not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+func (h *handler) AddOrganizationMember(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	orgId := 0
+	_, err := fmt.Sscanf(idStr, "%d", &orgId)
+	if err != nil || orgId == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect organization id")
+		return
+	}
+
+	req := dto.AddMemberRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	err = h.orgSvc.AddMember(c.Request.Context(), actorFromContext(c), orgId, req)
+	if err != nil {
+		sendOrganizationError(c, err, "user not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, "member saved")
+}
+
+func (h *handler) RemoveOrganizationMember(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	orgId := 0
+	_, err := fmt.Sscanf(idStr, "%d", &orgId)
+	if err != nil || orgId == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect organization id")
+		return
+	}
+
+	userIdStr := c.Params.ByName("user_id")
+	userId := 0
+	_, err = fmt.Sscanf(userIdStr, "%d", &userId)
+	if err != nil || userId == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect user_id")
+		return
+	}
+
+	id, err := h.orgSvc.RemoveMember(c.Request.Context(), actorFromContext(c), orgId, userId)
+	if err != nil {
+		sendOrganizationError(c, err, "member not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// sendOrganizationError maps organization service errors to HTTP responses.
+func sendOrganizationError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, organization.ErrValidation):
+		sendError(c, http.StatusBadRequest, err)
+	case errors.Is(err, organization.ErrNameTaken):
+		sendError(c, http.StatusConflict, err)
+	default:
+		sendServiceError(c, err, notFound)
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/player"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimPlayer links a player of a past or running lobby to the caller's account.
+// The player is identified by the player token received when joining the lobby.
+func (h *handler) ClaimPlayer(c *gin.Context) {
+	req := dto.ClaimPlayerRequest{}
+	err := c.BindJSON(&req)
+	if err != nil || req.PlayerToken == "" {
+		sendError(c, http.StatusBadRequest, "player token is required")
+		return
+	}
+
+	claims, err := h.jwtSvc.ParseLobbyToken(req.PlayerToken, model.LobbyTokenPlayer)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, "invalid player token")
+		return
+	}
+
+	err = h.playerSvc.Claim(c.Request.Context(), actorFromContext(c), claims.PlayerUUID)
+	if err != nil {
+		if errors.Is(err, player.ErrCannotClaim) {
+			sendError(c, http.StatusConflict, err)
+			return
+		}
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	resp := map[string]any{
+		"player_uuid": claims.PlayerUUID,
+		"lobby_uuid":  claims.LobbyUUID,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// PlayerHistory returns the games played by the caller's account.
+// Supported query params: limit, offset.
+func (h *handler) PlayerHistory(c *gin.Context) {
+	limit, offset := 0, 0
+	if val := c.Query("limit"); val != "" {
+		_, err := fmt.Sscanf(val, "%d", &limit)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "incorrect limit")
+			return
+		}
+	}
+	if val := c.Query("offset"); val != "" {
+		_, err := fmt.Sscanf(val, "%d", &offset)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "incorrect offset")
+			return
+		}
+	}
+
+	res, err := h.playerSvc.History(c.Request.Context(), actorFromContext(c), limit, offset)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
@@ -2,16 +2,36 @@ package handler
 
 import (
 	"fmt"
+	"net/http"
+	"quizer_server/internal/model"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // 1. Хендлер загрузки файла
 func (h *handler) UploadPresentation(c *gin.Context) {
-	file, _ := c.FormFile("file")
+	file, err := c.FormFile("file")
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "file is required")
+		return
+	}
 	gameIdStr := c.PostForm("game_id")
+	gameId, err := strconv.Atoi(gameIdStr)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "incorrect game_id")
+		return
+	}
+
+	// Проверяем, что пользователь может редактировать игру
+	actor := actorFromContext(c)
+	err = h.gameSvc.CheckAccess(c.Request.Context(), actor, gameId, model.PermissionEdit)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
 
 	// Генерируем уникальное имя
 	filename := fmt.Sprintf("%s_%d.pdf", gameIdStr, time.Now().Unix())
@@ -25,17 +45,47 @@ func (h *handler) UploadPresentation(c *gin.Context) {
 
 	// Сохраняем ПУТЬ в базу данных
 	// UPDATE lobbies SET pdf_path = $1 WHERE uuid = $2
-	gameId, _ := strconv.Atoi(gameIdStr)
-	h.gameSvc.UpdateFilePath(c.Request.Context(), gameId, filepath)
+	h.gameSvc.UpdateFilePath(c.Request.Context(), actor, gameId, filepath)
 
 	c.JSON(200, gin.H{"status": "uploaded", "path": filename})
 }
 
 // 2. Хендлер отдачи файла (для react-pdf)
 func (h *handler) GetPDF(c *gin.Context) {
-	gameIdStr := c.Query("game_id")
-	gameId, _ := strconv.Atoi(gameIdStr)
-	game, _ := h.gameSvc.GameLoad(c.Request.Context(), gameId)
+	lobbyUUID, err := uuid.Parse(c.Query("lobby_uuid"))
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "lobby_uuid is required")
+		return
+	}
+	path, err := h.gameSvc.LobbyPresentationPath(c.Request.Context(), lobbyUUID)
+	if err != nil {
+		sendServiceError(c, err, "presentation not found")
+		return
+	}
+	if path == "" {
+		sendError(c, http.StatusNotFound, "presentation not found")
+		return
+	}
+
+	c.File(path) // Gin сам отдаст файл корректно
+}
+
+// GamePresentation serves the presentation of the game to those who can view the game.
+func (h *handler) GamePresentation(c *gin.Context) {
+	gameId, err := strconv.Atoi(c.Params.ByName("id"))
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+	path, err := h.gameSvc.PresentationPath(c.Request.Context(), actorFromContext(c), gameId)
+	if err != nil {
+		sendServiceError(c, err, "presentation not found")
+		return
+	}
+	if path == "" {
+		sendError(c, http.StatusNotFound, "presentation not found")
+		return
+	}
 
-	c.File(game.Link) // Gin сам отдаст файл корректно
+	c.File(path)
 }
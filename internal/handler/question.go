@@ -1,13 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"quizer_server/internal/dto"
 	"quizer_server/internal/model"
+	"quizer_server/internal/service/question"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
 )
 
 func (h *handler) QuestionById(c *gin.Context) {
@@ -19,14 +20,10 @@ func (h *handler) QuestionById(c *gin.Context) {
 		return
 	}
 
-	res, err := h.questionSvc.Load(c.Request.Context(), id)
+	res, err := h.questionSvc.Load(c.Request.Context(), actorFromContext(c), id)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			sendError(c, http.StatusNotFound, "question not found")
-			return
-		}
-		sendError(c, http.StatusInternalServerError, "internal err")
+		sendServiceError(c, err, "question not found")
 		return
 	}
 
@@ -42,10 +39,44 @@ func (h *handler) QuestionsByGameId(c *gin.Context) {
 		return
 	}
 
-	res, err := h.questionSvc.ListByGameId(c.Request.Context(), gameId)
+	res, err := h.questionSvc.ListByGameId(c.Request.Context(), actorFromContext(c), gameId)
+
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+// SearchQuestions runs a full-text search over the questions the caller can see.
+// Supported query params: q (required), game_id, limit.
+func (h *handler) SearchQuestions(c *gin.Context) {
+	filter := dto.QuestionSearchFilter{
+		Query: c.Query("q"),
+	}
+	if filter.Query == "" {
+		sendError(c, http.StatusBadRequest, "q is required")
+		return
+	}
+	if val := c.Query("game_id"); val != "" {
+		_, err := fmt.Sscanf(val, "%d", &filter.GameId)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "incorrect game_id")
+			return
+		}
+	}
+	if val := c.Query("limit"); val != "" {
+		_, err := fmt.Sscanf(val, "%d", &filter.Limit)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "incorrect limit")
+			return
+		}
+	}
 
+	res, err := h.questionSvc.Search(c.Request.Context(), actorFromContext(c), filter)
 	if err != nil {
-		sendError(c, http.StatusInternalServerError, "internal err")
+		sendServiceError(c, err, "game not found")
 		return
 	}
 
@@ -60,9 +91,13 @@ func (h *handler) CreateQuestion(c *gin.Context) {
 		return
 	}
 
-	id, err := h.questionSvc.Create(c.Request.Context(), req)
+	id, err := h.questionSvc.Create(c.Request.Context(), actorFromContext(c), req)
+	if errors.Is(err, question.ErrRoundNotInGame) {
+		sendError(c, http.StatusBadRequest, "round_id must be a round of the game")
+		return
+	}
 	if err != nil {
-		sendError(c, http.StatusInternalServerError, "internal err")
+		sendServiceError(c, err, "game not found")
 		return
 	}
 
@@ -90,9 +125,15 @@ func (h *handler) UpdateQuestion(c *gin.Context) {
 		return
 	}
 
-	id, err = h.questionSvc.Update(c.Request.Context(), req)
+	req.Id = id
+
+	id, err = h.questionSvc.Update(c.Request.Context(), actorFromContext(c), req)
+	if errors.Is(err, question.ErrRoundNotInGame) {
+		sendError(c, http.StatusBadRequest, "round_id must be a round of the game")
+		return
+	}
 	if err != nil || id == 0 {
-		sendError(c, http.StatusInternalServerError, "internal err")
+		sendServiceError(c, err, "question not found")
 		return
 	}
 
@@ -113,9 +154,51 @@ func (h *handler) DeleteQuestion(c *gin.Context) {
 		return
 	}
 
-	id, err = h.questionSvc.DeleteById(c.Request.Context(), id)
+	id, err = h.questionSvc.DeleteById(c.Request.Context(), actorFromContext(c), id)
+	if err != nil || id == 0 {
+		sendServiceError(c, err, "question not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// QuestionTrash lists the deleted questions of the game.
+func (h *handler) QuestionTrash(c *gin.Context) {
+	gameIdStr := c.Params.ByName("id")
+	gameId := 0
+	_, err := fmt.Sscanf(gameIdStr, "%d", &gameId)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	res, err := h.questionSvc.Trash(c.Request.Context(), actorFromContext(c), gameId)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+// RestoreQuestion takes the question out of the trash.
+func (h *handler) RestoreQuestion(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "question id is required")
+		return
+	}
+
+	id, err = h.questionSvc.Restore(c.Request.Context(), actorFromContext(c), id)
 	if err != nil || id == 0 {
-		sendError(c, http.StatusInternalServerError, "internal err")
+		sendServiceError(c, err, "question not found in the trash")
 		return
 	}
 
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/service/game"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GameRounds lists the rounds of the game in their order.
+func (h *handler) GameRounds(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	res, err := h.gameSvc.Rounds(c.Request.Context(), actorFromContext(c), id)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, res)
+}
+
+// CreateRound adds a round to the game, questions are put into it with their round_id.
+func (h *handler) CreateRound(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	gameId := 0
+	_, err := fmt.Sscanf(idStr, "%d", &gameId)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "game id is required")
+		return
+	}
+
+	req := dto.RoundRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err := h.gameSvc.CreateRound(c.Request.Context(), actorFromContext(c), gameId, req)
+	switch {
+	case errors.Is(err, game.ErrValidation):
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+func (h *handler) UpdateRound(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "round id is required")
+		return
+	}
+
+	req := dto.RoundRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err = h.gameSvc.UpdateRound(c.Request.Context(), actorFromContext(c), id, req)
+	switch {
+	case errors.Is(err, game.ErrValidation):
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	case err != nil || id == 0:
+		sendServiceError(c, err, "round not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// DeleteRound removes the round, its questions stay in the game outside of any round.
+func (h *handler) DeleteRound(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "round id is required")
+		return
+	}
+
+	id, err = h.gameSvc.DeleteRound(c.Request.Context(), actorFromContext(c), id)
+	if err != nil || id == 0 {
+		sendServiceError(c, err, "round not found")
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/question"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// maxImportBodySize is larger than the presentation limit to leave room for the base64 overhead.
+const maxImportBodySize = 80 << 20
+
+// ExportGame responds with the game in the portable quiz format as a file download.
+// ?format=yaml switches the encoding from JSON to YAML.
+func (h *handler) ExportGame(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil || id == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect game_id")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "yaml" {
+		sendError(c, http.StatusBadRequest, "format must be json or yaml")
+		return
+	}
+
+	file, err := h.gameSvc.Export(c.Request.Context(), actorFromContext(c), id)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	var (
+		data        []byte
+		contentType string
+	)
+	if format == "yaml" {
+		data, err = yaml.Marshal(file)
+		contentType = "application/yaml"
+	} else {
+		data, err = json.MarshalIndent(file, "", "  ")
+		contentType = "application/json"
+	}
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="game-%d.quiz.%s"`, id, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportGame creates a game from a quiz file sent as JSON or YAML. YAML is detected
+// by the Content-Type header or ?format=yaml. The validation report is returned
+// both on success and with 422 when the file is rejected.
+func (h *handler) ImportGame(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxImportBodySize+1))
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+	if len(body) > maxImportBodySize {
+		sendError(c, http.StatusRequestEntityTooLarge, "quiz file is too large")
+		return
+	}
+
+	file := model.QuizFile{}
+	if c.Query("format") == "yaml" || strings.Contains(c.ContentType(), "yaml") {
+		err = yaml.Unmarshal(body, &file)
+	} else {
+		err = json.Unmarshal(body, &file)
+	}
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "malformed quiz file: "+err.Error())
+		return
+	}
+
+	report, err := h.gameSvc.Import(c.Request.Context(), actorFromContext(c), file)
+	if err != nil {
+		sendServiceError(c, err, "organization not found")
+		return
+	}
+	sendImportReport(c, report)
+}
+
+// maxCSVBodySize limits question spreadsheets and question banks, which hold text only.
+const maxCSVBodySize = 5 << 20
+
+// ImportQuestionsCSV adds questions to the game from a CSV file sent either as the
+// "file" field of a multipart form or as a raw text/csv body. ?dry_run=true only
+// validates the rows. The per-row report is returned with 422 when any row is invalid.
+func (h *handler) ImportQuestionsCSV(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil || id == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect game_id")
+		return
+	}
+	dryRun := c.Query("dry_run") == "true" || c.Query("dry_run") == "1"
+
+	body, ok := uploadedFile(c, maxCSVBodySize)
+	if !ok {
+		return
+	}
+	defer body.Close()
+
+	report, err := h.questionSvc.ImportCSV(c.Request.Context(), actorFromContext(c), id, body, dryRun)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+	sendImportReport(c, report)
+}
+
+// ImportQuestionsMoodle adds the questions of a Moodle question bank to the game.
+// ?format=gift|xml selects GIFT or Moodle XML, the file is sent like for ImportQuestionsCSV.
+func (h *handler) ImportQuestionsMoodle(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil || id == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect game_id")
+		return
+	}
+	format := c.DefaultQuery("format", question.FormatMoodleXML)
+	if format != question.FormatGIFT && format != question.FormatMoodleXML {
+		sendError(c, http.StatusBadRequest, "format must be gift or xml")
+		return
+	}
+	dryRun := c.Query("dry_run") == "true" || c.Query("dry_run") == "1"
+
+	body, ok := uploadedFile(c, maxCSVBodySize)
+	if !ok {
+		return
+	}
+	defer body.Close()
+
+	report, err := h.questionSvc.ImportMoodle(c.Request.Context(), actorFromContext(c), id, format, body, dryRun)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+	sendImportReport(c, report)
+}
+
+// ExportQuestionsMoodle responds with the questions of the game as a GIFT or Moodle XML file.
+func (h *handler) ExportQuestionsMoodle(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil || id == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect game_id")
+		return
+	}
+	format := c.DefaultQuery("format", question.FormatMoodleXML)
+	if format != question.FormatGIFT && format != question.FormatMoodleXML {
+		sendError(c, http.StatusBadRequest, "format must be gift or xml")
+		return
+	}
+
+	data, err := h.questionSvc.ExportMoodle(c.Request.Context(), actorFromContext(c), id, format)
+	if err != nil {
+		sendServiceError(c, err, "game not found")
+		return
+	}
+
+	contentType := "application/xml"
+	if format == question.FormatGIFT {
+		contentType = "text/plain; charset=utf-8"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="game-%d-questions.%s"`, id, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// uploadedFile returns the "file" field of a multipart form or the raw request body
+// limited to limit bytes. It responds with an error itself when the file is missing.
+func uploadedFile(c *gin.Context, limit int64) (io.ReadCloser, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.Request.Body, true
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "file is required")
+		return nil, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "file is required")
+		return nil, false
+	}
+	return file, true
+}
+
+// sendImportReport responds with the report, with 422 when the import was rejected.
+func sendImportReport(c *gin.Context, report model.ImportReport) {
+	if !report.Valid {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"message": report,
+		})
+		return
+	}
+	sendSuccess(c, http.StatusOK, report)
+}
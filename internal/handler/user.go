@@ -3,11 +3,19 @@ package handler
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"quizer_server/internal/dto"
 	"quizer_server/internal/model"
+	"quizer_server/internal/service/jwt"
+	"quizer_server/internal/service/throttle"
+	"quizer_server/internal/service/user"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 )
 
 func (h *handler) Login(c *gin.Context) {
@@ -23,30 +31,106 @@ func (h *handler) Login(c *gin.Context) {
 		return
 	}
 
-	dataBytes := bytes.Split(body, []byte(":"))
-	login := string(dataBytes[0])
-	password := string(dataBytes[1])
+	dataBytes := bytes.SplitN(body, []byte(":"), 2)
+	if len(dataBytes) != 2 {
+		sendError(c, http.StatusUnauthorized, "Access denied, invalid login or password")
+		return
+	}
 
-	user, err := h.userSvc.UserByLogin(c, login)
+	h.login(c, string(dataBytes[0]), string(dataBytes[1]))
+}
 
+func (h *handler) LoginJSON(c *gin.Context) {
+	req := dto.LoginRequest{}
+	err := c.BindJSON(&req)
 	if err != nil {
-		sendError(c, http.StatusUnauthorized, "Access denied, user not found")
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	h.login(c, req.Login, req.Password)
+}
+
+// login authenticates the credentials and issues a token pair.
+// Failed attempts are throttled per login and per client IP, and the error
+// message does not reveal whether the user exists.
+func (h *handler) login(c *gin.Context, login string, password string) {
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	err := h.throttleSvc.Check(ctx, login, ip)
+	var locked *throttle.LockedError
+	if errors.As(err, &locked) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		sendError(c, http.StatusTooManyRequests, "Access denied, too many failed login attempts, try again later")
 		return
 	}
 
-	if user.Password != password {
-		sendError(c, http.StatusUnauthorized, "Access denied, password do not match")
+	_, err = h.userSvc.Authenticate(ctx, login, password)
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidCredentials) {
+			h.throttleSvc.Fail(ctx, login, ip)
+		}
+		sendError(c, http.StatusUnauthorized, "Access denied, invalid login or password")
 		return
 	}
 
-	tokens := h.jwtSvc.CreateToken(c.Request.Context(), model.JwtRequest{
+	h.throttleSvc.Reset(ctx, login)
+
+	tokens, err := h.jwtSvc.CreateToken(ctx, model.JwtRequest{
 		Login:    login,
 		Password: password,
 	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, tokens)
+}
+
+func (h *handler) RefreshToken(c *gin.Context) {
+	req := dto.RefreshTokenRequest{}
+	err := c.BindJSON(&req)
+	if err != nil || req.RefreshToken == "" {
+		sendError(c, http.StatusBadRequest, "refresh token is required")
+		return
+	}
+
+	tokens, err := h.jwtSvc.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, jwt.ErrInvalidRefreshToken) || errors.Is(err, jwt.ErrRefreshTokenReused) {
+			sendError(c, http.StatusUnauthorized, "Access denied, invalid refresh token")
+			return
+		}
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
 
 	sendSuccess(c, http.StatusOK, tokens)
 }
 
+func (h *handler) Logout(c *gin.Context) {
+	req := dto.RefreshTokenRequest{}
+	err := c.BindJSON(&req)
+	if err != nil || req.RefreshToken == "" {
+		sendError(c, http.StatusBadRequest, "refresh token is required")
+		return
+	}
+
+	err = h.jwtSvc.Logout(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, jwt.ErrInvalidRefreshToken) {
+			sendError(c, http.StatusUnauthorized, "Access denied, invalid refresh token")
+			return
+		}
+		sendError(c, http.StatusInternalServerError, "internal err")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, "logged out")
+}
+
 func (h *handler) UserByLogin(c *gin.Context) {
 	login := c.Param("login")
 	user, err := h.userSvc.UserByLogin(c, login)
@@ -58,3 +142,146 @@ func (h *handler) UserByLogin(c *gin.Context) {
 
 	sendSuccess(c, http.StatusOK, user)
 }
+
+func (h *handler) RegisterUser(c *gin.Context) {
+	req := dto.CreateUserRequest{}
+	err := c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err := h.userSvc.Register(c.Request.Context(), model.Actor{IP: c.ClientIP()}, req)
+	if err != nil {
+		sendUserError(c, err)
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+func (h *handler) CurrentUser(c *gin.Context) {
+	userId := actorFromContext(c).Id
+	user, err := h.userSvc.UserById(c.Request.Context(), userId)
+	if err != nil {
+		sendError(c, http.StatusNotFound, "user not found")
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, user)
+}
+
+func (h *handler) UpdateProfile(c *gin.Context) {
+	req := dto.UpdateProfileRequest{}
+	err := c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err := h.userSvc.UpdateProfile(c.Request.Context(), actorFromContext(c), req)
+	if err != nil {
+		sendUserError(c, err)
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+func (h *handler) ChangePassword(c *gin.Context) {
+	req := dto.ChangePasswordRequest{}
+	err := c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	err = h.userSvc.ChangePassword(c.Request.Context(), actorFromContext(c), req)
+	if err != nil {
+		sendUserError(c, err)
+		return
+	}
+
+	sendSuccess(c, http.StatusOK, "password changed")
+}
+
+func (h *handler) DeleteUser(c *gin.Context) {
+	req := dto.DeleteUserRequest{}
+	err := c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err := h.userSvc.Delete(c.Request.Context(), actorFromContext(c), req.Password)
+	if err != nil {
+		sendUserError(c, err)
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// sendUserError maps user service errors to HTTP responses.
+func sendUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, user.ErrValidation):
+		sendError(c, http.StatusBadRequest, err)
+	case errors.Is(err, user.ErrLoginTaken):
+		sendError(c, http.StatusConflict, err)
+	case errors.Is(err, user.ErrInvalidCredentials):
+		sendError(c, http.StatusUnauthorized, "Access denied, invalid login or password")
+	case errors.Is(err, user.ErrHasGames):
+		sendError(c, http.StatusConflict, "delete your games before deleting the account")
+	case errors.Is(err, pgx.ErrNoRows):
+		sendError(c, http.StatusNotFound, "user not found")
+	default:
+		sendError(c, http.StatusInternalServerError, "internal err")
+	}
+}
+
+func (h *handler) SetUserRoles(c *gin.Context) {
+	idStr := c.Params.ByName("id")
+	id := 0
+	_, err := fmt.Sscanf(idStr, "%d", &id)
+	if err != nil || id == 0 {
+		sendError(c, http.StatusBadRequest, "incorrect user id")
+		return
+	}
+
+	req := dto.UpdateRolesRequest{}
+	err = c.BindJSON(&req)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "body req err")
+		return
+	}
+
+	id, err = h.userSvc.SetRoles(c.Request.Context(), actorFromContext(c), id, req.Roles)
+	if err != nil {
+		sendUserError(c, err)
+		return
+	}
+
+	resp := map[string]any{
+		"id": id,
+	}
+
+	sendSuccess(c, http.StatusOK, resp)
+}
+
+// JWKS publishes the public signing keys in the raw JWKS format expected by verifiers.
+func (h *handler) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.jwtSvc.JWKS())
+}
@@ -20,14 +20,15 @@ func (h *handler) wsHandler(c *gin.Context) {
 	paramPlayerUUID := c.Query("player_uuid")
 	paramLobbyUUID := c.Query("lobby_uuid")
 	paramPlayerName := c.Query("player_name")
-	paramIsAuth := c.Query("is_auth")
+	paramToken := c.Query("token")
+	paramHostToken := c.Query("host_token")
+	paramPlayerToken := c.Query("player_token")
 	isAdmin := false
-
-	if paramPlayerUUID == "" {
-		sendError(c, http.StatusBadRequest, "player uuid required")
-		log.Println("player uuid is required")
-		return
-	}
+	playerToken := ""
+	// host is the actor recorded in the audit log for host actions
+	host := model.Actor{IP: c.ClientIP()}
+	// account is the signed in user, players joining with an access token are linked to it
+	account := model.Actor{}
 
 	if paramLobbyUUID == "" {
 		sendError(c, http.StatusBadRequest, "lobby uuid is required")
@@ -41,17 +42,6 @@ func (h *handler) wsHandler(c *gin.Context) {
 		return
 	}
 
-	if paramIsAuth == "true" {
-		isAdmin = true
-	}
-
-	playerUUID, err := uuid.Parse(paramPlayerUUID)
-	if err != nil {
-		sendError(c, http.StatusBadRequest, "player uuid is incorrect")
-		log.Println("player uuid is incorrect:", paramPlayerUUID)
-		return
-	}
-
 	lobbyUUID, err := uuid.Parse(paramLobbyUUID)
 	if err != nil {
 		sendError(c, http.StatusBadRequest, "game uuid is incorrect")
@@ -59,6 +49,78 @@ func (h *handler) wsHandler(c *gin.Context) {
 		return
 	}
 
+	if paramToken != "" {
+		account, err = h.jwtSvc.ActorFromToken(paramToken)
+		if err != nil {
+			sendError(c, http.StatusUnauthorized, "invalid access token")
+			log.Println("invalid access token for lobby:", lobbyUUID)
+			return
+		}
+		account.IP = host.IP
+	}
+
+	// The host proves its identity either with the host token issued on lobby
+	// creation or with an access token of the game owner.
+	if paramHostToken != "" {
+		claims, err := h.jwtSvc.ParseLobbyToken(paramHostToken, model.LobbyTokenHost)
+		if err != nil || claims.LobbyUUID != lobbyUUID {
+			sendError(c, http.StatusUnauthorized, "invalid host token")
+			log.Println("invalid host token for lobby:", lobbyUUID)
+			return
+		}
+		isAdmin = true
+	} else if account.Id != 0 {
+		isAdmin = h.lobbySvc.IsHost(c.Request.Context(), account, lobbyUUID)
+		if isAdmin {
+			host = account
+		}
+	}
+
+	var playerUUID uuid.UUID
+	switch {
+	case isAdmin:
+		// the host connection is always registered under the lobby uuid
+		playerUUID = lobbyUUID
+	case paramPlayerToken != "":
+		claims, err := h.jwtSvc.ParseLobbyToken(paramPlayerToken, model.LobbyTokenPlayer)
+		if err != nil || claims.LobbyUUID != lobbyUUID {
+			sendError(c, http.StatusUnauthorized, "invalid player token")
+			log.Println("invalid player token for lobby:", lobbyUUID)
+			return
+		}
+		playerUUID = claims.PlayerUUID
+		playerToken = paramPlayerToken
+		if account.Id != 0 {
+			err = h.playerSvc.Claim(c.Request.Context(), account, playerUUID)
+			if err != nil {
+				log.Println("claim player on rejoin err:", err)
+			}
+		}
+	default:
+		if paramPlayerUUID == "" {
+			sendError(c, http.StatusBadRequest, "player uuid required")
+			log.Println("player uuid is required")
+			return
+		}
+		playerUUID, err = uuid.Parse(paramPlayerUUID)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "player uuid is incorrect")
+			log.Println("player uuid is incorrect:", paramPlayerUUID)
+			return
+		}
+		if playerUUID == lobbyUUID || h.lobbySvc.PlayerExists(c.Request.Context(), playerUUID) {
+			sendError(c, http.StatusForbidden, "player token is required to rejoin")
+			log.Println("player token is required for player:", playerUUID)
+			return
+		}
+		playerToken, err = h.jwtSvc.CreateLobbyToken(model.LobbyTokenPlayer, lobbyUUID, playerUUID)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, "internal err")
+			log.Println("create player token err:", err)
+			return
+		}
+	}
+
 	ws, err := h.updater.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		sendError(c, http.StatusInternalServerError, "ws error")
@@ -79,8 +141,28 @@ func (h *handler) wsHandler(c *gin.Context) {
 		UserName:   paramPlayerName,
 		IsAdmin:    isAdmin,
 	}
+	if !isAdmin && account.Id != 0 {
+		data.UserId = account.Id
+	}
+
+	if playerToken != "" {
+		ws.WriteJSON(gin.H{
+			"type": "player_token",
+			"data": gin.H{
+				"player_uuid":  playerUUID,
+				"player_token": playerToken,
+			},
+		})
+	}
 
-	h.wsRegistration(c.Request.Context(), lobbyUUID, playerUUID, data)
+	// actor is the host on the host connection, players act as their account or anonymously
+	actor := host
+	if !isAdmin {
+		actor = account
+		actor.IP = c.ClientIP()
+	}
+
+	h.wsRegistration(c.Request.Context(), actor, lobbyUUID, playerUUID, data)
 	h.updateUserList(lobbyUUID)
 
 	for {
@@ -89,12 +171,12 @@ func (h *handler) wsHandler(c *gin.Context) {
 			log.Println(err)
 			break
 		}
-		h.parseMsg(c.Request.Context(), playerUUID, lobbyUUID, msg, msgType)
+		h.parseMsg(c.Request.Context(), actor, playerUUID, lobbyUUID, msg, msgType)
 	}
 }
 
 // wsRegistration adds a new WebSocket connection to the active connections map indexed by user UUID.
-func (h *handler) wsRegistration(ctx context.Context, lobbyUUID uuid.UUID, playerUUID uuid.UUID, data PlayerData) {
+func (h *handler) wsRegistration(ctx context.Context, actor model.Actor, lobbyUUID uuid.UUID, playerUUID uuid.UUID, data PlayerData) {
 	_, ok := h.sessions.activeConnections[lobbyUUID]
 	if !ok {
 		h.sessions.activeConnections[lobbyUUID] = make(map[uuid.UUID]PlayerData)
@@ -109,7 +191,10 @@ func (h *handler) wsRegistration(ctx context.Context, lobbyUUID uuid.UUID, playe
 		IsAdmin:   data.IsAdmin,
 		UserName:  data.UserName,
 	}
-	h.gameSvc.SavePlayer(ctx, newPlayer)
+	if data.UserId != 0 {
+		newPlayer.UserId = &data.UserId
+	}
+	h.gameSvc.SavePlayer(ctx, actor, newPlayer)
 }
 
 func (h *handler) updateUserList(lobbyUUID uuid.UUID) {
@@ -132,7 +217,24 @@ func (h *handler) isAdmin(playerUUID, lobbyUUID uuid.UUID) bool {
 	return h.sessions.activeConnections[lobbyUUID][playerUUID].IsAdmin
 }
 
-func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID, msg []byte, msgType int) {
+// requireHost reports whether the message comes from the host connection of the lobby,
+// other connections are answered with access_denied.
+func (h *handler) requireHost(playerUUID, lobbyUUID uuid.UUID) bool {
+	h.sessions.mu.Lock()
+	defer h.sessions.mu.Unlock()
+	if h.isAdmin(playerUUID, lobbyUUID) {
+		return true
+	}
+	if l, ok := h.sessions.activeConnections[lobbyUUID][playerUUID]; ok {
+		l.Connection.WriteJSON(gin.H{
+			"type": "access_denied",
+		})
+	}
+	log.Println("host command rejected, player:", playerUUID, "lobby:", lobbyUUID)
+	return false
+}
+
+func (h *handler) parseMsg(ctx context.Context, actor model.Actor, playerUUID, lobbyUUID uuid.UUID, msg []byte, msgType int) {
 	log.Println("lobby_uuid:", lobbyUUID, "player_uuid:", playerUUID, "msgType:", msgType, "msg:", string(msg))
 
 	if string(msg) == "start" {
@@ -199,27 +301,57 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 	// 	return
 	// }
 
-	if strings.Contains(string(msg), "start_lobby") {
+	if strings.HasPrefix(string(msg), "start_lobby") {
+		if !h.requireHost(playerUUID, lobbyUUID) {
+			return
+		}
 		lobby, _ := h.lobbySvc.LoadByUUID(ctx, lobbyUUID)
-		questions, _ := h.questionSvc.ListByGameId(ctx, lobby.GameId)
-		err := h.lobbySvc.Update(context.Background(), lobby.UUID)
+		// the lobby is pinned to a snapshot first so that the questions sent are the ones scored
+		err := h.lobbySvc.Update(context.Background(), actor, lobby.UUID)
 		if err != nil {
 			log.Println("OOPS UPDATE FAIL")
 		}
+		// the question order of a shuffled lobby is set when it starts
+		lobby, _ = h.lobbySvc.LoadByUUID(ctx, lobbyUUID)
+		questions, _ := h.questionSvc.ListForLobby(ctx, lobbyUUID)
+		rounds, _ := h.gameSvc.LobbyRounds(ctx, lobbyUUID)
+		questionRounds := map[int]int{}
+		for _, q := range questions {
+			if q.RoundId != nil {
+				questionRounds[q.Number] = *q.RoundId
+			}
+		}
+		playerQuestions := h.questionsForPlayers(ctx, lobbyUUID, questions)
 		h.sessions.mu.Lock()
-		for _, l := range h.sessions.activeConnections[lobbyUUID] {
+		for id, l := range h.sessions.activeConnections[lobbyUUID] {
+			data, ok := playerQuestions[id]
+			if l.IsAdmin {
+				data = questions
+			} else if !ok {
+				// joined after the questions were prepared
+				continue
+			}
 			l.Connection.WriteJSON(gin.H{
 				"type": "questions",
-				"data": questions,
+				"data": data,
 			})
+			if len(rounds) > 0 {
+				l.Connection.WriteJSON(gin.H{
+					"type": "rounds",
+					"data": rounds,
+				})
+			}
 		}
 		oldLobby := h.sessions.activeConnections[lobbyUUID][lobbyUUID]
 		tmp := PlayerData{
-			Connection:    oldLobby.Connection,
-			UserName:      oldLobby.UserName,
-			IsAdmin:       oldLobby.IsAdmin,
-			QuestionCount: len(questions),
-			GameId:        lobby.GameId,
+			Connection:     oldLobby.Connection,
+			UserName:       oldLobby.UserName,
+			IsAdmin:        oldLobby.IsAdmin,
+			QuestionCount:  len(questions),
+			GameId:         lobby.GameId,
+			Rounds:         rounds,
+			QuestionRounds: questionRounds,
+			QuestionOrder:  lobby.QuestionOrder,
 		}
 		h.sessions.activeConnections[lobbyUUID][lobbyUUID] = tmp
 		h.sessions.mu.Unlock()
@@ -227,7 +359,11 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 	}
 
 	if string(msg) == "end_lobby" {
+		if !h.requireHost(playerUUID, lobbyUUID) {
+			return
+		}
 		h.sessions.mu.Lock()
+		h.changeRound(lobbyUUID, 0)
 		for _, l := range h.sessions.activeConnections[lobbyUUID] {
 			l.Connection.WriteJSON(gin.H{
 				"type": "end_lobby",
@@ -246,6 +382,9 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 	}
 
 	if string(msg) == "calculate_result" {
+		if !h.requireHost(playerUUID, lobbyUUID) {
+			return
+		}
 		data := h.gameSvc.CalculateQuizResult(ctx, lobbyUUID)
 		h.sessions.mu.Lock()
 		h.sessions.activeConnections[lobbyUUID][lobbyUUID].Connection.WriteJSON(gin.H{
@@ -256,36 +395,55 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 		return
 	}
 
-	if strings.Contains(string(msg), "next_question:") {
+	if strings.HasPrefix(string(msg), "next_question:") {
+		if !h.requireHost(playerUUID, lobbyUUID) {
+			return
+		}
+		// the host counts positions, shuffled lobbies map them to question numbers
 		id := 0
 		fmt.Sscanf(string(msg), "next_question:%d", &id)
 		h.sessions.mu.Lock()
-		count := h.sessions.activeConnections[lobbyUUID][lobbyUUID].QuestionCount
+		entry := h.sessions.activeConnections[lobbyUUID][lobbyUUID]
+		number := model.QuestionNumber(entry.QuestionOrder, id)
+		h.changeRound(lobbyUUID, entry.QuestionRounds[number])
+		count := entry.QuestionCount
 		for _, l := range h.sessions.activeConnections[lobbyUUID] {
 			l.Connection.WriteJSON(gin.H{
-				"type":           "next_question",
-				"data":           id,
-				"question_count": count,
+				"type":            "next_question",
+				"data":            id,
+				"question_number": number,
+				"question_count":  count,
 			})
 		}
 		h.sessions.mu.Unlock()
 		return
 	}
 
-	if strings.Contains(string(msg), "get_question:") {
-		questionNum := 0
+	if strings.HasPrefix(string(msg), "get_question:") {
+		if !h.requireHost(playerUUID, lobbyUUID) {
+			return
+		}
+		position := 0
 		isText := false
-		fmt.Sscanf(string(msg), "get_question:%d", &questionNum)
-		lobby, _ := h.lobbySvc.LoadByUUID(ctx, lobbyUUID)
-		question, _ := h.questionSvc.LoadByNumber(ctx, lobby.GameId, questionNum)
+		fmt.Sscanf(string(msg), "get_question:%d", &position)
+		question, _ := h.questionSvc.LoadForLobby(ctx, lobbyUUID, position)
 		if question.AnswerText != "" {
 			isText = true
 		}
+		playerQuestions := h.questionsForPlayers(ctx, lobbyUUID, []model.Question{question})
 		h.sessions.mu.Lock()
-		for _, l := range h.sessions.activeConnections[lobbyUUID] {
+		for id, l := range h.sessions.activeConnections[lobbyUUID] {
+			data := question
+			if !l.IsAdmin {
+				q, ok := playerQuestions[id]
+				if !ok || len(q) != 1 {
+					continue
+				}
+				data = q[0]
+			}
 			l.Connection.WriteJSON(gin.H{
 				"type":   "question",
-				"data":   question,
+				"data":   data,
 				"isText": isText,
 			})
 		}
@@ -293,7 +451,7 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 		return
 	}
 
-	if strings.Contains(string(msg), "answer_num:") {
+	if strings.HasPrefix(string(msg), "answer_num:") {
 		questionId := 0
 		questionNum := 0
 		answer := 0
@@ -307,7 +465,7 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 			QuestionNumber: questionNum,
 			QuestionId:     questionId,
 		}
-		h.gameSvc.SaveAnswer(ctx, data)
+		h.gameSvc.SaveAnswer(ctx, actor, data)
 		h.sessions.mu.Lock()
 		playerName := h.sessions.activeConnections[lobbyUUID][playerUUID].UserName
 		h.sessions.activeConnections[lobbyUUID][lobbyUUID].Connection.WriteJSON(gin.H{
@@ -318,8 +476,12 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 		return
 	}
 
-	if strings.Contains(string(msg), "answer_text:") {
-		questionId, questionNum, answerText := parseTextAnswer(string(msg))
+	if strings.HasPrefix(string(msg), "answer_text:") {
+		questionId, questionNum, answerText, ok := parseTextAnswer(string(msg))
+		if !ok {
+			log.Println("malformed text answer from player:", playerUUID)
+			return
+		}
 		data := model.Answer{
 			LobbyUUID:      lobbyUUID,
 			PlayerUUID:     playerUUID,
@@ -328,7 +490,7 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 			QuestionNumber: questionNum,
 			QuestionId:     questionId,
 		}
-		h.gameSvc.SaveAnswer(ctx, data)
+		h.gameSvc.SaveAnswer(ctx, actor, data)
 		h.sessions.mu.Lock()
 		playerName := h.sessions.activeConnections[lobbyUUID][playerUUID].UserName
 		h.sessions.activeConnections[lobbyUUID][lobbyUUID].Connection.WriteJSON(gin.H{
@@ -339,8 +501,15 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 		return
 	}
 
-	if strings.Contains(string(msg), "result_text:") {
+	if strings.HasPrefix(string(msg), "result_text:") {
+		if !h.requireHost(playerUUID, lobbyUUID) {
+			return
+		}
 		res := strings.Split(string(msg), ":")
+		if len(res) < 4 {
+			log.Println("malformed text result in lobby:", lobbyUUID)
+			return
+		}
 		pUUID, _ := uuid.Parse(res[1])
 		qNum, _ := strconv.Atoi(res[2])
 		isCorrect := false
@@ -360,12 +529,67 @@ func (h *handler) parseMsg(ctx context.Context, playerUUID, lobbyUUID uuid.UUID,
 		}
 
 		log.Println("result:", result)
-		h.gameSvc.SaveTextResult(ctx, result)
+		h.gameSvc.SaveTextResult(ctx, actor, result)
+	}
+}
+
+// questionsForPlayers returns the questions the way every player connected to the lobby sees them,
+// without answers, see question.Service.ForPlayer. The host sees the original questions and is left out.
+func (h *handler) questionsForPlayers(ctx context.Context, lobbyUUID uuid.UUID, questions []model.Question) map[uuid.UUID][]model.Question {
+	players := []uuid.UUID{}
+	h.sessions.mu.Lock()
+	for id, l := range h.sessions.activeConnections[lobbyUUID] {
+		if !l.IsAdmin {
+			players = append(players, id)
+		}
+	}
+	h.sessions.mu.Unlock()
+
+	res := make(map[uuid.UUID][]model.Question, len(players))
+	for _, id := range players {
+		res[id] = h.questionSvc.ForPlayer(ctx, lobbyUUID, id, questions)
 	}
+	return res
 }
 
-func parseTextAnswer(input string) (int, int, string) {
+// changeRound announces the end of the current round and the start of the round roundId
+// to everybody in the lobby when they differ, a zero roundId only ends the current round.
+// The caller holds the sessions lock.
+func (h *handler) changeRound(lobbyUUID uuid.UUID, roundId int) {
+	lobby, ok := h.sessions.activeConnections[lobbyUUID][lobbyUUID]
+	if !ok || lobby.RoundId == roundId {
+		return
+	}
+	announce := func(eventType string, id int) {
+		for _, r := range lobby.Rounds {
+			if r.Id != id {
+				continue
+			}
+			for _, l := range h.sessions.activeConnections[lobbyUUID] {
+				l.Connection.WriteJSON(gin.H{
+					"type": eventType,
+					"data": r,
+				})
+			}
+		}
+	}
+	if lobby.RoundId != 0 {
+		announce("round_end", lobby.RoundId)
+	}
+	if roundId != 0 {
+		announce("round_start", roundId)
+	}
+	lobby.RoundId = roundId
+	h.sessions.activeConnections[lobbyUUID][lobbyUUID] = lobby
+}
+
+// parseTextAnswer splits "answer_text:<question id>:<question number>/<text>",
+// ok is false when the text is missing.
+func parseTextAnswer(input string) (int, int, string, bool) {
 	parts := strings.SplitN(input, "/", 2)
+	if len(parts) < 2 {
+		return 0, 0, "", false
+	}
 
 	firstPart := parts[0]
 
@@ -376,5 +600,5 @@ func parseTextAnswer(input string) (int, int, string) {
 
 	fmt.Sscanf(firstPart, "answer_text:%d:%d", &questionId, &questionNum)
 
-	return questionId, questionNum, text
+	return questionId, questionNum, text, true
 }
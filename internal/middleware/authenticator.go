@@ -3,9 +3,12 @@ package middleware
 import (
 	"fmt"
 	"net/http"
-	"quizer_server/internal/config"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/apikey"
 	"quizer_server/internal/service/jwt"
+	"quizer_server/internal/service/organization"
 	"quizer_server/internal/service/user"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -13,45 +16,163 @@ import (
 
 type UserAuthenticator interface {
 	Authorization() gin.HandlerFunc
+	RequireRoles(roles ...string) gin.HandlerFunc
+	RequireScope(scope string) gin.HandlerFunc
+	RequireSession() gin.HandlerFunc
 }
 
 type userAuthenticator struct {
-	userService user.Service
-	jwtService  jwt.Service
+	userService   user.Service
+	jwtService    jwt.Service
+	apiKeyService apikey.Service
+	orgService    organization.Service
 }
 
 // NewUserAuthenticator creates a new instance of UserAuthenticator with provided dependencies.
-// It takes a Repository interface for user-related operations and JwtService for JWT token management.
-func NewUserAuthenticator(u user.Service, js jwt.Service) UserAuthenticator {
+// It takes a Repository interface for user-related operations, JwtService for JWT token management,
+// ApiKeyService for API key verification and OrganizationService to select the caller's organization.
+func NewUserAuthenticator(u user.Service, js jwt.Service, ks apikey.Service, ogs organization.Service) UserAuthenticator {
 	return &userAuthenticator{
-		userService: u,
-		jwtService:  js,
+		userService:   u,
+		jwtService:    js,
+		apiKeyService: ks,
+		orgService:    ogs,
 	}
 }
 
 // Authorization implements a middleware handler for authentication purposes.
-// It extracts a token from the request header, validates its authenticity against the JWT secret key,
-// and stores the valid token in the context before proceeding to next handler.
+// It accepts either an API key ('ApiKey <key>' or the X-API-Key header) or a Bearer JWT,
+// and stores the actor of the caller in the context before proceeding to next handler.
 func (a *userAuthenticator) Authorization() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		key := parseApiKeyFromHeaders(c.GetHeader("Authorization"), c.GetHeader("X-API-Key"))
+		if key != "" {
+			actor, err := a.apiKeyService.Authenticate(c.Request.Context(), key)
+			if err != nil {
+				sendError(c, http.StatusUnauthorized, "Access denied, invalid api key")
+				return
+			}
+			if !a.setActor(c, actor) {
+				return
+			}
+			c.Next()
+			return
+		}
+
 		token, err := parseTokenFromHeader(c.GetHeader("Authorization"))
 		if err != nil {
 			sendError(c, http.StatusUnauthorized, fmt.Sprint(err))
 			return
 		}
 
-		_, err = a.jwtService.ParseToken(token, config.GetConfig().Jwt.SecretKey)
+		actor, err := a.jwtService.ActorFromToken(token)
 
 		if err != nil {
 			sendError(c, http.StatusUnauthorized, "Access denied, invalid access token")
 			return
 		}
 
+		if !a.setActor(c, actor) {
+			return
+		}
 		c.Set("access_token", token)
 		c.Next()
 	}
 }
 
+// setActor completes the actor with the client IP and the organization selected
+// with the X-Organization-Id header, and stores it in the context.
+// It aborts the request and returns false when the organization can not be used.
+func (a *userAuthenticator) setActor(c *gin.Context, actor model.Actor) bool {
+	actor.IP = c.ClientIP()
+
+	requested := 0
+	if header := c.GetHeader("X-Organization-Id"); header != "" {
+		id, err := strconv.Atoi(header)
+		if err != nil || id <= 0 {
+			sendError(c, http.StatusBadRequest, "incorrect X-Organization-Id header")
+			return false
+		}
+		requested = id
+	}
+
+	orgId, err := a.orgService.Resolve(c.Request.Context(), actor, requested)
+	if err != nil {
+		sendError(c, http.StatusForbidden, "Access denied to organization")
+		return false
+	}
+	actor.OrgId = orgId
+
+	c.Set("actor", actor)
+	return true
+}
+
+// RequireRoles allows the request only if the authenticated actor has at least one of the given roles.
+// It must be registered after Authorization. Admins pass every role check.
+func (a *userAuthenticator) RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := c.Value("actor").(model.Actor)
+		if !ok {
+			sendError(c, http.StatusUnauthorized, "Access denied, authorization required")
+			return
+		}
+
+		for _, r := range roles {
+			if actor.HasRole(r) {
+				c.Next()
+				return
+			}
+		}
+
+		sendError(c, http.StatusForbidden, "Access denied, insufficient role")
+	}
+}
+
+// RequireScope allows API key requests only if the key carries the given scope.
+// Requests authenticated with a JWT are not affected.
+func (a *userAuthenticator) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := c.Value("actor").(model.Actor)
+		if !ok {
+			sendError(c, http.StatusUnauthorized, "Access denied, authorization required")
+			return
+		}
+
+		if !actor.HasScope(scope) {
+			sendError(c, http.StatusForbidden, "Access denied, api key scope "+scope+" required")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession rejects requests authenticated with an API key.
+// It guards account management, which must not be reachable by automation clients.
+func (a *userAuthenticator) RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := c.Value("actor").(model.Actor)
+		if !ok || actor.ApiKeyId != 0 {
+			sendError(c, http.StatusForbidden, "Access denied, user session required")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// parseApiKeyFromHeaders returns the API key from the 'Authorization: ApiKey <key>' or 'X-API-Key' header.
+func parseApiKeyFromHeaders(authHeader string, keyHeader string) string {
+	if keyHeader != "" {
+		return keyHeader
+	}
+	headerArr := strings.Split(authHeader, " ")
+	if len(headerArr) == 2 && headerArr[0] == "ApiKey" {
+		return headerArr[1]
+	}
+	return ""
+}
+
 // parseTokenFromHeader extracts the actual token value from the 'Authorization' header.
 // It expects the format 'Bearer <token>' and returns either the extracted token or an appropriate error message.
 func parseTokenFromHeader(header string) (string, error) {
@@ -59,7 +180,7 @@ func parseTokenFromHeader(header string) (string, error) {
 		return header, fmt.Errorf("access denied, Authorization header required")
 	}
 	headerArr := strings.Split(header, " ")
-	if headerArr[0] != "Bearer" {
+	if headerArr[0] != "Bearer" || len(headerArr) < 2 {
 		return header, fmt.Errorf("access denied, Bearer authorization required")
 	}
 	return headerArr[1], nil
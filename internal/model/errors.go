@@ -0,0 +1,6 @@
+package model
+
+import "errors"
+
+// ErrForbidden is returned by services when the actor is not allowed to perform the action.
+var ErrForbidden = errors.New("access forbidden")
@@ -1,25 +1,207 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	RoleAdmin  = "admin"
+	RoleAuthor = "author"
+	RoleHost   = "host"
+)
+
 type User struct {
-	Id       int    `json:"user_id" db:"id"`
-	Login    string `json:"login" db:"login"`
-	Password string `json:"password" db:"password"`
+	Id          int       `json:"user_id" db:"id"`
+	Login       string    `json:"login" db:"login"`
+	Password    string    `json:"-" db:"password"`
+	DisplayName string    `json:"display_name" db:"display_name"`
+	Email       string    `json:"email" db:"email"`
+	Roles       []string  `json:"roles" db:"roles"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+const (
+	ScopeGamesRead   = "games:read"
+	ScopeGamesWrite  = "games:write"
+	ScopeLobbiesHost = "lobbies:host"
+)
+
+// Actor is the authenticated caller of a request.
+// ApiKeyId is set when the caller authenticated with an API key,
+// in which case only the key's scopes are granted.
+// OrgId is the organization the request works in.
+type Actor struct {
+	Id       int
+	Login    string
+	Roles    []string
+	Scopes   []string
+	ApiKeyId int
+	IP       string
+	OrgId    int
+}
+
+// HasScope reports whether the actor may use the given API scope.
+// Sessions authenticated with a JWT are not limited by scopes.
+func (a Actor) HasScope(scope string) bool {
+	if a.ApiKeyId == 0 {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the actor has the given role. Admins have every role.
+func (a Actor) HasRole(role string) bool {
+	for _, r := range a.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.HasRole(RoleAdmin)
+}
+
+type ApiKey struct {
+	Id         int        `json:"id" db:"id"`
+	UserId     int        `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+const (
+	AuditTargetGame     = "game"
+	AuditTargetQuestion = "question"
+	AuditTargetLobby    = "lobby"
+	AuditTargetUser     = "user"
+	AuditTargetOrg      = "organization"
+
+	AuditTargetLibraryQuestion = "library_question"
+)
+
+type AuditRecord struct {
+	Id         int64           `json:"id" db:"id"`
+	ActorId    *int            `json:"actor_id" db:"actor_id"`
+	Action     string          `json:"action" db:"action"`
+	TargetType string          `json:"target_type" db:"target_type"`
+	TargetId   string          `json:"target_id" db:"target_id"`
+	Before     json.RawMessage `json:"before" db:"before"`
+	After      json.RawMessage `json:"after" db:"after"`
+	IP         string          `json:"ip" db:"ip"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+type AuditPage struct {
+	Items  []AuditRecord `json:"items"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// Permissions granted to game collaborators. Every permission includes view.
+const (
+	PermissionView = "view"
+	PermissionEdit = "edit"
+	PermissionHost = "host"
+)
+
+// PermissionCovers reports whether the granted permission allows an action requiring the given one.
+func PermissionCovers(granted string, required string) bool {
+	return granted == required || required == PermissionView
+}
+
+// Roles of organization members.
+const (
+	OrgRoleMember = "member"
+	OrgRoleAdmin  = "admin"
+)
+
+type Organization struct {
+	Id        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type OrganizationMember struct {
+	OrgId     int       `json:"org_id" db:"org_id"`
+	UserId    int       `json:"user_id" db:"user_id"`
+	Login     string    `json:"login" db:"login"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type Collaborator struct {
+	GameId     int       `json:"game_id" db:"game_id"`
+	UserId     int       `json:"user_id" db:"user_id"`
+	Login      string    `json:"login" db:"login"`
+	Permission string    `json:"permission" db:"permission"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 type Game struct {
 	Id          int       `json:"game_id" db:"id"`
 	Description string    `json:"description" db:"description"`
+	OwnerId     int       `json:"owner_id" db:"owner_id"`
+	OrgId       int       `json:"org_id" db:"org_id"`
 	Owner       string    `json:"owner" db:"login"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	Link        string    `json:"link" db:"link"`
+	Status      string    `json:"status" db:"status"`
+	Category    string    `json:"category" db:"category"`
+	Tags        []string  `json:"tags" db:"tags"`
+	// DeletedAt is set for games in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// GameSearchResult is a game found by full-text search. Headline is the HTML-escaped
+// description with the matching words wrapped in <b> tags.
+type GameSearchResult struct {
+	Game
+	Rank     float32 `json:"rank" db:"rank"`
+	Headline string  `json:"headline" db:"headline"`
+}
+
+// DeletedQuestion is a question in the trash.
+type DeletedQuestion struct {
+	Question
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+}
+
+// QuestionSearchResult is a question found by full-text search.
+type QuestionSearchResult struct {
+	Question
+	GameDescription string  `json:"game_description" db:"game_description"`
+	Rank            float32 `json:"rank" db:"rank"`
+	Headline        string  `json:"headline" db:"headline"`
 }
 
+// LabelCount is a tag or category with the number of games using it.
+type LabelCount struct {
+	Name  string `json:"name" db:"name"`
+	Games int    `json:"games" db:"games"`
+}
+
+// Game statuses. New games are drafts, only published games can be played in a lobby.
+const (
+	GameStatusDraft     = "draft"
+	GameStatusPublished = "published"
+	GameStatusArchived  = "archived"
+)
+
 type Question struct {
 	Id          int    `json:"question_id" db:"id"`
 	GameId      int    `json:"game_id" db:"game_id"`
@@ -28,12 +210,197 @@ type Question struct {
 	AnswerNum   int    `json:"answer" db:"answer"`
 	AnswerText  string `json:"answer_text" db:"answer_text"`
 	Description string `json:"description" db:"description"`
+	// Options are the texts of the choices, AnswerNum points to the correct one starting from 1.
+	// Questions whose choices are shown only in the presentation have no options.
+	Options []string `json:"options" db:"options"`
+	// RoundId is the round the question belongs to, questions outside of rounds have none.
+	RoundId *int `json:"round_id" db:"round_id"`
+	// LibraryQuestionId is the library question the question was taken from. Unless the question
+	// is Forked, changes of the library question are copied to it.
+	LibraryQuestionId *int `json:"library_question_id" db:"library_question_id"`
+	Forked            bool `json:"forked" db:"forked"`
+}
+
+// Answer returns the answer text of the question. Questions created before answer texts
+// were set explicitly hold the column default "NULL", which means there is none.
+func (q Question) Answer() string {
+	if q.AnswerText == "NULL" {
+		return ""
+	}
+	return q.AnswerText
+}
+
+// Visibility of library questions. Private questions are seen by their owner only,
+// organization questions by every member of the organization.
+const (
+	LibraryVisibilityPrivate      = "private"
+	LibraryVisibilityOrganization = "organization"
+)
+
+// LibraryQuestion is a question kept outside of games so that it can be reused by many of them.
+// UsedBy counts the game questions following it.
+type LibraryQuestion struct {
+	Id          int       `json:"library_question_id" db:"id"`
+	OrgId       int       `json:"org_id" db:"org_id"`
+	OwnerId     int       `json:"owner_id" db:"owner_id"`
+	Owner       string    `json:"owner" db:"login"`
+	Visibility  string    `json:"visibility" db:"visibility"`
+	Description string    `json:"description" db:"description"`
+	AnswerNum   int       `json:"answer" db:"answer"`
+	AnswerText  string    `json:"answer_text" db:"answer_text"`
+	Cost        int       `json:"cost" db:"cost"`
+	Options     []string  `json:"options" db:"options"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	UsedBy      int       `json:"used_by" db:"used_by"`
+}
+
+// LibraryQuestionStats sums up how a library question did in every lobby that played it.
+type LibraryQuestionStats struct {
+	LibraryQuestionId int     `json:"library_question_id" db:"library_question_id"`
+	Games             int     `json:"games" db:"games"`
+	Lobbies           int     `json:"lobbies" db:"lobbies"`
+	Answers           int     `json:"answers" db:"answers"`
+	Correct           int     `json:"correct" db:"correct"`
+	CorrectRate       float64 `json:"correct_rate" db:"correct_rate"`
+	AverageScore      float64 `json:"average_score" db:"average_score"`
+}
+
+// Round is a section of a game, such as "Music" or "Blitz". The costs of its questions are
+// multiplied by Multiplier, the intermission is shown before the round starts.
+type Round struct {
+	Id                int     `json:"round_id" db:"id"`
+	GameId            int     `json:"game_id" db:"game_id"`
+	Position          int     `json:"position" db:"position"`
+	Title             string  `json:"title" db:"title"`
+	Multiplier        float64 `json:"multiplier" db:"multiplier"`
+	IntermissionText  string  `json:"intermission_text" db:"intermission_text"`
+	IntermissionSlide int     `json:"intermission_slide" db:"intermission_slide"`
+}
+
+// RoundScore is the subtotal of a player in a round, questions outside of rounds are counted with a zero RoundId.
+type RoundScore struct {
+	RoundId int    `json:"round_id" db:"round_id"`
+	Title   string `json:"title" db:"-"`
+	Score   int    `json:"score" db:"score"`
+}
+
+// Quiz file format, see docs/quiz-format.md.
+const (
+	QuizFileFormat  = "quizer"
+	QuizFileVersion = 1
+)
+
+// QuizFile is a portable copy of a whole quiz used by export and import.
+type QuizFile struct {
+	Format       string                `json:"format" yaml:"format"`
+	Version      int                   `json:"version" yaml:"version"`
+	Game         QuizFileGame          `json:"game" yaml:"game"`
+	Rounds       []QuizFileRound       `json:"rounds,omitempty" yaml:"rounds,omitempty"`
+	Questions    []QuizFileQuestion    `json:"questions" yaml:"questions"`
+	Presentation *QuizFilePresentation `json:"presentation,omitempty" yaml:"presentation,omitempty"`
+}
+
+type QuizFileGame struct {
+	Description string   `json:"description" yaml:"description"`
+	Category    string   `json:"category,omitempty" yaml:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// QuizFileRound is a round of the quiz, questions refer to it by its position.
+type QuizFileRound struct {
+	Position          int     `json:"position" yaml:"position"`
+	Title             string  `json:"title" yaml:"title"`
+	Multiplier        float64 `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	IntermissionText  string  `json:"intermission_text,omitempty" yaml:"intermission_text,omitempty"`
+	IntermissionSlide int     `json:"intermission_slide,omitempty" yaml:"intermission_slide,omitempty"`
+}
+
+// QuizFileQuestion is a question of the quiz. Round is the position of its round, 0 outside of rounds.
+type QuizFileQuestion struct {
+	Number      int      `json:"number" yaml:"number"`
+	Round       int      `json:"round,omitempty" yaml:"round,omitempty"`
+	Description string   `json:"description" yaml:"description"`
+	Cost        int      `json:"cost" yaml:"cost"`
+	Answer      int      `json:"answer" yaml:"answer"`
+	AnswerText  string   `json:"answer_text,omitempty" yaml:"answer_text,omitempty"`
+	Options     []string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// QuizFilePresentation holds the presentation file encoded with standard base64.
+type QuizFilePresentation struct {
+	Filename string `json:"filename" yaml:"filename"`
+	Data     string `json:"data" yaml:"data"`
+}
+
+// ImportIssue points to the offending field with a JSON-path like location, e.g. "questions[2].cost".
+type ImportIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ImportReport is the result of a quiz import. Nothing is imported when Errors is not empty.
+type ImportReport struct {
+	Valid     bool          `json:"valid"`
+	DryRun    bool          `json:"dry_run,omitempty"`
+	GameId    int           `json:"game_id,omitempty"`
+	Questions int           `json:"questions"`
+	Errors    []ImportIssue `json:"errors"`
+	Warnings  []ImportIssue `json:"warnings"`
 }
 
 type Lobby struct {
 	UUID      uuid.UUID `json:"uuid" db:"uuid"`
 	GameId    int       `json:"game_id" db:"game_id"`
 	IsStarted bool      `json:"is_started" db:"is_started"`
+	OrgId     int       `json:"org_id" db:"org_id"`
+	// SnapshotId is the version of the game the lobby plays, it is set when the lobby starts.
+	SnapshotId *int `json:"snapshot_id" db:"snapshot_id"`
+	// ShuffleQuestions plays the questions in a random order within their rounds, QuestionOrder
+	// holds the question numbers in that order once the lobby has started.
+	// ShuffleOptions shows the options of every question in a random order to each player.
+	ShuffleQuestions bool  `json:"shuffle_questions" db:"shuffle_questions"`
+	ShuffleOptions   bool  `json:"shuffle_options" db:"shuffle_options"`
+	QuestionOrder    []int `json:"question_order" db:"question_order"`
+}
+
+// QuestionNumber returns the number of the question played at the position, counting from 1,
+// in a lobby with the question order. Without an order questions are played by their numbers.
+func QuestionNumber(order []int, position int) int {
+	if position < 1 || position > len(order) {
+		return position
+	}
+	return order[position-1]
+}
+
+// OptionOrder is the order a player sees the options of a question in. Order[i] is the
+// original number of the option shown at position i+1, so answers are graded as Order[answer-1].
+type OptionOrder struct {
+	LobbyUUID  uuid.UUID `json:"lobby_uuid" db:"lobby_uuid"`
+	PlayerUUID uuid.UUID `json:"player_uuid" db:"player_uuid"`
+	QuestionId int       `json:"question_id" db:"question_id"`
+	Order      []int     `json:"option_order" db:"option_order"`
+}
+
+// Original returns the original number of the option shown at position shown,
+// answers outside of the options are returned as is.
+func (o OptionOrder) Original(shown int) int {
+	if shown < 1 || shown > len(o.Order) {
+		return shown
+	}
+	return o.Order[shown-1]
+}
+
+// GameSnapshot is an immutable version of a game and its questions. Started lobbies
+// serve and score questions from their snapshot, so editing the game does not affect them.
+type GameSnapshot struct {
+	Id          int       `json:"snapshot_id" db:"id"`
+	GameId      int       `json:"game_id" db:"game_id"`
+	Version     int       `json:"version" db:"version"`
+	Description string    `json:"description" db:"description"`
+	Link        string    `json:"link" db:"link"`
+	Questions   int       `json:"questions" db:"questions"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
 type Player struct {
@@ -42,6 +409,33 @@ type Player struct {
 	LobbyUUID uuid.UUID `json:"lobby_uuid" db:"lobby_uuid"`
 	IsAdmin   bool      `json:"is_admin" db:"is_admin"`
 	GameId    int       `json:"game_id" db:"game_id"`
+	UserId    *int      `json:"user_id" db:"user_id"`
+}
+
+// PlayerHistoryEntry is a lobby played by a user account with the player's total score
+// and rank among the lobby's players.
+type PlayerHistoryEntry struct {
+	LobbyUUID    uuid.UUID `json:"lobby_uuid" db:"lobby_uuid"`
+	GameId       int       `json:"game_id" db:"game_id"`
+	Description  string    `json:"description" db:"description"`
+	PlayedAt     time.Time `json:"played_at" db:"played_at"`
+	PlayerUUID   uuid.UUID `json:"player_uuid" db:"player_uuid"`
+	UserName     string    `json:"user_name" db:"user_name"`
+	Score        int       `json:"score" db:"score"`
+	Rank         int       `json:"rank" db:"rank"`
+	PlayersCount int       `json:"players_count" db:"players_count"`
+}
+
+const (
+	LobbyTokenHost   = "host"
+	LobbyTokenPlayer = "player"
+)
+
+// LobbyClaims are the claims of a signed per-lobby host or player token.
+type LobbyClaims struct {
+	Kind       string
+	LobbyUUID  uuid.UUID
+	PlayerUUID uuid.UUID
 }
 
 type Answer struct {
@@ -86,13 +480,69 @@ type SaveTextResult struct {
 }
 
 type CalcResult struct {
-	TotalScore int    `json:"total_score" db:"total_score"`
-	UserName   string `json:"user_name" db:"user_name"`
+	TotalScore int          `json:"total_score" db:"total_score"`
+	UserName   string       `json:"user_name" db:"user_name"`
+	Rounds     []RoundScore `json:"rounds,omitempty" db:"-"`
+}
+
+// PlayerRoundScore is RoundScore of the player UserName.
+type PlayerRoundScore struct {
+	UserName string `db:"user_name"`
+	RoundScore
 }
 
 type JwtResponce struct {
-	AccessToken string `json:"access_token"`
-	UserID      int    `json:"user_id"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	UserID       int    `json:"user_id"`
+}
+
+type RefreshToken struct {
+	Id        int        `json:"id" db:"id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	UserId    int        `json:"user_id" db:"user_id"`
+	FamilyId  uuid.UUID  `json:"family_id" db:"family_id"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+const (
+	LoginScopeLogin = "login"
+	LoginScopeIP    = "ip"
+)
+
+type LoginAttempt struct {
+	Scope         string     `json:"scope" db:"scope"`
+	Key           string     `json:"key" db:"key"`
+	Failures      int        `json:"failures" db:"failures"`
+	LockedUntil   *time.Time `json:"locked_until" db:"locked_until"`
+	LastFailureAt time.Time  `json:"last_failure_at" db:"last_failure_at"`
+}
+
+// OidcState is a pending OpenID Connect login, stored between the redirect to the provider and the callback.
+type OidcState struct {
+	State        string    `db:"state"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
 }
 
 type JwtRequest struct {
@@ -0,0 +1,53 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"quizer_server/internal/db"
+	"quizer_server/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// loadGame loads the game within the actor's organization.
+// Games of other organizations are reported as missing with pgx.ErrNoRows.
+func loadGame(ctx context.Context, s db.Storage, actor model.Actor, gameId int) (model.Game, error) {
+	if actor.OrgId == 0 {
+		return model.Game{}, fmt.Errorf("%w: user %d has no organization", model.ErrForbidden, actor.Id)
+	}
+	return s.GameLoad(ctx, actor.OrgId, gameId)
+}
+
+// RequireGameOwner returns model.ErrForbidden unless the actor owns the game or is an admin.
+// Storage errors, including pgx.ErrNoRows for a missing game, are returned as is.
+func RequireGameOwner(ctx context.Context, s db.Storage, actor model.Actor, gameId int) error {
+	game, err := loadGame(ctx, s, actor, gameId)
+	if err != nil {
+		return err
+	}
+	if actor.IsAdmin() || game.OwnerId == actor.Id {
+		return nil
+	}
+	return fmt.Errorf("%w: user %d does not own game %d", model.ErrForbidden, actor.Id, gameId)
+}
+
+// Require returns model.ErrForbidden unless the actor is an admin, owns the game
+// or is a collaborator whose permission covers perm.
+func Require(ctx context.Context, s db.Storage, actor model.Actor, gameId int, perm string) error {
+	game, err := loadGame(ctx, s, actor, gameId)
+	if err != nil {
+		return err
+	}
+	if actor.IsAdmin() || game.OwnerId == actor.Id {
+		return nil
+	}
+	granted, err := s.CollaboratorPermission(ctx, gameId, actor.Id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err == nil && model.PermissionCovers(granted, perm) {
+		return nil
+	}
+	return fmt.Errorf("%w: user %d has no %s permission on game %d", model.ErrForbidden, actor.Id, perm, gameId)
+}
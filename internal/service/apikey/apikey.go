@@ -0,0 +1,176 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"quizer_server/internal/db"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"strings"
+	"unicode/utf8"
+)
+
+// keyPrefix marks our API keys, e.g. qz_1a2b3c4d5e6f_<secret>.
+const keyPrefix = "qz"
+
+const maxNameLen = 64
+
+var (
+	ErrInvalidKey = errors.New("invalid api key")
+	ErrValidation = errors.New("validation failed")
+)
+
+var knownScopes = []string{
+	model.ScopeGamesRead,
+	model.ScopeGamesWrite,
+	model.ScopeLobbiesHost,
+}
+
+type Service interface {
+	Create(ctx context.Context, actor model.Actor, req dto.CreateApiKeyRequest) (model.ApiKey, string, error)
+	List(ctx context.Context, actor model.Actor) ([]model.ApiKey, error)
+	Revoke(ctx context.Context, actor model.Actor, id int) (int, error)
+	Authenticate(ctx context.Context, key string) (model.Actor, error)
+}
+
+type apiKeyService struct {
+	storage db.Storage
+}
+
+func New(s db.Storage) Service {
+	return &apiKeyService{
+		storage: s,
+	}
+}
+
+// Create generates a new API key for the actor. The plaintext key is returned
+// only once; the database keeps its SHA-256 hash.
+func (s *apiKeyService) Create(ctx context.Context, actor model.Actor, req dto.CreateApiKeyRequest) (model.ApiKey, string, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if utf8.RuneCountInString(req.Name) > maxNameLen {
+		return model.ApiKey{}, "", fmt.Errorf("%w: name must be at most %d characters long", ErrValidation, maxNameLen)
+	}
+	if len(req.Scopes) == 0 {
+		return model.ApiKey{}, "", fmt.Errorf("%w: at least one scope is required", ErrValidation)
+	}
+	for _, scope := range req.Scopes {
+		if !isKnownScope(scope) {
+			return model.ApiKey{}, "", fmt.Errorf("%w: unknown scope %q", ErrValidation, scope)
+		}
+	}
+
+	prefix, err := randomHex(6)
+	if err != nil {
+		return model.ApiKey{}, "", err
+	}
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return model.ApiKey{}, "", err
+	}
+	key := fmt.Sprintf("%s_%s_%s", keyPrefix, prefix, base64.RawURLEncoding.EncodeToString(secret))
+
+	data := model.ApiKey{
+		UserId:  actor.Id,
+		Name:    req.Name,
+		Prefix:  prefix,
+		KeyHash: hashKey(key),
+		Scopes:  req.Scopes,
+	}
+	id, err := s.storage.CreateApiKey(ctx, data)
+	if err != nil {
+		log.Println("api key svc create err:", err)
+		return model.ApiKey{}, "", err
+	}
+	data.Id = id
+
+	return data, key, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, actor model.Actor) ([]model.ApiKey, error) {
+	res, err := s.storage.ApiKeysByUser(ctx, actor.Id)
+	if err != nil {
+		log.Println("api key svc list err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, actor model.Actor, id int) (int, error) {
+	res, err := s.storage.RevokeApiKey(ctx, actor.Id, id)
+	if err != nil {
+		log.Println("api key svc revoke err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// Authenticate verifies the key and returns the actor of its owner, limited to the key's scopes.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (model.Actor, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix {
+		return model.Actor{}, ErrInvalidKey
+	}
+
+	stored, err := s.storage.ApiKeyByPrefix(ctx, parts[1])
+	if err != nil {
+		return model.Actor{}, ErrInvalidKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored.KeyHash), []byte(hashKey(key))) != 1 {
+		return model.Actor{}, ErrInvalidKey
+	}
+
+	if stored.RevokedAt != nil {
+		return model.Actor{}, ErrInvalidKey
+	}
+
+	user, err := s.storage.UserById(ctx, stored.UserId)
+	if err != nil {
+		log.Println("api key svc load user err:", err)
+		return model.Actor{}, ErrInvalidKey
+	}
+
+	err = s.storage.TouchApiKey(ctx, stored.Id)
+	if err != nil {
+		log.Println("api key svc touch err:", err)
+	}
+
+	return model.Actor{
+		Id:       user.Id,
+		Login:    user.Login,
+		Roles:    user.Roles,
+		Scopes:   stored.Scopes,
+		ApiKeyId: stored.Id,
+	}, nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, s := range knownScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
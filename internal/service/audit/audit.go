@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"quizer_server/internal/db"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+type Service interface {
+	Record(ctx context.Context, actor model.Actor, action string, targetType string, targetId any, before any, after any)
+	List(ctx context.Context, filter dto.AuditFilter) (model.AuditPage, error)
+}
+
+type auditService struct {
+	storage db.Storage
+}
+
+func New(s db.Storage) Service {
+	return &auditService{
+		storage: s,
+	}
+}
+
+// Record writes an audit record of a mutation. before and after are stored as JSON,
+// nil values are stored as NULL. Failures are logged and never break the mutation itself.
+func (as *auditService) Record(ctx context.Context, actor model.Actor, action string, targetType string, targetId any, before any, after any) {
+	rec := model.AuditRecord{
+		Action:     action,
+		TargetType: targetType,
+		TargetId:   fmt.Sprint(targetId),
+		Before:     marshal(before),
+		After:      marshal(after),
+		IP:         actor.IP,
+	}
+	if actor.Id != 0 {
+		rec.ActorId = &actor.Id
+	}
+
+	err := as.storage.CreateAuditRecord(ctx, rec)
+	if err != nil {
+		log.Println("audit svc record err:", err)
+	}
+}
+
+func (as *auditService) List(ctx context.Context, filter dto.AuditFilter) (model.AuditPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	items, total, err := as.storage.AuditList(ctx, filter)
+	if err != nil {
+		log.Println("audit svc list err:", err)
+		return model.AuditPage{}, err
+	}
+
+	return model.AuditPage{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+func marshal(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Println("audit svc marshal err:", err)
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,92 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"strings"
+	"time"
+)
+
+// Clone copies the game with all its questions and its presentation into a new game
+// owned by the actor. The game and questions are copied in one transaction, the copy
+// of the presentation file is removed again when the transaction fails.
+func (gs *gameService) Clone(ctx context.Context, actor model.Actor, gameId int, req dto.CloneGameRequest) (int, error) {
+	err := access.Require(ctx, gs.storage, actor, gameId, model.PermissionView)
+	if err != nil {
+		log.Println("game svc clone access err:", err)
+		return 0, err
+	}
+	source, err := gs.storage.GameLoad(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("game svc clone load err:", err)
+		return 0, err
+	}
+
+	data := dto.CreateNewGame{
+		OwnerId:     actor.Id,
+		OrgId:       actor.OrgId,
+		Description: strings.TrimSpace(req.Description),
+		Category:    source.Category,
+		Tags:        source.Tags,
+	}
+	if data.Description == "" {
+		data.Description = source.Description + " (copy)"
+	}
+
+	if source.Link != "" {
+		link := fmt.Sprintf("%sclone_%d_%d%s", uploadsDir, actor.Id, time.Now().UnixNano(), filepath.Ext(source.Link))
+		err = copyFile(source.Link, link)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// the copy is still useful without the presentation, it can be uploaded again
+			log.Println("game svc clone presentation is missing:", source.Link)
+		case err != nil:
+			log.Println("game svc clone copy presentation err:", err)
+			return 0, err
+		default:
+			data.Link = link
+		}
+	}
+
+	id, err := gs.storage.CloneGame(ctx, gameId, data)
+	if err != nil {
+		log.Println("game svc clone err:", err)
+		if data.Link != "" {
+			os.Remove(data.Link)
+		}
+		return 0, err
+	}
+	gs.audit.Record(ctx, actor, "game.clone", model.AuditTargetGame, id, map[string]any{"source_id": gameId}, data)
+	return id, nil
+}
+
+// copyFile copies src to the new file dst, a partly written dst is removed.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
@@ -2,54 +2,98 @@ package game
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"quizer_server/internal/db"
 	"quizer_server/internal/dto"
 	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"quizer_server/internal/service/audit"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+var ErrValidation = errors.New("validation failed")
+
 type Service interface {
-	CreateNewGame(ctx context.Context, data dto.CreateNewGame) (int, error)
-	GameList(ctx context.Context) ([]model.Game, error)
-	GameLoad(ctx context.Context, id int) (model.Game, error)
-	DeleteGame(ctx context.Context, id int) (int, error)
-	UpdateGame(ctx context.Context, updated model.Game) (int, error)
-	UpdateFilePath(ctx context.Context, gameId int, path string) (int, error)
+	CreateNewGame(ctx context.Context, actor model.Actor, data dto.CreateNewGame) (int, error)
+	GameList(ctx context.Context, filter dto.GameListFilter) ([]model.Game, error)
+	GameLoad(ctx context.Context, actor model.Actor, id int) (model.Game, error)
+	DeleteGame(ctx context.Context, actor model.Actor, id int) (int, error)
+	Trash(ctx context.Context, actor model.Actor) ([]model.Game, error)
+	RestoreGame(ctx context.Context, actor model.Actor, id int) (int, error)
+	PurgeTrash(ctx context.Context, before time.Time) error
+	UpdateGame(ctx context.Context, actor model.Actor, updated model.Game) (int, error)
+	CheckAccess(ctx context.Context, actor model.Actor, gameId int, perm string) error
+	UpdateFilePath(ctx context.Context, actor model.Actor, gameId int, path string) (int, error)
+	PresentationPath(ctx context.Context, actor model.Actor, gameId int) (string, error)
+	LobbyPresentationPath(ctx context.Context, lobbyUUID uuid.UUID) (string, error)
+	Export(ctx context.Context, actor model.Actor, gameId int) (model.QuizFile, error)
+	Import(ctx context.Context, actor model.Actor, file model.QuizFile) (model.ImportReport, error)
+	Clone(ctx context.Context, actor model.Actor, gameId int, req dto.CloneGameRequest) (int, error)
+	Snapshots(ctx context.Context, actor model.Actor, gameId int) ([]model.GameSnapshot, error)
+	SetStatus(ctx context.Context, actor model.Actor, gameId int, status string) ([]model.ImportIssue, error)
+	SetLabels(ctx context.Context, actor model.Actor, gameId int, req dto.SetGameLabelsRequest) error
+
+	Rounds(ctx context.Context, actor model.Actor, gameId int) ([]model.Round, error)
+	CreateRound(ctx context.Context, actor model.Actor, gameId int, req dto.RoundRequest) (int, error)
+	UpdateRound(ctx context.Context, actor model.Actor, id int, req dto.RoundRequest) (int, error)
+	DeleteRound(ctx context.Context, actor model.Actor, id int) (int, error)
+	LobbyRounds(ctx context.Context, lobbyUUID uuid.UUID) ([]model.Round, error)
+	Search(ctx context.Context, filter dto.GameListFilter, query string, limit int) ([]model.GameSearchResult, error)
+	Tags(ctx context.Context, filter dto.GameListFilter) ([]model.LabelCount, error)
+	Categories(ctx context.Context, filter dto.GameListFilter) ([]model.LabelCount, error)
+
+	Collaborators(ctx context.Context, actor model.Actor, gameId int) ([]model.Collaborator, error)
+	AddCollaborator(ctx context.Context, actor model.Actor, gameId int, req dto.AddCollaboratorRequest) error
+	RemoveCollaborator(ctx context.Context, actor model.Actor, gameId int, userId int) (int, error)
 
 	GetPlayersByGameUUID(ctx context.Context, gameUUID uuid.UUID) []model.Player
-	SavePlayer(ctx context.Context, newPlayer model.Player) error
+	SavePlayer(ctx context.Context, actor model.Actor, newPlayer model.Player) error
 
-	SaveAnswer(ctx context.Context, data model.Answer)
+	SaveAnswer(ctx context.Context, actor model.Actor, data model.Answer)
 	GetTextAnswers(ctx context.Context, lobbyUUID uuid.UUID) []model.PlayerTextAnswer
 
 	CalcResultNum(ctx context.Context, lobbyUUID uuid.UUID)
 	CalculateQuizResult(ctx context.Context, lobbyUUID uuid.UUID) []model.CalcResult
-	SaveTextResult(ctx context.Context, result model.SaveTextResult)
+	SaveTextResult(ctx context.Context, actor model.Actor, result model.SaveTextResult)
 }
 
 type gameService struct {
 	storage db.Storage
+	audit   audit.Service
 }
 
-func New(s db.Storage) Service {
+func New(s db.Storage, a audit.Service) Service {
 	return &gameService{
 		storage: s,
+		audit:   a,
 	}
 }
 
-func (gs *gameService) CreateNewGame(ctx context.Context, data dto.CreateNewGame) (int, error) {
+func (gs *gameService) CreateNewGame(ctx context.Context, actor model.Actor, data dto.CreateNewGame) (int, error) {
+	if data.OrgId == 0 {
+		return 0, fmt.Errorf("%w: user %d has no organization", model.ErrForbidden, actor.Id)
+	}
+	var err error
+	data.Category, data.Tags, err = normalizeLabels(data.Category, data.Tags)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
 	id, err := gs.storage.CreateGame(ctx, data)
 	if err != nil {
 		log.Println(err)
 		return id, err
 	}
+	gs.audit.Record(ctx, actor, "game.create", model.AuditTargetGame, id, nil, data)
 	return id, err
 }
 
-func (gs *gameService) GameList(ctx context.Context) ([]model.Game, error) {
-	list, err := gs.storage.GameList(ctx)
+func (gs *gameService) GameList(ctx context.Context, filter dto.GameListFilter) ([]model.Game, error) {
+	list, err := gs.storage.GameList(ctx, filter)
 	if err != nil {
 		log.Println(err)
 		return list, err
@@ -57,8 +101,13 @@ func (gs *gameService) GameList(ctx context.Context) ([]model.Game, error) {
 	return list, nil
 }
 
-func (gs *gameService) GameLoad(ctx context.Context, id int) (model.Game, error) {
-	res, err := gs.storage.GameLoad(ctx, id)
+func (gs *gameService) GameLoad(ctx context.Context, actor model.Actor, id int) (model.Game, error) {
+	err := access.Require(ctx, gs.storage, actor, id, model.PermissionView)
+	if err != nil {
+		log.Println("game svc load access err:", err)
+		return model.Game{}, err
+	}
+	res, err := gs.storage.GameLoad(ctx, actor.OrgId, id)
 	if err != nil {
 		log.Println(err)
 		return res, err
@@ -66,39 +115,69 @@ func (gs *gameService) GameLoad(ctx context.Context, id int) (model.Game, error)
 	return res, nil
 }
 
-func (gs *gameService) UpdateGame(ctx context.Context, updated model.Game) (int, error) {
+func (gs *gameService) UpdateGame(ctx context.Context, actor model.Actor, updated model.Game) (int, error) {
+	err := access.Require(ctx, gs.storage, actor, updated.Id, model.PermissionEdit)
+	if err != nil {
+		log.Println("game svc update access err:", err)
+		return 0, err
+	}
+	before, _ := gs.storage.GameLoad(ctx, actor.OrgId, updated.Id)
 	res, err := gs.storage.UpdateGame(ctx, updated)
 	if err != nil || res == 0 {
 		log.Println(err)
 		return res, err
 	}
+	after, _ := gs.storage.GameLoad(ctx, actor.OrgId, updated.Id)
+	gs.audit.Record(ctx, actor, "game.update", model.AuditTargetGame, updated.Id, before, after)
 	return res, nil
 }
 
-func (gs *gameService) DeleteGame(ctx context.Context, id int) (int, error) {
+func (gs *gameService) DeleteGame(ctx context.Context, actor model.Actor, id int) (int, error) {
+	err := access.RequireGameOwner(ctx, gs.storage, actor, id)
+	if err != nil {
+		log.Println("game svc delete access err:", err)
+		return 0, err
+	}
+	before, _ := gs.storage.GameLoad(ctx, actor.OrgId, id)
 	res, err := gs.storage.DeleteGame(ctx, id)
 	if err != nil {
 		log.Println(err)
 		return res, err
 	}
+	gs.audit.Record(ctx, actor, "game.delete", model.AuditTargetGame, id, before, nil)
 	return res, nil
 }
 
-func (gs *gameService) SavePlayer(ctx context.Context, newPlayer model.Player) error {
-	gs.storage.SavePlayer(ctx, newPlayer)
+// SavePlayer registers the player joining the lobby, the actor is the player's account or
+// an anonymous actor with the player's address.
+func (gs *gameService) SavePlayer(ctx context.Context, actor model.Actor, newPlayer model.Player) error {
+	err := gs.storage.SavePlayer(ctx, newPlayer)
+	if err != nil {
+		log.Println("game svc save player err:", err)
+		return err
+	}
+	gs.audit.Record(ctx, actor, "lobby.join", model.AuditTargetLobby, newPlayer.LobbyUUID, nil,
+		map[string]any{"player_uuid": newPlayer.UUID, "user_name": newPlayer.UserName, "is_admin": newPlayer.IsAdmin})
 	return nil
 }
 
 func (gs *gameService) GetPlayersByGameUUID(ctx context.Context, gameUUID uuid.UUID) []model.Player {
-	res, _ := gs.storage.PlayersByGameUUID(ctx, gameUUID)
+	lobby, err := gs.storage.LobbyLoadByUUID(ctx, gameUUID)
+	if err != nil {
+		log.Println("game svc players load lobby err:", err)
+		return nil
+	}
+	res, _ := gs.storage.PlayersByGameUUID(ctx, lobby.OrgId, gameUUID)
 	return res
 }
 
-func (gs *gameService) SaveAnswer(ctx context.Context, data model.Answer) {
+func (gs *gameService) SaveAnswer(ctx context.Context, actor model.Actor, data model.Answer) {
 	err := gs.storage.SaveAnswer(ctx, data)
 	if err != nil {
 		log.Println("service save answer err: ", err)
+		return
 	}
+	gs.audit.Record(ctx, actor, "lobby.answer", model.AuditTargetLobby, data.LobbyUUID, nil, data)
 }
 
 func (gs *gameService) GetTextAnswers(ctx context.Context, lobbyUUID uuid.UUID) []model.PlayerTextAnswer {
@@ -110,6 +189,8 @@ func (gs *gameService) GetTextAnswers(ctx context.Context, lobbyUUID uuid.UUID)
 	return answers
 }
 
+// CalcResultNum grades the answers to the questions with numbered answers,
+// answers given to shuffled options are graded against the original options.
 func (gs *gameService) CalcResultNum(ctx context.Context, lobbyUUID uuid.UUID) {
 	log.Println("calcucating result")
 	lobby, err := gs.storage.LobbyLoadByUUID(ctx, lobbyUUID)
@@ -126,25 +207,49 @@ func (gs *gameService) CalcResultNum(ctx context.Context, lobbyUUID uuid.UUID) {
 		log.Println("calc result num answer list is empty")
 	}
 
-	qArr, err := gs.storage.QuestionsByGameId(ctx, lobby.GameId)
+	qArr, err := gs.lobbyQuestions(ctx, lobby)
 	if err != nil {
 		log.Println("calc result num load questions err: ", err)
 
 	}
+	rounds, err := gs.lobbyRounds(ctx, lobby)
+	if err != nil {
+		log.Println("calc result num load rounds err: ", err)
+	}
+
+	// players of lobbies shuffling options answer with the position they saw the option at
+	type orderKey struct {
+		playerUUID uuid.UUID
+		questionId int
+	}
+	orders := map[orderKey]model.OptionOrder{}
+	if lobby.ShuffleOptions {
+		stored, err := gs.storage.OptionOrders(ctx, lobbyUUID, uuid.Nil)
+		if err != nil {
+			log.Println("calc result num load option orders err: ", err)
+		}
+		for _, o := range stored {
+			orders[orderKey{o.PlayerUUID, o.QuestionId}] = o
+		}
+	}
 
 	for _, a := range answers {
 		for _, q := range qArr {
 			if a.AnswerNum != 0 && a.QuestionNumber == q.Number {
+				answerNum := a.AnswerNum
+				if order, ok := orders[orderKey{a.PlayerUUID, q.Id}]; ok {
+					answerNum = order.Original(answerNum)
+				}
 				score := 0
-				if a.AnswerNum == q.AnswerNum {
-					score = q.Cost
+				if answerNum == q.AnswerNum {
+					score = questionScore(q, rounds)
 				}
 				res := model.Result{
 					LobbyUUID:      lobbyUUID,
 					PlayerUUID:     a.PlayerUUID,
 					QuestionNumber: a.QuestionNumber,
 					QuestionId:     a.QuestionId,
-					AnswerNumber:   a.AnswerNum,
+					AnswerNumber:   answerNum,
 					Score:          score,
 				}
 				err = gs.storage.SaveResult(ctx, res)
@@ -157,8 +262,9 @@ func (gs *gameService) CalcResultNum(ctx context.Context, lobbyUUID uuid.UUID) {
 	}
 }
 
-func (gs *gameService) SaveTextResult(ctx context.Context, data model.SaveTextResult) {
-	question, err := gs.storage.QuestionLoadByNumber(ctx, data.GameId, data.QuestionNumber)
+// SaveTextResult grades the text answer of the player, the actor is the host.
+func (gs *gameService) SaveTextResult(ctx context.Context, actor model.Actor, data model.SaveTextResult) {
+	question, err := gs.lobbyQuestion(ctx, data.LobbyUUID, data.QuestionNumber)
 	if err != nil {
 		log.Println("game service save text result question load err:", err)
 		return
@@ -168,9 +274,19 @@ func (gs *gameService) SaveTextResult(ctx context.Context, data model.SaveTextRe
 		log.Println("game service save text result answer load err:", err)
 		return
 	}
+	lobby, err := gs.storage.LobbyLoadByUUID(ctx, data.LobbyUUID)
+	if err != nil {
+		log.Println("game service save text result lobby load err:", err)
+		return
+	}
+	rounds, err := gs.lobbyRounds(ctx, lobby)
+	if err != nil {
+		log.Println("game service save text result rounds load err:", err)
+		return
+	}
 	score := 0
 	if data.IsCorrect {
-		score = 1
+		score = questionScore(question, rounds)
 	}
 	result := model.Result{
 		LobbyUUID:      data.LobbyUUID,
@@ -178,25 +294,208 @@ func (gs *gameService) SaveTextResult(ctx context.Context, data model.SaveTextRe
 		QuestionNumber: data.QuestionNumber,
 		QuestionId:     answer.QuestionId,
 		AnswerText:     answer.AnswerText,
-		Score:          question.Cost * score,
+		Score:          score,
 	}
 	err = gs.storage.SaveResult(ctx, result)
 	if err != nil {
 		log.Println("game service save text result save result err:", err)
 		return
 	}
+	gs.audit.Record(ctx, actor, "lobby.grade", model.AuditTargetLobby, data.LobbyUUID, nil, result)
+}
+
+// lobbyQuestions returns the questions the lobby is scored against: its snapshot,
+// or the live questions for lobbies started before snapshots existed.
+func (gs *gameService) lobbyQuestions(ctx context.Context, lobby model.Lobby) ([]model.Question, error) {
+	if lobby.SnapshotId != nil {
+		return gs.storage.SnapshotQuestions(ctx, *lobby.SnapshotId)
+	}
+	return gs.storage.QuestionsByGameId(ctx, lobby.OrgId, lobby.GameId)
+}
+
+// lobbyQuestion is lobbyQuestions for a single question.
+func (gs *gameService) lobbyQuestion(ctx context.Context, lobbyUUID uuid.UUID, number int) (model.Question, error) {
+	lobby, err := gs.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	if err != nil {
+		return model.Question{}, err
+	}
+	if lobby.SnapshotId != nil {
+		return gs.storage.SnapshotQuestionByNumber(ctx, *lobby.SnapshotId, number)
+	}
+	return gs.storage.QuestionLoadByNumber(ctx, lobby.OrgId, lobby.GameId, number)
+}
+
+// Snapshots lists the versions of the game that lobbies have played.
+func (gs *gameService) Snapshots(ctx context.Context, actor model.Actor, gameId int) ([]model.GameSnapshot, error) {
+	err := access.Require(ctx, gs.storage, actor, gameId, model.PermissionView)
+	if err != nil {
+		log.Println("game svc snapshots access err:", err)
+		return nil, err
+	}
+	res, err := gs.storage.GameSnapshots(ctx, gameId)
+	if err != nil {
+		log.Println("game svc snapshots err:", err)
+		return res, err
+	}
+	return res, nil
 }
 
+// CalculateQuizResult returns the final standings of the lobby with the subtotals per round.
 func (gs *gameService) CalculateQuizResult(ctx context.Context, lobbyUUID uuid.UUID) []model.CalcResult {
 	res := gs.storage.CalculateResults(ctx, lobbyUUID)
+
+	rounds, err := gs.LobbyRounds(ctx, lobbyUUID)
+	if err != nil || len(rounds) == 0 {
+		return res
+	}
+	scores, err := gs.storage.CalculateRoundResults(ctx, lobbyUUID)
+	if err != nil {
+		log.Println("game svc calculate quiz result rounds err:", err)
+		return res
+	}
+	roundSubtotals(res, scores, rounds)
 	return res
 }
 
-func (gs *gameService) UpdateFilePath(ctx context.Context, gameId int, path string) (int, error) {
+// CheckAccess returns model.ErrForbidden unless the actor has the permission on the game.
+func (gs *gameService) CheckAccess(ctx context.Context, actor model.Actor, gameId int, perm string) error {
+	err := access.Require(ctx, gs.storage, actor, gameId, perm)
+	if err != nil {
+		log.Println("game svc check access err:", err)
+		return err
+	}
+	return nil
+}
+
+func (gs *gameService) UpdateFilePath(ctx context.Context, actor model.Actor, gameId int, path string) (int, error) {
+	err := access.Require(ctx, gs.storage, actor, gameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("update file path access err:", err)
+		return 0, err
+	}
+	before, _ := gs.storage.GameLoad(ctx, actor.OrgId, gameId)
 	id, err := gs.storage.UpdateFilePath(ctx, gameId, path)
 	if err != nil {
 		log.Println("update file path err:", err)
 		return id, err
 	}
+	gs.audit.Record(ctx, actor, "game.upload_presentation", model.AuditTargetGame, gameId, before.Link, path)
+	return id, nil
+}
+
+// PresentationPath returns the path of the presentation uploaded for the game.
+func (gs *gameService) PresentationPath(ctx context.Context, actor model.Actor, gameId int) (string, error) {
+	err := access.Require(ctx, gs.storage, actor, gameId, model.PermissionView)
+	if err != nil {
+		log.Println("game svc presentation path access err:", err)
+		return "", err
+	}
+	game, err := gs.storage.GameLoad(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("game svc presentation path err:", err)
+		return "", err
+	}
+	return game.Link, nil
+}
+
+// LobbyPresentationPath returns the path of the presentation of the game played in the lobby.
+// Knowing the lobby uuid is what lets players in, so no actor is required here.
+func (gs *gameService) LobbyPresentationPath(ctx context.Context, lobbyUUID uuid.UUID) (string, error) {
+	lobby, err := gs.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	if err != nil {
+		log.Println("game svc lobby presentation path load lobby err:", err)
+		return "", err
+	}
+	game, err := gs.storage.GameLoad(ctx, lobby.OrgId, lobby.GameId)
+	if err != nil {
+		log.Println("game svc lobby presentation path err:", err)
+		return "", err
+	}
+	return game.Link, nil
+}
+
+// Collaborators lists the users the game is shared with. Any collaborator may see the list.
+func (gs *gameService) Collaborators(ctx context.Context, actor model.Actor, gameId int) ([]model.Collaborator, error) {
+	err := access.Require(ctx, gs.storage, actor, gameId, model.PermissionView)
+	if err != nil {
+		log.Println("game svc collaborators access err:", err)
+		return nil, err
+	}
+	res, err := gs.storage.CollaboratorsByGame(ctx, gameId)
+	if err != nil {
+		log.Println("game svc collaborators err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// AddCollaborator shares the game with the user with the given login or changes
+// the permission of an existing collaborator. Only the owner can share the game.
+func (gs *gameService) AddCollaborator(ctx context.Context, actor model.Actor, gameId int, req dto.AddCollaboratorRequest) error {
+	err := access.RequireGameOwner(ctx, gs.storage, actor, gameId)
+	if err != nil {
+		log.Println("game svc add collaborator access err:", err)
+		return err
+	}
+
+	switch req.Permission {
+	case model.PermissionView, model.PermissionEdit, model.PermissionHost:
+	default:
+		return fmt.Errorf("%w: unknown permission %q", ErrValidation, req.Permission)
+	}
+
+	user, err := gs.storage.UserByLogin(ctx, req.Login)
+	if err != nil {
+		log.Println("game svc add collaborator load user err:", err)
+		return err
+	}
+
+	game, err := gs.storage.GameLoad(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("game svc add collaborator load game err:", err)
+		return err
+	}
+	if user.Id == game.OwnerId {
+		return fmt.Errorf("%w: the owner can not be a collaborator", ErrValidation)
+	}
+	_, err = gs.storage.OrganizationRole(ctx, game.OrgId, user.Id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: user %s is not a member of the organization", ErrValidation, req.Login)
+	}
+	if err != nil {
+		log.Println("game svc add collaborator load membership err:", err)
+		return err
+	}
+
+	before, _ := gs.storage.CollaboratorPermission(ctx, gameId, user.Id)
+	err = gs.storage.SaveCollaborator(ctx, gameId, user.Id, req.Permission)
+	if err != nil {
+		log.Println("game svc add collaborator err:", err)
+		return err
+	}
+
+	gs.audit.Record(ctx, actor, "game.share", model.AuditTargetGame, gameId,
+		map[string]any{"user_id": user.Id, "permission": before},
+		map[string]any{"user_id": user.Id, "permission": req.Permission})
+	return nil
+}
+
+// RemoveCollaborator revokes the user's access to the game. Only the owner can do it.
+func (gs *gameService) RemoveCollaborator(ctx context.Context, actor model.Actor, gameId int, userId int) (int, error) {
+	err := access.RequireGameOwner(ctx, gs.storage, actor, gameId)
+	if err != nil {
+		log.Println("game svc remove collaborator access err:", err)
+		return 0, err
+	}
+
+	before, _ := gs.storage.CollaboratorPermission(ctx, gameId, userId)
+	id, err := gs.storage.DeleteCollaborator(ctx, gameId, userId)
+	if err != nil {
+		log.Println("game svc remove collaborator err:", err)
+		return id, err
+	}
+
+	gs.audit.Record(ctx, actor, "game.unshare", model.AuditTargetGame, gameId,
+		map[string]any{"user_id": userId, "permission": before}, nil)
 	return id, nil
 }
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxTags           = 20
+	maxLabelLength    = 64
+	defaultSearchSize = 50
+	maxSearchSize     = 200
+)
+
+// normalizeLabels trims the category and turns the tags into a sorted set of lower case words,
+// so that "Kino" and " kino" are the same tag.
+func normalizeLabels(category string, tags []string) (string, []string, error) {
+	category = strings.TrimSpace(category)
+	if utf8.RuneCountInString(category) > maxLabelLength {
+		return "", nil, fmt.Errorf("category is longer than %d characters", maxLabelLength)
+	}
+
+	res := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxLabelLength {
+			return "", nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxLabelLength)
+		}
+		res = append(res, tag)
+	}
+	slices.Sort(res)
+	res = slices.Compact(res)
+	if len(res) > maxTags {
+		return "", nil, fmt.Errorf("a game can have at most %d tags", maxTags)
+	}
+	return category, res, nil
+}
+
+// SetLabels replaces the category and the tags of the game.
+func (gs *gameService) SetLabels(ctx context.Context, actor model.Actor, gameId int, req dto.SetGameLabelsRequest) error {
+	category, tags, err := normalizeLabels(req.Category, req.Tags)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	err = access.Require(ctx, gs.storage, actor, gameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("game svc set labels access err:", err)
+		return err
+	}
+	before, err := gs.storage.GameLoad(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("game svc set labels load err:", err)
+		return err
+	}
+
+	_, err = gs.storage.UpdateGameLabels(ctx, gameId, category, tags)
+	if err != nil {
+		log.Println("game svc set labels err:", err)
+		return err
+	}
+	gs.audit.Record(ctx, actor, "game.set_labels", model.AuditTargetGame, gameId,
+		dto.SetGameLabelsRequest{Category: before.Category, Tags: before.Tags},
+		dto.SetGameLabelsRequest{Category: category, Tags: tags})
+	return nil
+}
+
+// Search runs a full-text search over the games of the filter and their questions.
+func (gs *gameService) Search(ctx context.Context, filter dto.GameListFilter, query string, limit int) ([]model.GameSearchResult, error) {
+	if limit <= 0 {
+		limit = defaultSearchSize
+	}
+	if limit > maxSearchSize {
+		limit = maxSearchSize
+	}
+	res, err := gs.storage.SearchGames(ctx, filter, strings.TrimSpace(query), limit)
+	if err != nil {
+		log.Println("game svc search err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// Tags counts the games of the filter per tag.
+func (gs *gameService) Tags(ctx context.Context, filter dto.GameListFilter) ([]model.LabelCount, error) {
+	res, err := gs.storage.GameTags(ctx, filter)
+	if err != nil {
+		log.Println("game svc tags err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// Categories counts the games of the filter per category.
+func (gs *gameService) Categories(ctx context.Context, filter dto.GameListFilter) ([]model.LabelCount, error) {
+	res, err := gs.storage.GameCategories(ctx, filter)
+	if err != nil {
+		log.Println("game svc categories err:", err)
+		return res, err
+	}
+	return res, nil
+}
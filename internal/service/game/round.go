@@ -0,0 +1,198 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"quizer_server/internal/db"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// validateRound trims the request and fills the defaults.
+func validateRound(req dto.RoundRequest) (dto.RoundRequest, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	req.IntermissionText = strings.TrimSpace(req.IntermissionText)
+	switch {
+	case req.Title == "":
+		return req, fmt.Errorf("%w: round title is required", ErrValidation)
+	case req.Position < 0:
+		return req, fmt.Errorf("%w: round position must not be negative", ErrValidation)
+	case req.Multiplier < 0 || math.IsNaN(req.Multiplier) || math.IsInf(req.Multiplier, 0):
+		return req, fmt.Errorf("%w: round multiplier must be positive or 0 to count the questions at their cost", ErrValidation)
+	case req.IntermissionSlide < 0:
+		return req, fmt.Errorf("%w: intermission slide must not be negative", ErrValidation)
+	}
+	if req.Multiplier == 0 {
+		req.Multiplier = 1
+	}
+	return req, nil
+}
+
+// Rounds lists the rounds of the game in their order.
+func (gs *gameService) Rounds(ctx context.Context, actor model.Actor, gameId int) ([]model.Round, error) {
+	err := access.Require(ctx, gs.storage, actor, gameId, model.PermissionView)
+	if err != nil {
+		log.Println("game svc rounds access err:", err)
+		return nil, err
+	}
+	res, err := gs.storage.RoundsByGameId(ctx, gameId)
+	if err != nil {
+		log.Println("game svc rounds err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// CreateRound adds a round to the game.
+func (gs *gameService) CreateRound(ctx context.Context, actor model.Actor, gameId int, req dto.RoundRequest) (int, error) {
+	req, err := validateRound(req)
+	if err != nil {
+		return 0, err
+	}
+	err = access.Require(ctx, gs.storage, actor, gameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("game svc create round access err:", err)
+		return 0, err
+	}
+	id, err := gs.storage.CreateRound(ctx, gameId, req)
+	if db.IsUniqueViolation(err) {
+		return 0, fmt.Errorf("%w: position %d is taken by another round", ErrValidation, req.Position)
+	}
+	if err != nil {
+		log.Println("game svc create round err:", err)
+		return id, err
+	}
+	gs.audit.Record(ctx, actor, "round.create", model.AuditTargetGame, gameId, nil, map[string]any{"round_id": id, "round": req})
+	return id, nil
+}
+
+// UpdateRound replaces the round, a zero position keeps the current one.
+func (gs *gameService) UpdateRound(ctx context.Context, actor model.Actor, id int, req dto.RoundRequest) (int, error) {
+	req, err := validateRound(req)
+	if err != nil {
+		return 0, err
+	}
+	before, err := gs.storage.RoundLoad(ctx, actor.OrgId, id)
+	if err != nil {
+		log.Println("game svc update round load err:", err)
+		return 0, err
+	}
+	err = access.Require(ctx, gs.storage, actor, before.GameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("game svc update round access err:", err)
+		return 0, err
+	}
+
+	after := model.Round{
+		Id:                id,
+		GameId:            before.GameId,
+		Position:          req.Position,
+		Title:             req.Title,
+		Multiplier:        req.Multiplier,
+		IntermissionText:  req.IntermissionText,
+		IntermissionSlide: req.IntermissionSlide,
+	}
+	if after.Position == 0 {
+		after.Position = before.Position
+	}
+	res, err := gs.storage.UpdateRound(ctx, after)
+	if db.IsUniqueViolation(err) {
+		return 0, fmt.Errorf("%w: position %d is taken by another round", ErrValidation, after.Position)
+	}
+	if err != nil {
+		log.Println("game svc update round err:", err)
+		return res, err
+	}
+	gs.audit.Record(ctx, actor, "round.update", model.AuditTargetGame, before.GameId, before, after)
+	return res, nil
+}
+
+// DeleteRound removes the round, its questions stay in the game.
+func (gs *gameService) DeleteRound(ctx context.Context, actor model.Actor, id int) (int, error) {
+	before, err := gs.storage.RoundLoad(ctx, actor.OrgId, id)
+	if err != nil {
+		log.Println("game svc delete round load err:", err)
+		return 0, err
+	}
+	err = access.Require(ctx, gs.storage, actor, before.GameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("game svc delete round access err:", err)
+		return 0, err
+	}
+	res, err := gs.storage.DeleteRound(ctx, id)
+	if err != nil {
+		log.Println("game svc delete round err:", err)
+		return res, err
+	}
+	gs.audit.Record(ctx, actor, "round.delete", model.AuditTargetGame, before.GameId, before, nil)
+	return res, nil
+}
+
+// LobbyRounds returns the rounds the lobby plays, see lobbyQuestions.
+func (gs *gameService) LobbyRounds(ctx context.Context, lobbyUUID uuid.UUID) ([]model.Round, error) {
+	lobby, err := gs.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	if err != nil {
+		log.Println("game svc lobby rounds load lobby err:", err)
+		return nil, err
+	}
+	res, err := gs.lobbyRounds(ctx, lobby)
+	if err != nil {
+		log.Println("game svc lobby rounds err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+func (gs *gameService) lobbyRounds(ctx context.Context, lobby model.Lobby) ([]model.Round, error) {
+	if lobby.SnapshotId != nil {
+		return gs.storage.SnapshotRounds(ctx, *lobby.SnapshotId)
+	}
+	return gs.storage.RoundsByGameId(ctx, lobby.GameId)
+}
+
+// questionScore is the cost of the question multiplied by the multiplier of its round.
+func questionScore(q model.Question, rounds []model.Round) int {
+	if q.RoundId == nil {
+		return q.Cost
+	}
+	i := slices.IndexFunc(rounds, func(r model.Round) bool { return r.Id == *q.RoundId })
+	if i < 0 {
+		return q.Cost
+	}
+	return int(math.Round(float64(q.Cost) * rounds[i].Multiplier))
+}
+
+// roundSubtotals attaches the scores per round to the standings, in the order of the rounds.
+// Points of questions outside of rounds come last with a zero round id.
+func roundSubtotals(standings []model.CalcResult, scores []model.PlayerRoundScore, rounds []model.Round) {
+	byPlayer := map[string][]model.RoundScore{}
+	for _, s := range scores {
+		byPlayer[s.UserName] = append(byPlayer[s.UserName], s.RoundScore)
+	}
+	order := func(id int) int {
+		i := slices.IndexFunc(rounds, func(r model.Round) bool { return r.Id == id })
+		if i < 0 {
+			return len(rounds)
+		}
+		return i
+	}
+	for i := range standings {
+		subtotals := byPlayer[standings[i].UserName]
+		for j := range subtotals {
+			if k := order(subtotals[j].RoundId); k < len(rounds) {
+				subtotals[j].Title = rounds[k].Title
+			}
+		}
+		slices.SortFunc(subtotals, func(a, b model.RoundScore) int {
+			return order(a.RoundId) - order(b.RoundId)
+		})
+		standings[i].Rounds = subtotals
+	}
+}
@@ -0,0 +1,90 @@
+package game
+
+import (
+	"quizer_server/internal/model"
+	"reflect"
+	"testing"
+)
+
+var testRounds = []model.Round{
+	{Id: 11, Position: 1, Title: "Warm-up", Multiplier: 1},
+	{Id: 12, Position: 2, Title: "Double", Multiplier: 2},
+	{Id: 13, Position: 3, Title: "Half", Multiplier: 0.5},
+	{Id: 14, Position: 4, Title: "Odd", Multiplier: 1.25},
+}
+
+func TestQuestionScore(t *testing.T) {
+	round := func(id int) *int { return &id }
+	tests := []struct {
+		name string
+		q    model.Question
+		want int
+	}{
+		{name: "outside rounds", q: model.Question{Cost: 3}, want: 3},
+		{name: "unknown round", q: model.Question{Cost: 3, RoundId: round(99)}, want: 3},
+		{name: "multiplier 1", q: model.Question{Cost: 3, RoundId: round(11)}, want: 3},
+		{name: "multiplier 2", q: model.Question{Cost: 3, RoundId: round(12)}, want: 6},
+		{name: "half rounds up", q: model.Question{Cost: 3, RoundId: round(13)}, want: 2},
+		{name: "half of even cost", q: model.Question{Cost: 4, RoundId: round(13)}, want: 2},
+		{name: "quarter rounds down", q: model.Question{Cost: 1, RoundId: round(14)}, want: 1},
+		{name: "quarter rounds up", q: model.Question{Cost: 2, RoundId: round(14)}, want: 3},
+		{name: "zero cost", q: model.Question{Cost: 0, RoundId: round(12)}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := questionScore(tt.q, testRounds); got != tt.want {
+				t.Fatalf("score %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundSubtotals(t *testing.T) {
+	score := func(user string, roundId, points int) model.PlayerRoundScore {
+		return model.PlayerRoundScore{UserName: user, RoundScore: model.RoundScore{RoundId: roundId, Score: points}}
+	}
+	tests := []struct {
+		name   string
+		scores []model.PlayerRoundScore
+		rounds []model.Round
+		want   map[string][]model.RoundScore
+	}{
+		{
+			name:   "rounds in their order, outside of rounds last",
+			scores: []model.PlayerRoundScore{score("alice", 0, 1), score("alice", 13, 2), score("alice", 11, 3), score("bob", 12, 4)},
+			rounds: testRounds,
+			want: map[string][]model.RoundScore{
+				"alice": {{RoundId: 11, Title: "Warm-up", Score: 3}, {RoundId: 13, Title: "Half", Score: 2}, {RoundId: 0, Score: 1}},
+				"bob":   {{RoundId: 12, Title: "Double", Score: 4}},
+			},
+		},
+		{
+			name:   "deleted round comes last without a title",
+			scores: []model.PlayerRoundScore{score("alice", 99, 5), score("alice", 14, 1)},
+			rounds: testRounds,
+			want: map[string][]model.RoundScore{
+				"alice": {{RoundId: 14, Title: "Odd", Score: 1}, {RoundId: 99, Score: 5}},
+				"bob":   nil,
+			},
+		},
+		{
+			name:   "game without rounds",
+			scores: []model.PlayerRoundScore{score("bob", 0, 7)},
+			want: map[string][]model.RoundScore{
+				"alice": nil,
+				"bob":   {{RoundId: 0, Score: 7}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standings := []model.CalcResult{{UserName: "alice"}, {UserName: "bob"}}
+			roundSubtotals(standings, tt.scores, tt.rounds)
+			for _, s := range standings {
+				if !reflect.DeepEqual(s.Rounds, tt.want[s.UserName]) {
+					t.Fatalf("%s rounds %+v, want %+v", s.UserName, s.Rounds, tt.want[s.UserName])
+				}
+			}
+		})
+	}
+}
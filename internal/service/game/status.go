@@ -0,0 +1,57 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"quizer_server/internal/service/publish"
+)
+
+// ErrNotPublishable is returned by SetStatus together with the problems that keep the game from being published.
+var ErrNotPublishable = publish.ErrNotPublishable
+
+// SetStatus moves the game to draft, published or archived. Publishing validates the
+// questions first and returns every problem found with ErrNotPublishable.
+func (gs *gameService) SetStatus(ctx context.Context, actor model.Actor, gameId int, status string) ([]model.ImportIssue, error) {
+	switch status {
+	case model.GameStatusDraft, model.GameStatusPublished, model.GameStatusArchived:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrValidation, status)
+	}
+
+	err := access.Require(ctx, gs.storage, actor, gameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("game svc set status access err:", err)
+		return nil, err
+	}
+	before, err := gs.storage.GameLoad(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("game svc set status load err:", err)
+		return nil, err
+	}
+	if before.Status == status {
+		return nil, nil
+	}
+
+	if status == model.GameStatusPublished {
+		questions, err := gs.storage.QuestionsByGameId(ctx, actor.OrgId, gameId)
+		if err != nil {
+			log.Println("game svc set status load questions err:", err)
+			return nil, err
+		}
+		issues := publish.Validate(questions)
+		if len(issues) > 0 {
+			return issues, ErrNotPublishable
+		}
+	}
+
+	_, err = gs.storage.UpdateGameStatus(ctx, gameId, status)
+	if err != nil {
+		log.Println("game svc set status err:", err)
+		return nil, err
+	}
+	gs.audit.Record(ctx, actor, "game.set_status", model.AuditTargetGame, gameId, before.Status, status)
+	return nil, nil
+}
@@ -0,0 +1,292 @@
+package game
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"strings"
+	"time"
+)
+
+const (
+	uploadsDir          = "./uploads/"
+	maxPresentationSize = 50 << 20
+	maxImportQuestions  = 1000
+	maxImportRounds     = 100
+)
+
+// Export returns the game with its rounds, questions and presentation in the portable quiz format.
+func (gs *gameService) Export(ctx context.Context, actor model.Actor, gameId int) (model.QuizFile, error) {
+	err := access.Require(ctx, gs.storage, actor, gameId, model.PermissionView)
+	if err != nil {
+		log.Println("game svc export access err:", err)
+		return model.QuizFile{}, err
+	}
+
+	game, err := gs.storage.GameLoad(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("game svc export load game err:", err)
+		return model.QuizFile{}, err
+	}
+	rounds, err := gs.storage.RoundsByGameId(ctx, gameId)
+	if err != nil {
+		log.Println("game svc export load rounds err:", err)
+		return model.QuizFile{}, err
+	}
+	questions, err := gs.storage.QuestionsByGameId(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("game svc export load questions err:", err)
+		return model.QuizFile{}, err
+	}
+
+	res := model.QuizFile{
+		Format:  model.QuizFileFormat,
+		Version: model.QuizFileVersion,
+		Game: model.QuizFileGame{
+			Description: game.Description,
+			Category:    game.Category,
+			Tags:        game.Tags,
+		},
+		Questions: make([]model.QuizFileQuestion, 0, len(questions)),
+	}
+	positions := map[int]int{}
+	for _, r := range rounds {
+		positions[r.Id] = r.Position
+		res.Rounds = append(res.Rounds, model.QuizFileRound{
+			Position:          r.Position,
+			Title:             r.Title,
+			Multiplier:        r.Multiplier,
+			IntermissionText:  r.IntermissionText,
+			IntermissionSlide: r.IntermissionSlide,
+		})
+	}
+	for _, q := range questions {
+		round := 0
+		if q.RoundId != nil {
+			round = positions[*q.RoundId]
+		}
+		res.Questions = append(res.Questions, model.QuizFileQuestion{
+			Number:      q.Number,
+			Round:       round,
+			Description: q.Description,
+			Cost:        q.Cost,
+			Answer:      q.AnswerNum,
+			AnswerText:  q.Answer(),
+			Options:     q.Options,
+		})
+	}
+
+	if game.Link != "" {
+		data, err := os.ReadFile(game.Link)
+		if err != nil {
+			log.Println("game svc export read presentation err:", err)
+		} else {
+			res.Presentation = &model.QuizFilePresentation{
+				Filename: filepath.Base(game.Link),
+				Data:     base64.StdEncoding.EncodeToString(data),
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// Import validates the quiz file and creates a new game of the actor with all rounds and questions
+// in one transaction. The report lists every problem found, nothing is created when it has errors.
+func (gs *gameService) Import(ctx context.Context, actor model.Actor, file model.QuizFile) (model.ImportReport, error) {
+	report := model.ImportReport{
+		Questions: len(file.Questions),
+		Errors:    []model.ImportIssue{},
+		Warnings:  []model.ImportIssue{},
+	}
+	if actor.OrgId == 0 {
+		return report, fmt.Errorf("%w: user %d has no organization", model.ErrForbidden, actor.Id)
+	}
+
+	presentation, rounds := validateQuizFile(file, &report)
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	link := ""
+	if presentation != nil {
+		link = fmt.Sprintf("%simport_%d_%d.pdf", uploadsDir, actor.Id, time.Now().UnixNano())
+		err := os.WriteFile(link, presentation, 0o644)
+		if err != nil {
+			log.Println("game svc import write presentation err:", err)
+			return report, err
+		}
+	}
+
+	questions := make([]dto.CreateNewQuestionRequest, 0, len(file.Questions))
+	for _, q := range file.Questions {
+		req := dto.CreateNewQuestionRequest{
+			Number:      q.Number,
+			Cost:        q.Cost,
+			AnswerNum:   q.Answer,
+			AnswerText:  q.AnswerText,
+			Description: q.Description,
+			Options:     q.Options,
+		}
+		if q.Round != 0 {
+			// the storage resolves the position to the id of the created round
+			req.RoundId = &q.Round
+		}
+		questions = append(questions, req)
+	}
+
+	category, tags, _ := normalizeLabels(file.Game.Category, file.Game.Tags)
+	id, err := gs.storage.CreateGameWithQuestions(ctx, dto.CreateNewGame{
+		OwnerId:     actor.Id,
+		OrgId:       actor.OrgId,
+		Description: file.Game.Description,
+		Link:        link,
+		Category:    category,
+		Tags:        tags,
+	}, rounds, questions)
+	if err != nil {
+		log.Println("game svc import err:", err)
+		if link != "" {
+			os.Remove(link)
+		}
+		return report, err
+	}
+
+	report.Valid = true
+	report.GameId = id
+	gs.audit.Record(ctx, actor, "game.import", model.AuditTargetGame, id, nil,
+		map[string]any{"description": file.Game.Description, "rounds": len(rounds), "questions": len(questions), "presentation": link != ""})
+	return report, nil
+}
+
+// validateQuizFile appends every problem of the file to the report and returns
+// the decoded presentation, if the file has a valid one, and the rounds to create.
+func validateQuizFile(file model.QuizFile, report *model.ImportReport) ([]byte, []dto.RoundRequest) {
+	fail := func(path, format string, args ...any) {
+		report.Errors = append(report.Errors, model.ImportIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(path, format string, args ...any) {
+		report.Warnings = append(report.Warnings, model.ImportIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if file.Format != model.QuizFileFormat {
+		fail("format", "must be %q", model.QuizFileFormat)
+	}
+	if file.Version != model.QuizFileVersion {
+		fail("version", "unsupported version %d, supported: %d", file.Version, model.QuizFileVersion)
+	}
+	if strings.TrimSpace(file.Game.Description) == "" {
+		fail("game.description", "is required")
+	}
+	if _, _, err := normalizeLabels(file.Game.Category, file.Game.Tags); err != nil {
+		fail("game", "%v", err)
+	}
+
+	if len(file.Questions) == 0 {
+		warn("questions", "the quiz has no questions")
+	}
+	if len(file.Questions) > maxImportQuestions {
+		fail("questions", "at most %d questions are supported", maxImportQuestions)
+	}
+
+	if len(file.Rounds) > maxImportRounds {
+		fail("rounds", "at most %d rounds are supported", maxImportRounds)
+	}
+	rounds := make([]dto.RoundRequest, 0, len(file.Rounds))
+	positions := map[int]int{}
+	for i, r := range file.Rounds {
+		path := fmt.Sprintf("rounds[%d]", i)
+		if r.Position <= 0 {
+			fail(path+".position", "must be positive")
+		} else if prev, ok := positions[r.Position]; ok {
+			fail(path+".position", "duplicates the position of rounds[%d]", prev)
+		} else {
+			positions[r.Position] = i
+		}
+		if strings.TrimSpace(r.Title) == "" {
+			fail(path+".title", "is required")
+		}
+		if r.Multiplier < 0 || math.IsNaN(r.Multiplier) || math.IsInf(r.Multiplier, 0) {
+			fail(path+".multiplier", "must be positive or 0 to count the questions at their cost")
+		}
+		if r.IntermissionSlide < 0 {
+			fail(path+".intermission_slide", "must not be negative")
+		}
+		// validateRound only trims and fills the defaults after the checks above
+		req, _ := validateRound(dto.RoundRequest{
+			Position:          r.Position,
+			Title:             r.Title,
+			Multiplier:        r.Multiplier,
+			IntermissionText:  r.IntermissionText,
+			IntermissionSlide: r.IntermissionSlide,
+		})
+		rounds = append(rounds, req)
+	}
+
+	numbers := map[int]int{}
+	for i, q := range file.Questions {
+		path := fmt.Sprintf("questions[%d]", i)
+		if q.Number <= 0 {
+			fail(path+".number", "must be positive")
+		} else if prev, ok := numbers[q.Number]; ok {
+			fail(path+".number", "duplicates the number of questions[%d]", prev)
+		} else {
+			numbers[q.Number] = i
+		}
+		if strings.TrimSpace(q.Description) == "" {
+			fail(path+".description", "is required")
+		}
+		if _, ok := positions[q.Round]; q.Round != 0 && !ok {
+			fail(path+".round", "there is no round at position %d", q.Round)
+		}
+		if q.Cost < 0 {
+			fail(path+".cost", "must not be negative")
+		}
+		if q.Answer < 0 {
+			fail(path+".answer", "must not be negative")
+		}
+		if len(q.Options) > 0 && q.Answer > len(q.Options) {
+			fail(path+".answer", "points past the %d options", len(q.Options))
+		}
+		if q.Answer == 0 && q.AnswerText == "" {
+			warn(path, "has neither an answer number nor an answer text")
+		}
+		if q.Cost == 0 {
+			warn(path+".cost", "the question gives no points")
+		}
+	}
+	if len(numbers) == len(file.Questions) {
+		for n := 1; n <= len(numbers); n++ {
+			if _, ok := numbers[n]; !ok {
+				warn("questions", "numbers are not consecutive, %d is missing", n)
+				break
+			}
+		}
+	}
+
+	if file.Presentation == nil {
+		return nil, rounds
+	}
+	data, err := base64.StdEncoding.DecodeString(file.Presentation.Data)
+	if err != nil {
+		fail("presentation.data", "is not valid base64")
+		return nil, rounds
+	}
+	if len(data) > maxPresentationSize {
+		fail("presentation.data", "the file is larger than %d MB", maxPresentationSize>>20)
+		return nil, rounds
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		fail("presentation.data", "only PDF presentations are supported")
+		return nil, rounds
+	}
+	return data, rounds
+}
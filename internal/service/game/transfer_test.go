@@ -0,0 +1,198 @@
+package game
+
+import (
+	"encoding/base64"
+	"math"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// quizFile returns a valid file with two rounds and three questions for the cases to break.
+func quizFile() model.QuizFile {
+	return model.QuizFile{
+		Format:  model.QuizFileFormat,
+		Version: model.QuizFileVersion,
+		Game:    model.QuizFileGame{Description: "Pub quiz", Category: "General", Tags: []string{"music"}},
+		Rounds: []model.QuizFileRound{
+			{Position: 1, Title: " Warm-up "},
+			{Position: 2, Title: "Finale", Multiplier: 1.5, IntermissionText: " Break ", IntermissionSlide: 4},
+		},
+		Questions: []model.QuizFileQuestion{
+			{Number: 1, Round: 1, Description: "Capital of France?", Cost: 1, AnswerText: "Paris"},
+			{Number: 2, Round: 2, Description: "Pick one", Cost: 2, Answer: 2, Options: []string{"a", "b"}},
+			{Number: 3, Description: "Slide question", Cost: 1, Answer: 3},
+		},
+	}
+}
+
+func TestValidateQuizFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		edit     func(f *model.QuizFile)
+		errors   []string
+		warnings []string
+	}{
+		{name: "valid", edit: func(f *model.QuizFile) {}},
+		{
+			name: "format and version",
+			edit: func(f *model.QuizFile) {
+				f.Format = "other"
+				f.Version = model.QuizFileVersion + 1
+			},
+			errors: []string{"format", "version"},
+		},
+		{
+			name:   "game",
+			edit:   func(f *model.QuizFile) { f.Game = model.QuizFileGame{Description: " ", Tags: []string{strings.Repeat("t", 100)}} },
+			errors: []string{"game.description", "game"},
+		},
+		{
+			name:     "no questions",
+			edit:     func(f *model.QuizFile) { f.Questions = nil },
+			warnings: []string{"questions"},
+		},
+		{
+			name: "too many questions and rounds",
+			edit: func(f *model.QuizFile) {
+				f.Questions = make([]model.QuizFileQuestion, maxImportQuestions+1)
+				for i := range f.Questions {
+					f.Questions[i] = model.QuizFileQuestion{Number: i + 1, Description: "Q", Cost: 1, Answer: 1}
+				}
+				f.Rounds = make([]model.QuizFileRound, maxImportRounds+1)
+				for i := range f.Rounds {
+					f.Rounds[i] = model.QuizFileRound{Position: i + 1, Title: "R"}
+				}
+			},
+			errors: []string{"questions", "rounds"},
+		},
+		{
+			name: "round positions",
+			edit: func(f *model.QuizFile) {
+				f.Rounds[0].Position = 0
+				f.Rounds = append(f.Rounds, model.QuizFileRound{Position: 2, Title: "Again"})
+				f.Questions[0].Round = 0
+			},
+			errors: []string{"rounds[0].position", "rounds[2].position"},
+		},
+		{
+			name: "round fields",
+			edit: func(f *model.QuizFile) {
+				f.Rounds[0].Title = " "
+				f.Rounds[0].IntermissionSlide = -1
+			},
+			errors: []string{"rounds[0].title", "rounds[0].intermission_slide"},
+		},
+		{
+			name:   "negative multiplier",
+			edit:   func(f *model.QuizFile) { f.Rounds[1].Multiplier = -1 },
+			errors: []string{"rounds[1].multiplier"},
+		},
+		{
+			name:   "not a number multiplier",
+			edit:   func(f *model.QuizFile) { f.Rounds[1].Multiplier = math.NaN() },
+			errors: []string{"rounds[1].multiplier"},
+		},
+		{
+			name:   "infinite multiplier",
+			edit:   func(f *model.QuizFile) { f.Rounds[1].Multiplier = math.Inf(1) },
+			errors: []string{"rounds[1].multiplier"},
+		},
+		{
+			name: "question numbers",
+			edit: func(f *model.QuizFile) {
+				f.Questions[0].Number = 0
+				f.Questions[2].Number = 2
+			},
+			errors: []string{"questions[0].number", "questions[2].number"},
+		},
+		{
+			name:     "numbers with a gap",
+			edit:     func(f *model.QuizFile) { f.Questions[2].Number = 5 },
+			warnings: []string{"questions"},
+		},
+		{
+			name: "question fields",
+			edit: func(f *model.QuizFile) {
+				f.Questions[0] = model.QuizFileQuestion{Number: 1, Round: 3, Description: " ", Cost: -1, Answer: -1}
+				f.Questions[1].Answer = 3
+			},
+			errors: []string{"questions[0].description", "questions[0].round", "questions[0].cost", "questions[0].answer", "questions[1].answer"},
+		},
+		{
+			name: "question without answer or points",
+			edit: func(f *model.QuizFile) {
+				f.Questions[0].AnswerText = ""
+				f.Questions[0].Cost = 0
+			},
+			warnings: []string{"questions[0]", "questions[0].cost"},
+		},
+		{
+			name:   "presentation not base64",
+			edit:   func(f *model.QuizFile) { f.Presentation = &model.QuizFilePresentation{Filename: "a.pdf", Data: "%%%"} },
+			errors: []string{"presentation.data"},
+		},
+		{
+			name: "presentation not a PDF",
+			edit: func(f *model.QuizFile) {
+				f.Presentation = &model.QuizFilePresentation{Filename: "a.png", Data: base64.StdEncoding.EncodeToString([]byte("\x89PNG"))}
+			},
+			errors: []string{"presentation.data"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := quizFile()
+			tt.edit(&file)
+			report := &model.ImportReport{Errors: []model.ImportIssue{}, Warnings: []model.ImportIssue{}}
+			validateQuizFile(file, report)
+
+			if got := issuePaths(report.Errors); !reflect.DeepEqual(got, orEmpty(tt.errors)) {
+				t.Fatalf("errors at %v, want %v: %+v", got, tt.errors, report.Errors)
+			}
+			if got := issuePaths(report.Warnings); !reflect.DeepEqual(got, orEmpty(tt.warnings)) {
+				t.Fatalf("warnings at %v, want %v: %+v", got, tt.warnings, report.Warnings)
+			}
+		})
+	}
+}
+
+func TestValidateQuizFileResult(t *testing.T) {
+	file := quizFile()
+	pdf := []byte("%PDF-1.7\n")
+	file.Presentation = &model.QuizFilePresentation{Filename: "quiz.pdf", Data: base64.StdEncoding.EncodeToString(pdf)}
+	report := &model.ImportReport{Errors: []model.ImportIssue{}, Warnings: []model.ImportIssue{}}
+	data, rounds := validateQuizFile(file, report)
+
+	if len(report.Errors) > 0 || len(report.Warnings) > 0 {
+		t.Fatalf("valid file reported %+v %+v", report.Errors, report.Warnings)
+	}
+	if string(data) != string(pdf) {
+		t.Fatalf("presentation %q, want %q", data, pdf)
+	}
+	// a missing multiplier counts the questions at their cost
+	want := []dto.RoundRequest{
+		{Position: 1, Title: "Warm-up", Multiplier: 1},
+		{Position: 2, Title: "Finale", Multiplier: 1.5, IntermissionText: "Break", IntermissionSlide: 4},
+	}
+	if !reflect.DeepEqual(rounds, want) {
+		t.Fatalf("rounds %+v, want %+v", rounds, want)
+	}
+}
+
+func issuePaths(issues []model.ImportIssue) []string {
+	res := []string{}
+	for _, i := range issues {
+		res = append(res, i.Path)
+	}
+	return res
+}
+
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
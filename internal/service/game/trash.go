@@ -0,0 +1,75 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"time"
+)
+
+// Trash lists the deleted games of the actor, admins see the trash of the whole organization.
+func (gs *gameService) Trash(ctx context.Context, actor model.Actor) ([]model.Game, error) {
+	filter := dto.GameListFilter{
+		OrgId:   actor.OrgId,
+		Deleted: true,
+	}
+	if !actor.IsAdmin() {
+		filter.OwnerId = actor.Id
+	}
+	res, err := gs.storage.GameList(ctx, filter)
+	if err != nil {
+		log.Println("game svc trash err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// RestoreGame takes the game out of the trash. Like deleting, it is allowed to the owner and admins.
+func (gs *gameService) RestoreGame(ctx context.Context, actor model.Actor, id int) (int, error) {
+	game, err := gs.storage.TrashedGameLoad(ctx, actor.OrgId, id)
+	if err != nil {
+		log.Println("game svc restore load err:", err)
+		return 0, err
+	}
+	if !actor.IsAdmin() && game.OwnerId != actor.Id {
+		return 0, fmt.Errorf("%w: user %d does not own game %d", model.ErrForbidden, actor.Id, id)
+	}
+	res, err := gs.storage.RestoreGame(ctx, id)
+	if err != nil {
+		log.Println("game svc restore err:", err)
+		return res, err
+	}
+	gs.audit.Record(ctx, actor, "game.restore", model.AuditTargetGame, id, nil, game)
+	return res, nil
+}
+
+// PurgeTrash removes the games and questions deleted before the time for good,
+// together with the rows that depend on them and the presentation files.
+func (gs *gameService) PurgeTrash(ctx context.Context, before time.Time) error {
+	ids, links, err := gs.storage.PurgeDeletedGames(ctx, before)
+	if err != nil {
+		log.Println("game svc purge games err:", err)
+		return err
+	}
+	for _, link := range links {
+		err := os.Remove(link)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Println("game svc purge presentation err:", err)
+		}
+	}
+
+	questions, err := gs.storage.PurgeDeletedQuestions(ctx, before)
+	if err != nil {
+		log.Println("game svc purge questions err:", err)
+		return err
+	}
+	if len(ids) > 0 || questions > 0 {
+		log.Printf("game svc purged %d games %v and %d questions from the trash", len(ids), ids, questions)
+	}
+	return nil
+}
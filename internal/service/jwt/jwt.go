@@ -2,30 +2,63 @@ package jwt
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"log"
 	"quizer_server/internal/config"
+	"quizer_server/internal/db"
 	"quizer_server/internal/model"
 	"quizer_server/internal/service/user"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrInvalidLobbyToken   = errors.New("invalid lobby token")
 )
 
 type Service interface {
-	CreateToken(ctx context.Context, req model.JwtRequest) model.JwtResponce
-	ParseToken(token string, key string) (*jwt.Token, error)
+	CreateToken(ctx context.Context, req model.JwtRequest) (model.JwtResponce, error)
+	Refresh(ctx context.Context, refreshToken string) (model.JwtResponce, error)
+	Logout(ctx context.Context, refreshToken string) error
+	ParseToken(token string) (*jwt.Token, error)
+	JWKS() model.JWKS
 	IDFromToken(tokenStr string) int
+	ActorFromToken(tokenStr string) (model.Actor, error)
+	CreateLobbyToken(kind string, lobbyUUID uuid.UUID, playerUUID uuid.UUID) (string, error)
+	ParseLobbyToken(tokenStr string, kind string) (model.LobbyClaims, error)
 }
 
 type jwtService struct {
 	service user.Service
+	storage db.Storage
 	cfg     *config.Config
+	keys    *keySet
 }
 
-// New creates a new instance of JwtService with the provided user repository.
-func New(s user.Service) Service {
+// New creates a new instance of JwtService with the provided user service and storage.
+// It loads the signing keys from the configuration and stops the application if they are invalid.
+func New(s user.Service, st db.Storage) Service {
+	cfg := config.GetConfig()
+	keys, err := loadKeys(cfg.Jwt.Keys, cfg.Jwt.ActiveKid, cfg.Jwt.SecretKey)
+	if err != nil {
+		log.Fatalln("jwt_service: load keys err:", err)
+	}
+	log.Println("jwt_service: active key:", keys.active.kid, "accepted methods:", keys.methods)
+
 	return &jwtService{
 		service: s,
-		cfg:     config.GetConfig(),
+		storage: st,
+		cfg:     cfg,
+		keys:    keys,
 	}
 }
 
@@ -33,7 +66,7 @@ func New(s user.Service) Service {
 // It parses the token, retrieves the ID claim, and returns the parsed UUID.
 func (js *jwtService) IDFromToken(tokenStr string) int {
 	resp := 0
-	token, err := js.ParseToken(tokenStr, js.cfg.Jwt.SecretKey)
+	token, err := js.ParseToken(tokenStr)
 
 	if err != nil {
 		log.Println("jwt_service: parse token err: ", err)
@@ -56,40 +89,250 @@ func (js *jwtService) IDFromToken(tokenStr string) int {
 	return int(user_id)
 }
 
-// CreateTokenPair generates a pair of access and refresh tokens for a user.
-// It retrieves the user record by login, creates the access token with limited duration and refresh token with extended validity period,
-// and returns them in a structured response.
-func (js *jwtService) CreateToken(ctx context.Context, req model.JwtRequest) model.JwtResponce {
+// ActorFromToken verifies the token and builds the request actor from its claims.
+func (js *jwtService) ActorFromToken(tokenStr string) (model.Actor, error) {
+	token, err := js.ParseToken(tokenStr)
+	if err != nil {
+		return model.Actor{}, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return model.Actor{}, errors.New("invalid token claims")
+	}
+
+	if _, ok := claims["typ"]; ok {
+		return model.Actor{}, errors.New("not an access token")
+	}
+
+	userId, ok := claims["user_id"].(float64)
+	if !ok {
+		return model.Actor{}, errors.New("token has no user_id claim")
+	}
+
+	login, _ := claims["login"].(string)
+	actor := model.Actor{
+		Id:    int(userId),
+		Login: login,
+	}
+
+	roles, _ := claims["roles"].([]any)
+	for _, r := range roles {
+		role, ok := r.(string)
+		if ok {
+			actor.Roles = append(actor.Roles, role)
+		}
+	}
+
+	return actor, nil
+}
+
+// CreateLobbyToken signs a token that proves the holder is the host of the lobby
+// or the player with the given UUID in it.
+func (js *jwtService) CreateLobbyToken(kind string, lobbyUUID uuid.UUID, playerUUID uuid.UUID) (string, error) {
+	now := time.Now()
+
+	payload := jwt.MapClaims{
+		"typ":         kind,
+		"lobby_uuid":  lobbyUUID.String(),
+		"player_uuid": playerUUID.String(),
+		"iat":         now.Unix(),
+		"exp":         now.Add(js.cfg.Jwt.LobbyTTL).Unix(),
+	}
+
+	return js.keys.sign(payload)
+}
+
+// ParseLobbyToken verifies a lobby token and checks that it is of the expected kind.
+func (js *jwtService) ParseLobbyToken(tokenStr string, kind string) (model.LobbyClaims, error) {
+	token, err := js.ParseToken(tokenStr)
+	if err != nil {
+		return model.LobbyClaims{}, ErrInvalidLobbyToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return model.LobbyClaims{}, ErrInvalidLobbyToken
+	}
+
+	typ, _ := claims["typ"].(string)
+	if typ != kind {
+		return model.LobbyClaims{}, ErrInvalidLobbyToken
+	}
+
+	lobbyStr, _ := claims["lobby_uuid"].(string)
+	lobbyUUID, err := uuid.Parse(lobbyStr)
+	if err != nil {
+		return model.LobbyClaims{}, ErrInvalidLobbyToken
+	}
+
+	playerStr, _ := claims["player_uuid"].(string)
+	playerUUID, err := uuid.Parse(playerStr)
+	if err != nil {
+		return model.LobbyClaims{}, ErrInvalidLobbyToken
+	}
+
+	return model.LobbyClaims{
+		Kind:       typ,
+		LobbyUUID:  lobbyUUID,
+		PlayerUUID: playerUUID,
+	}, nil
+}
+
+// CreateToken generates a pair of access and refresh tokens for a user.
+// It retrieves the user record by login, creates a short-lived access token and
+// starts a new refresh token family, and returns them in a structured response.
+func (js *jwtService) CreateToken(ctx context.Context, req model.JwtRequest) (model.JwtResponce, error) {
 	user, err := js.service.UserByLogin(ctx, req.Login)
 
 	if err != nil {
 		log.Println("jwt_service get user err: ", err)
-		return model.JwtResponce{}
+		return model.JwtResponce{}, err
 	}
 
-	aToken := js.createAccessToken(user.Id, user.Login)
+	return js.issueTokens(ctx, user, uuid.New())
+}
 
-	return model.JwtResponce{
-		AccessToken: aToken,
-		UserID:      user.Id,
+// Refresh exchanges a valid refresh token for a new token pair and revokes the old one.
+// Presenting a refresh token that was already rotated or revoked is treated as theft:
+// the whole token family is revoked and the caller has to log in again.
+func (js *jwtService) Refresh(ctx context.Context, refreshToken string) (model.JwtResponce, error) {
+	stored, err := js.storage.RefreshTokenByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			log.Println("jwt_service load refresh token err: ", err)
+		}
+		return model.JwtResponce{}, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		js.revokeFamily(ctx, stored.FamilyId)
+		return model.JwtResponce{}, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return model.JwtResponce{}, ErrInvalidRefreshToken
+	}
+
+	revoked, err := js.storage.RevokeRefreshToken(ctx, stored.Id)
+	if err != nil {
+		log.Println("jwt_service revoke refresh token err: ", err)
+		return model.JwtResponce{}, err
+	}
+	if !revoked {
+		js.revokeFamily(ctx, stored.FamilyId)
+		return model.JwtResponce{}, ErrRefreshTokenReused
+	}
+
+	user, err := js.service.UserById(ctx, stored.UserId)
+	if err != nil {
+		log.Println("jwt_service get user err: ", err)
+		return model.JwtResponce{}, ErrInvalidRefreshToken
 	}
+
+	return js.issueTokens(ctx, user, stored.FamilyId)
 }
 
-// ParseToken verifies and parses a JWT token using the provided secret key.
-func (js *jwtService) ParseToken(tokenString string, key string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return []byte(key), nil
-	})
+// Logout revokes the refresh token family the given token belongs to.
+func (js *jwtService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := js.storage.RefreshTokenByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			log.Println("jwt_service load refresh token err: ", err)
+		}
+		return ErrInvalidRefreshToken
+	}
+
+	err = js.storage.RevokeRefreshTokenFamily(ctx, stored.FamilyId)
+	if err != nil {
+		log.Println("jwt_service logout err: ", err)
+		return err
+	}
+	return nil
+}
+
+// ParseToken verifies and parses a JWT token with the key selected by its kid header.
+// Only the configured signing methods are accepted and tokens without an expiration time are rejected.
+func (js *jwtService) ParseToken(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, js.keys.keyFunc,
+		jwt.WithValidMethods(js.keys.methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 }
 
-func (js *jwtService) createAccessToken(userId int, login string) string {
+// JWKS returns the public keys other services can use to verify our tokens.
+func (js *jwtService) JWKS() model.JWKS {
+	return js.keys.jwks()
+}
+
+func (js *jwtService) issueTokens(ctx context.Context, user model.User, familyId uuid.UUID) (model.JwtResponce, error) {
+	aToken, err := js.createAccessToken(user)
+	if err != nil {
+		log.Println("jwt_service create access token err: ", err)
+		return model.JwtResponce{}, err
+	}
+
+	rToken, err := js.createRefreshToken(ctx, user.Id, familyId)
+	if err != nil {
+		log.Println("jwt_service create refresh token err: ", err)
+		return model.JwtResponce{}, err
+	}
+
+	return model.JwtResponce{
+		AccessToken:  aToken,
+		RefreshToken: rToken,
+		ExpiresIn:    int(js.cfg.Jwt.AccessTTL.Seconds()),
+		UserID:       user.Id,
+	}, nil
+}
+
+func (js *jwtService) createAccessToken(user model.User) (string, error) {
+	now := time.Now()
 
 	payload := jwt.MapClaims{
-		"user_id": userId,
-		"login":   login,
+		"user_id": user.Id,
+		"login":   user.Login,
+		"roles":   user.Roles,
+		"iat":     now.Unix(),
+		"exp":     now.Add(js.cfg.Jwt.AccessTTL).Unix(),
+		"jti":     uuid.NewString(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	t, _ := token.SignedString([]byte(js.cfg.Jwt.SecretKey))
-	return t
+	return js.keys.sign(payload)
+}
+
+// createRefreshToken generates an opaque refresh token and stores its hash.
+func (js *jwtService) createRefreshToken(ctx context.Context, userId int, familyId uuid.UUID) (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	_, err = js.storage.CreateRefreshToken(ctx, model.RefreshToken{
+		TokenHash: hashRefreshToken(token),
+		UserId:    userId,
+		FamilyId:  familyId,
+		ExpiresAt: time.Now().Add(js.cfg.Jwt.RefreshTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (js *jwtService) revokeFamily(ctx context.Context, familyId uuid.UUID) {
+	log.Println("jwt_service: refresh token reuse detected, revoking family:", familyId)
+	err := js.storage.RevokeRefreshTokenFamily(ctx, familyId)
+	if err != nil {
+		log.Println("jwt_service revoke refresh token family err: ", err)
+	}
+}
+
+// hashRefreshToken returns the SHA-256 hex digest under which refresh tokens are stored.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,204 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"quizer_server/internal/model"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// legacyKid is the key id of the single JWT_SECRET_KEY used before key rotation
+// was introduced. Tokens without a kid header are verified with this key.
+const legacyKid = "default"
+
+const minSecretLen = 32
+
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+type keySet struct {
+	active  *signingKey
+	keys    map[string]*signingKey
+	methods []string
+}
+
+// loadKeys builds the key set from the configuration.
+// Every entry of keys has the form "kid:alg:value", where value is the secret
+// for HS256 and the path to a PEM encoded private key for RS256 and EdDSA.
+// When no keys are configured the legacy secret is used as an HS256 key.
+func loadKeys(keys []string, activeKid string, legacySecret string) (*keySet, error) {
+	ks := &keySet{
+		keys: make(map[string]*signingKey),
+	}
+
+	if len(keys) == 0 && legacySecret != "" {
+		keys = []string{legacyKid + ":HS256:" + legacySecret}
+	}
+
+	for _, entry := range keys {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid jwt key entry, expected kid:alg:value")
+		}
+		key, err := parseKey(parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %v", parts[0], err)
+		}
+		if _, ok := ks.keys[key.kid]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.kid)
+		}
+		ks.keys[key.kid] = key
+		if !contains(ks.methods, key.method.Alg()) {
+			ks.methods = append(ks.methods, key.method.Alg())
+		}
+		if ks.active == nil {
+			ks.active = key
+		}
+	}
+
+	if ks.active == nil {
+		return nil, fmt.Errorf("no jwt signing keys configured")
+	}
+
+	if activeKid != "" {
+		key, ok := ks.keys[activeKid]
+		if !ok {
+			return nil, fmt.Errorf("active jwt key %q is not configured", activeKid)
+		}
+		ks.active = key
+	}
+
+	return ks, nil
+}
+
+func parseKey(kid, alg, value string) (*signingKey, error) {
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		if len(value) < minSecretLen {
+			log.Printf("jwt key %q: secret is shorter than %d bytes\n", kid, minSecretLen)
+		}
+		return &signingKey{
+			kid:     kid,
+			method:  jwt.SigningMethodHS256,
+			private: []byte(value),
+			public:  []byte(value),
+		}, nil
+	case jwt.SigningMethodRS256.Alg():
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		return &signingKey{
+			kid:     kid,
+			method:  jwt.SigningMethodRS256,
+			private: private,
+			public:  &private.PublicKey,
+		}, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		edPrivate, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("not an ed25519 private key")
+		}
+		return &signingKey{
+			kid:     kid,
+			method:  jwt.SigningMethodEdDSA,
+			private: edPrivate,
+			public:  edPrivate.Public(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+// keyFunc selects the verification key by the kid header and pins the
+// algorithm to the one configured for that key.
+func (ks *keySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = legacyKid
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+	}
+
+	return key.public, nil
+}
+
+// sign signs the claims with the active key and sets the kid header.
+func (ks *keySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	token.Header["kid"] = ks.active.kid
+	return token.SignedString(ks.active.private)
+}
+
+// jwks returns the public keys in JWK format. Symmetric keys are never published.
+func (ks *keySet) jwks() model.JWKS {
+	res := model.JWKS{
+		Keys: []model.JWK{},
+	}
+	for _, key := range ks.keys {
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			res.Keys = append(res.Keys, model.JWK{
+				Kty: "RSA",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			res.Keys = append(res.Keys, model.JWK{
+				Kty: "OKP",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	sort.Slice(res.Keys, func(i, j int) bool {
+		return res.Keys[i].Kid < res.Keys[j].Kid
+	})
+	return res
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,366 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"quizer_server/internal/db"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"quizer_server/internal/service/audit"
+	"quizer_server/internal/service/publish"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrValidation = errors.New("validation failed")
+
+const (
+	defaultListSize = 50
+	maxListSize     = 200
+)
+
+type Service interface {
+	List(ctx context.Context, actor model.Actor, filter dto.LibraryFilter) ([]model.LibraryQuestion, error)
+	Load(ctx context.Context, actor model.Actor, id int) (model.LibraryQuestion, error)
+	Create(ctx context.Context, actor model.Actor, req dto.LibraryQuestionRequest) (int, error)
+	Update(ctx context.Context, actor model.Actor, id int, req dto.LibraryQuestionRequest) (int, error)
+	Delete(ctx context.Context, actor model.Actor, id int) (int, error)
+	Stats(ctx context.Context, actor model.Actor, id int) (model.LibraryQuestionStats, error)
+	AddToGame(ctx context.Context, actor model.Actor, gameId int, req dto.AddLibraryQuestionRequest) (int, error)
+	SaveQuestion(ctx context.Context, actor model.Actor, questionId int, visibility string) (int, error)
+	Fork(ctx context.Context, actor model.Actor, questionId int) (int, error)
+}
+
+type libraryService struct {
+	storage db.Storage
+	audit   audit.Service
+}
+
+func New(s db.Storage, a audit.Service) Service {
+	return &libraryService{
+		storage: s,
+		audit:   a,
+	}
+}
+
+func validateVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return model.LibraryVisibilityPrivate, nil
+	case model.LibraryVisibilityPrivate, model.LibraryVisibilityOrganization:
+		return visibility, nil
+	}
+	return "", fmt.Errorf("%w: unknown visibility %q", ErrValidation, visibility)
+}
+
+func validateQuestion(req dto.LibraryQuestionRequest) (dto.LibraryQuestionRequest, error) {
+	var err error
+	req.Visibility, err = validateVisibility(req.Visibility)
+	if err != nil {
+		return req, err
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	req.AnswerText = strings.TrimSpace(req.AnswerText)
+	switch {
+	case req.Description == "":
+		return req, fmt.Errorf("%w: description is required", ErrValidation)
+	case req.Cost < 0:
+		return req, fmt.Errorf("%w: cost must not be negative", ErrValidation)
+	case req.AnswerNum < 0:
+		return req, fmt.Errorf("%w: answer must not be negative", ErrValidation)
+	case req.AnswerNum == 0 && req.AnswerText == "":
+		return req, fmt.Errorf("%w: answer or answer_text is required", ErrValidation)
+	case len(req.Options) > 0 && req.AnswerNum > len(req.Options):
+		return req, fmt.Errorf("%w: answer must point to one of the %d options", ErrValidation, len(req.Options))
+	}
+	return req, nil
+}
+
+func requireOrganization(actor model.Actor) error {
+	if actor.OrgId == 0 {
+		return fmt.Errorf("%w: user %d has no organization", model.ErrForbidden, actor.Id)
+	}
+	return nil
+}
+
+// load loads the library question the actor can see: their own ones and the ones shared with the organization.
+func (s *libraryService) load(ctx context.Context, actor model.Actor, id int) (model.LibraryQuestion, error) {
+	err := requireOrganization(actor)
+	if err != nil {
+		return model.LibraryQuestion{}, err
+	}
+	res, err := s.storage.LibraryQuestionLoad(ctx, actor.OrgId, id)
+	if err != nil {
+		return res, err
+	}
+	if actor.IsAdmin() || res.OwnerId == actor.Id || res.Visibility == model.LibraryVisibilityOrganization {
+		return res, nil
+	}
+	return model.LibraryQuestion{}, fmt.Errorf("%w: user %d can not see library question %d", model.ErrForbidden, actor.Id, id)
+}
+
+// loadOwned loads the library question the actor may change: their own ones, or any one for admins.
+func (s *libraryService) loadOwned(ctx context.Context, actor model.Actor, id int) (model.LibraryQuestion, error) {
+	res, err := s.load(ctx, actor, id)
+	if err != nil {
+		return res, err
+	}
+	if actor.IsAdmin() || res.OwnerId == actor.Id {
+		return res, nil
+	}
+	return model.LibraryQuestion{}, fmt.Errorf("%w: user %d does not own library question %d", model.ErrForbidden, actor.Id, id)
+}
+
+// List lists the library questions the actor can see. Admins see the private questions of other users too.
+func (s *libraryService) List(ctx context.Context, actor model.Actor, filter dto.LibraryFilter) ([]model.LibraryQuestion, error) {
+	err := requireOrganization(actor)
+	if err != nil {
+		log.Println("library svc list access err:", err)
+		return nil, err
+	}
+	filter.OrgId = actor.OrgId
+	filter.UserId = actor.Id
+	if actor.IsAdmin() && !filter.Mine {
+		filter.UserId = 0
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListSize
+	}
+	if filter.Limit > maxListSize {
+		filter.Limit = maxListSize
+	}
+	res, err := s.storage.LibraryQuestions(ctx, filter)
+	if err != nil {
+		log.Println("library svc list err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *libraryService) Load(ctx context.Context, actor model.Actor, id int) (model.LibraryQuestion, error) {
+	res, err := s.load(ctx, actor, id)
+	if err != nil {
+		log.Println("library svc load err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *libraryService) Create(ctx context.Context, actor model.Actor, req dto.LibraryQuestionRequest) (int, error) {
+	req, err := validateQuestion(req)
+	if err != nil {
+		return 0, err
+	}
+	err = requireOrganization(actor)
+	if err != nil {
+		log.Println("library svc create access err:", err)
+		return 0, err
+	}
+	id, err := s.storage.CreateLibraryQuestion(ctx, actor.OrgId, actor.Id, req)
+	if err != nil {
+		log.Println("library svc create err:", err)
+		return id, err
+	}
+	s.audit.Record(ctx, actor, "library.create", model.AuditTargetLibraryQuestion, id, nil, req)
+	return id, nil
+}
+
+// Update replaces the library question, the game questions following it get the new contents.
+func (s *libraryService) Update(ctx context.Context, actor model.Actor, id int, req dto.LibraryQuestionRequest) (int, error) {
+	req, err := validateQuestion(req)
+	if err != nil {
+		return 0, err
+	}
+	before, err := s.loadOwned(ctx, actor, id)
+	if err != nil {
+		log.Println("library svc update access err:", err)
+		return 0, err
+	}
+	err = s.checkPublished(ctx, actor, id, req)
+	if err != nil {
+		log.Println("library svc update publish check err:", err)
+		return 0, err
+	}
+	propagated, err := s.storage.UpdateLibraryQuestion(ctx, id, req)
+	if err != nil {
+		log.Println("library svc update err:", err)
+		return 0, err
+	}
+	s.audit.Record(ctx, actor, "library.update", model.AuditTargetLibraryQuestion, id, before,
+		map[string]any{"question": req, "propagated": propagated})
+	return id, nil
+}
+
+// checkPublished returns *publish.Error when copying the library question to the game questions
+// that follow it would keep one of the published games from being published.
+func (s *libraryService) checkPublished(ctx context.Context, actor model.Actor, id int, req dto.LibraryQuestionRequest) error {
+	games, err := s.storage.LibraryQuestionPublishedGames(ctx, actor.OrgId, id)
+	if err != nil {
+		return err
+	}
+	follow := func(questions []model.Question) []model.Question {
+		for i, q := range questions {
+			if q.LibraryQuestionId != nil && *q.LibraryQuestionId == id && !q.Forked {
+				questions[i].Description = req.Description
+				questions[i].AnswerNum = req.AnswerNum
+				questions[i].AnswerText = req.AnswerText
+				questions[i].Cost = req.Cost
+				questions[i].Options = req.Options
+			}
+		}
+		return questions
+	}
+	for _, gameId := range games {
+		err = publish.Check(ctx, s.storage, actor.OrgId, gameId, follow)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the library question, the game questions that followed it keep their contents.
+func (s *libraryService) Delete(ctx context.Context, actor model.Actor, id int) (int, error) {
+	before, err := s.loadOwned(ctx, actor, id)
+	if err != nil {
+		log.Println("library svc delete access err:", err)
+		return 0, err
+	}
+	res, err := s.storage.DeleteLibraryQuestion(ctx, id)
+	if err != nil {
+		log.Println("library svc delete err:", err)
+		return res, err
+	}
+	s.audit.Record(ctx, actor, "library.delete", model.AuditTargetLibraryQuestion, id, before, nil)
+	return res, nil
+}
+
+// Stats sums up the results of the library question over every game and lobby that used it.
+func (s *libraryService) Stats(ctx context.Context, actor model.Actor, id int) (model.LibraryQuestionStats, error) {
+	_, err := s.load(ctx, actor, id)
+	if err != nil {
+		log.Println("library svc stats access err:", err)
+		return model.LibraryQuestionStats{}, err
+	}
+	res, err := s.storage.LibraryQuestionStats(ctx, id)
+	if err != nil {
+		log.Println("library svc stats err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// AddToGame puts a copy of the library question into the game, the copy follows the library
+// question until it is forked.
+func (s *libraryService) AddToGame(ctx context.Context, actor model.Actor, gameId int, req dto.AddLibraryQuestionRequest) (int, error) {
+	if req.Number < 0 {
+		return 0, fmt.Errorf("%w: number must not be negative", ErrValidation)
+	}
+	err := access.Require(ctx, s.storage, actor, gameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("library svc add to game access err:", err)
+		return 0, err
+	}
+	libraryQuestion, err := s.load(ctx, actor, req.LibraryQuestionId)
+	if err != nil {
+		log.Println("library svc add to game load err:", err)
+		return 0, err
+	}
+	if req.RoundId != nil {
+		round, err := s.storage.RoundLoad(ctx, actor.OrgId, *req.RoundId)
+		if errors.Is(err, pgx.ErrNoRows) || err == nil && round.GameId != gameId {
+			return 0, fmt.Errorf("%w: round %d is not a round of game %d", ErrValidation, *req.RoundId, gameId)
+		}
+		if err != nil {
+			log.Println("library svc add to game round err:", err)
+			return 0, err
+		}
+	}
+	err = publish.Check(ctx, s.storage, actor.OrgId, gameId, func(questions []model.Question) []model.Question {
+		number := req.Number
+		if number == 0 {
+			for _, q := range questions {
+				number = max(number, q.Number)
+			}
+			number++
+		}
+		return append(questions, model.Question{
+			Number:      number,
+			Description: libraryQuestion.Description,
+			GameId:      gameId,
+			AnswerNum:   libraryQuestion.AnswerNum,
+			AnswerText:  libraryQuestion.AnswerText,
+			Cost:        libraryQuestion.Cost,
+			Options:     libraryQuestion.Options,
+			RoundId:     req.RoundId,
+		})
+	})
+	if err != nil {
+		log.Println("library svc add to game publish check err:", err)
+		return 0, err
+	}
+	id, err := s.storage.AddLibraryQuestion(ctx, gameId, req.LibraryQuestionId, req.Number, req.RoundId)
+	if err != nil {
+		log.Println("library svc add to game err:", err)
+		return id, err
+	}
+	s.audit.Record(ctx, actor, "question.create", model.AuditTargetQuestion, id, nil, req)
+	return id, nil
+}
+
+// SaveQuestion puts the game question into the library, the game question then follows the new library question.
+func (s *libraryService) SaveQuestion(ctx context.Context, actor model.Actor, questionId int, visibility string) (int, error) {
+	visibility, err := validateVisibility(visibility)
+	if err != nil {
+		return 0, err
+	}
+	question, err := s.storage.QuestionLoad(ctx, actor.OrgId, questionId)
+	if err != nil {
+		log.Println("library svc save question load err:", err)
+		return 0, err
+	}
+	if question.LibraryQuestionId != nil && !question.Forked {
+		return 0, fmt.Errorf("%w: the question already follows library question %d", ErrValidation, *question.LibraryQuestionId)
+	}
+	err = access.Require(ctx, s.storage, actor, question.GameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("library svc save question access err:", err)
+		return 0, err
+	}
+	id, err := s.storage.SaveQuestionToLibrary(ctx, questionId, actor.Id, visibility)
+	if err != nil {
+		log.Println("library svc save question err:", err)
+		return id, err
+	}
+	s.audit.Record(ctx, actor, "library.create", model.AuditTargetLibraryQuestion, id, nil, question)
+	return id, nil
+}
+
+// Fork makes the game question a local copy that no longer follows its library question.
+func (s *libraryService) Fork(ctx context.Context, actor model.Actor, questionId int) (int, error) {
+	question, err := s.storage.QuestionLoad(ctx, actor.OrgId, questionId)
+	if err != nil {
+		log.Println("library svc fork load err:", err)
+		return 0, err
+	}
+	if question.LibraryQuestionId == nil {
+		return 0, fmt.Errorf("%w: the question is not from the library", ErrValidation)
+	}
+	err = access.Require(ctx, s.storage, actor, question.GameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("library svc fork access err:", err)
+		return 0, err
+	}
+	res, err := s.storage.ForkQuestion(ctx, questionId)
+	if err != nil {
+		log.Println("library svc fork err:", err)
+		return res, err
+	}
+	s.audit.Record(ctx, actor, "question.fork", model.AuditTargetQuestion, questionId, question, nil)
+	return res, nil
+}
@@ -2,40 +2,68 @@ package lobby
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"quizer_server/internal/db"
 	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"quizer_server/internal/service/audit"
 
 	"github.com/google/uuid"
 )
 
+// ErrGameNotPublished is returned by Create for games that are drafts or archived.
+var ErrGameNotPublished = errors.New("game is not published")
+
 type Service interface {
-	Create(ctx context.Context, lobby model.Lobby) (int, error)
+	Create(ctx context.Context, actor model.Actor, lobby model.Lobby) (int, error)
 	LoadByUUID(ctx context.Context, uuid uuid.UUID) (model.Lobby, error)
-	List(ctx context.Context) ([]model.Lobby, error)
-	Update(ctx context.Context, lobbyUUID uuid.UUID) error
+	List(ctx context.Context, actor model.Actor) ([]model.Lobby, error)
+	Update(ctx context.Context, actor model.Actor, lobbyUUID uuid.UUID) error
+	IsHost(ctx context.Context, actor model.Actor, lobbyUUID uuid.UUID) bool
+	PlayerExists(ctx context.Context, playerUUID uuid.UUID) bool
 }
 
 type lobbyService struct {
 	storage db.Storage
+	audit   audit.Service
 }
 
-func New(s db.Storage) Service {
+func New(s db.Storage, a audit.Service) Service {
 	return &lobbyService{
 		storage: s,
+		audit:   a,
 	}
 }
 
-func (ls *lobbyService) Create(ctx context.Context, lobby model.Lobby) (int, error) {
+func (ls *lobbyService) Create(ctx context.Context, actor model.Actor, lobby model.Lobby) (int, error) {
 	count := 0
+	err := access.Require(ctx, ls.storage, actor, lobby.GameId, model.PermissionHost)
+	if err != nil {
+		log.Println("lobby svc create access err:", err)
+		return count, err
+	}
+	game, err := ls.storage.GameLoad(ctx, actor.OrgId, lobby.GameId)
+	if err != nil {
+		log.Println("lobby svc create load game err:", err)
+		return count, err
+	}
+	if game.Status != model.GameStatusPublished {
+		return count, fmt.Errorf("%w: game %d is %s", ErrGameNotPublished, game.Id, game.Status)
+	}
+
 	lobby.IsStarted = false
-	err := ls.storage.CreateLobby(ctx, lobby)
+	lobby.OrgId = actor.OrgId
+	err = ls.storage.CreateLobby(ctx, lobby)
 
 	if err != nil {
 		log.Println("lobby svc create err:", err)
+		return count, err
 	}
+	ls.audit.Record(ctx, actor, "lobby.create", model.AuditTargetLobby, lobby.UUID, nil, lobby)
 
-	questions, _ := ls.storage.QuestionsByGameId(ctx, lobby.GameId)
+	questions, _ := ls.storage.QuestionsByGameId(ctx, lobby.OrgId, lobby.GameId)
 
 	count = len(questions)
 
@@ -51,8 +79,8 @@ func (ls *lobbyService) LoadByUUID(ctx context.Context, uuid uuid.UUID) (model.L
 	return res, nil
 }
 
-func (ls *lobbyService) List(ctx context.Context) ([]model.Lobby, error) {
-	res, err := ls.storage.LobbyList(ctx)
+func (ls *lobbyService) List(ctx context.Context, actor model.Actor) ([]model.Lobby, error) {
+	res, err := ls.storage.LobbyList(ctx, actor.OrgId)
 	if err != nil {
 		log.Println("lobby svc list err:", err)
 		return res, err
@@ -60,12 +88,52 @@ func (ls *lobbyService) List(ctx context.Context) ([]model.Lobby, error) {
 	return res, nil
 }
 
-func (ls *lobbyService) Update(ctx context.Context, lobbyUUID uuid.UUID) error {
+// Update starts the lobby and pins it to a snapshot of the game, questions are served
+// and scored from the snapshot from now on.
+func (ls *lobbyService) Update(ctx context.Context, actor model.Actor, lobbyUUID uuid.UUID) error {
 	log.Println("svc update, uuid:", lobbyUUID)
-	err := ls.storage.UpdateLobby(ctx, lobbyUUID)
+	before, _ := ls.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	_, err := ls.storage.StartLobby(ctx, lobbyUUID)
 	if err != nil {
 		log.Println("lobby svc update err:", err)
 		return err
 	}
+	after, _ := ls.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	ls.audit.Record(ctx, actor, "lobby.start", model.AuditTargetLobby, lobbyUUID, before, after)
 	return nil
 }
+
+// IsHost reports whether the actor is a member of the lobby's organization and owns the game
+// behind the lobby or was granted the host permission on it.
+func (ls *lobbyService) IsHost(ctx context.Context, actor model.Actor, lobbyUUID uuid.UUID) bool {
+	lobby, err := ls.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	if err != nil {
+		log.Println("lobby svc is host load lobby err:", err)
+		return false
+	}
+	_, err = ls.storage.OrganizationRole(ctx, lobby.OrgId, actor.Id)
+	if err != nil {
+		log.Println("lobby svc is host membership err:", err)
+		return false
+	}
+	game, err := ls.storage.GameLoad(ctx, lobby.OrgId, lobby.GameId)
+	if err != nil {
+		log.Println("lobby svc is host load game err:", err)
+		return false
+	}
+	if game.OwnerId == actor.Id {
+		return true
+	}
+	perm, err := ls.storage.CollaboratorPermission(ctx, game.Id, actor.Id)
+	return err == nil && perm == model.PermissionHost
+}
+
+// PlayerExists reports whether a player with the given UUID has already joined a lobby.
+func (ls *lobbyService) PlayerExists(ctx context.Context, playerUUID uuid.UUID) bool {
+	exists, err := ls.storage.PlayerExists(ctx, playerUUID)
+	if err != nil {
+		log.Println("lobby svc player exists err:", err)
+		return true
+	}
+	return exists
+}
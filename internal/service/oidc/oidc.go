@@ -0,0 +1,182 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"quizer_server/internal/db"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/user"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrDisabled     = errors.New("oidc login is disabled")
+	ErrInvalidState = errors.New("invalid or expired oidc state")
+	ErrInvalidToken = errors.New("invalid id token")
+	ErrProvider     = errors.New("oidc provider error")
+)
+
+// Config describes the OpenID Connect provider. Login is disabled when Issuer is empty.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	StateTTL     time.Duration
+}
+
+type Service interface {
+	Enabled() bool
+	AuthURL(ctx context.Context) (authURL string, state string, err error)
+	Callback(ctx context.Context, actor model.Actor, code string, state string) (model.User, error)
+	StateTTL() time.Duration
+}
+
+type oidcService struct {
+	cfg      Config
+	users    user.Service
+	storage  db.Storage
+	provider *provider
+}
+
+// New creates the OpenID Connect login service. The HTTP client is used for every request
+// to the provider, which allows running the flow against a local mock provider.
+func New(cfg Config, us user.Service, st db.Storage, client *http.Client) Service {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid"}
+	}
+	if cfg.StateTTL == 0 {
+		cfg.StateTTL = 10 * time.Minute
+	}
+	return &oidcService{
+		cfg:      cfg,
+		users:    us,
+		storage:  st,
+		provider: newProvider(cfg.Issuer, client),
+	}
+}
+
+func (s *oidcService) Enabled() bool {
+	return s.cfg.Issuer != ""
+}
+
+// StateTTL returns how long a started login stays valid.
+func (s *oidcService) StateTTL() time.Duration {
+	return s.cfg.StateTTL
+}
+
+// AuthURL starts a login: it stores a new state with the nonce and PKCE verifier
+// and returns the provider's authorization URL the browser should be redirected to.
+func (s *oidcService) AuthURL(ctx context.Context) (string, string, error) {
+	if !s.Enabled() {
+		return "", "", ErrDisabled
+	}
+
+	meta, err := s.provider.metadata(ctx)
+	if err != nil {
+		log.Println("oidc svc auth url discovery err:", err)
+		return "", "", err
+	}
+
+	data := model.OidcState{
+		State:        randomString(),
+		Nonce:        randomString(),
+		CodeVerifier: randomString(),
+		ExpiresAt:    time.Now().Add(s.cfg.StateTTL),
+	}
+	err = s.storage.CreateOidcState(ctx, data)
+	if err != nil {
+		log.Println("oidc svc auth url save state err:", err)
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(data.CodeVerifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.cfg.ClientID},
+		"redirect_uri":          {s.cfg.RedirectURL},
+		"scope":                 {strings.Join(s.cfg.Scopes, " ")},
+		"state":                 {data.State},
+		"nonce":                 {data.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + params.Encode(), data.State, nil
+}
+
+// Callback completes a login: it consumes the state, exchanges the code for tokens,
+// verifies the ID token and returns the local user linked to the identity.
+func (s *oidcService) Callback(ctx context.Context, actor model.Actor, code string, state string) (model.User, error) {
+	if !s.Enabled() {
+		return model.User{}, ErrDisabled
+	}
+
+	pending, err := s.storage.TakeOidcState(ctx, state)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Println("oidc svc callback load state err:", err)
+			return model.User{}, err
+		}
+		return model.User{}, ErrInvalidState
+	}
+	if time.Now().After(pending.ExpiresAt) {
+		return model.User{}, ErrInvalidState
+	}
+
+	rawIDToken, err := s.provider.exchange(ctx, s.cfg, code, pending.CodeVerifier)
+	if err != nil {
+		log.Println("oidc svc callback exchange err:", err)
+		return model.User{}, err
+	}
+
+	claims, err := s.provider.verify(ctx, rawIDToken, s.cfg.ClientID)
+	if err != nil {
+		log.Println("oidc svc callback verify err:", err)
+		return model.User{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Nonce != pending.Nonce {
+		return model.User{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+
+	identity := dto.ExternalIdentity{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		PreferredUsername: claims.PreferredUsername,
+		Name:              claims.Name,
+	}
+	if claims.EmailVerified {
+		identity.Email = claims.Email
+	}
+
+	return s.users.LoginExternal(ctx, actor, identity)
+}
+
+// randomString returns 32 random bytes encoded with base64url, suitable for state, nonce and PKCE verifier.
+func randomString() string {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
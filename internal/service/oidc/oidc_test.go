@@ -0,0 +1,232 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"quizer_server/internal/db"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/oidc"
+	"quizer_server/internal/service/oidc/oidctest"
+	"quizer_server/internal/service/user"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	clientId    = "quizer"
+	redirectURL = "https://quizer.example/auth/oidc/callback"
+)
+
+// stateStorage keeps the pending logins in memory, the flow needs no other storage.
+type stateStorage struct {
+	db.Storage
+
+	mu     sync.Mutex
+	states map[string]model.OidcState
+}
+
+func (s *stateStorage) CreateOidcState(ctx context.Context, data model.OidcState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[data.State] = data
+	return nil
+}
+
+func (s *stateStorage) TakeOidcState(ctx context.Context, state string) (model.OidcState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.states[state]
+	if !ok {
+		return data, pgx.ErrNoRows
+	}
+	delete(s.states, state)
+	return data, nil
+}
+
+// linkingUsers links identities to users like the user service, keyed by issuer and subject.
+type linkingUsers struct {
+	user.Service
+
+	identities []dto.ExternalIdentity
+	users      map[string]model.User
+}
+
+func (u *linkingUsers) LoginExternal(ctx context.Context, actor model.Actor, identity dto.ExternalIdentity) (model.User, error) {
+	u.identities = append(u.identities, identity)
+	key := identity.Issuer + " " + identity.Subject
+	if res, ok := u.users[key]; ok {
+		return res, nil
+	}
+	res := model.User{Id: len(u.users) + 1, Login: identity.PreferredUsername, Email: identity.Email}
+	u.users[key] = res
+	return res, nil
+}
+
+func newService(t *testing.T, stateTTL time.Duration) (oidc.Service, *oidctest.Provider, *linkingUsers, *http.Client) {
+	t.Helper()
+	provider := oidctest.NewProvider()
+	t.Cleanup(provider.Close)
+
+	users := &linkingUsers{users: map[string]model.User{}}
+	client := provider.Client()
+	svc := oidc.New(oidc.Config{
+		Issuer:      provider.URL,
+		ClientID:    clientId,
+		RedirectURL: redirectURL,
+		Scopes:      []string{"openid", "email", "profile"},
+		StateTTL:    stateTTL,
+	}, users, &stateStorage{states: map[string]model.OidcState{}}, client)
+	return svc, provider, users, client
+}
+
+// authorize follows the authorization URL like a browser and returns the code and state
+// the provider sends back to the redirect URL.
+func authorize(t *testing.T, client *http.Client, authURL string) (string, string) {
+	t.Helper()
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize redirect: %v", err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != redirectURL {
+		t.Fatalf("redirected to %q, want %q", got, redirectURL)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestLoginLinksUser(t *testing.T) {
+	svc, provider, users, client := newService(t, 0)
+	ctx := context.Background()
+	provider.SetIdentity(oidctest.Identity{
+		Subject:           "user-1",
+		Email:             "alice@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "alice",
+		Name:              "Alice",
+	})
+
+	if svc.StateTTL() != 10*time.Minute {
+		t.Fatalf("default state ttl %v", svc.StateTTL())
+	}
+
+	var first model.User
+	for i := 0; i < 2; i++ {
+		authURL, state, err := svc.AuthURL(ctx)
+		if err != nil {
+			t.Fatalf("auth url: %v", err)
+		}
+		code, returned := authorize(t, client, authURL)
+		if returned != state {
+			t.Fatalf("provider returned state %q, want %q", returned, state)
+		}
+
+		got, err := svc.Callback(ctx, model.Actor{}, code, state)
+		if err != nil {
+			t.Fatalf("callback: %v", err)
+		}
+		if i == 0 {
+			first = got
+			continue
+		}
+		if got.Id != first.Id {
+			t.Fatalf("second login returned user %d, want the linked user %d", got.Id, first.Id)
+		}
+	}
+
+	want := dto.ExternalIdentity{
+		Issuer:            provider.URL,
+		Subject:           "user-1",
+		PreferredUsername: "alice",
+		Email:             "alice@example.com",
+		Name:              "Alice",
+	}
+	for _, identity := range users.identities {
+		if identity != want {
+			t.Fatalf("identity %+v, want %+v", identity, want)
+		}
+	}
+}
+
+func TestLoginDropsUnverifiedEmail(t *testing.T) {
+	svc, provider, users, client := newService(t, 0)
+	ctx := context.Background()
+	provider.SetIdentity(oidctest.Identity{Subject: "user-2", Email: "bob@example.com"})
+
+	authURL, state, err := svc.AuthURL(ctx)
+	if err != nil {
+		t.Fatalf("auth url: %v", err)
+	}
+	code, _ := authorize(t, client, authURL)
+	_, err = svc.Callback(ctx, model.Actor{}, code, state)
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	if email := users.identities[0].Email; email != "" {
+		t.Fatalf("unverified email %q was passed on", email)
+	}
+}
+
+func TestCallbackRejectsReusedState(t *testing.T) {
+	svc, provider, _, client := newService(t, 0)
+	ctx := context.Background()
+	provider.SetIdentity(oidctest.Identity{Subject: "user-3"})
+
+	authURL, state, err := svc.AuthURL(ctx)
+	if err != nil {
+		t.Fatalf("auth url: %v", err)
+	}
+	code, _ := authorize(t, client, authURL)
+	_, err = svc.Callback(ctx, model.Actor{}, code, state)
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	_, err = svc.Callback(ctx, model.Actor{}, code, state)
+	if !errors.Is(err, oidc.ErrInvalidState) {
+		t.Fatalf("reused state: got %v, want ErrInvalidState", err)
+	}
+}
+
+func TestCallbackRejectsExpiredState(t *testing.T) {
+	svc, provider, _, client := newService(t, time.Nanosecond)
+	ctx := context.Background()
+	provider.SetIdentity(oidctest.Identity{Subject: "user-4"})
+
+	authURL, state, err := svc.AuthURL(ctx)
+	if err != nil {
+		t.Fatalf("auth url: %v", err)
+	}
+	code, _ := authorize(t, client, authURL)
+	time.Sleep(time.Millisecond)
+	_, err = svc.Callback(ctx, model.Actor{}, code, state)
+	if !errors.Is(err, oidc.ErrInvalidState) {
+		t.Fatalf("expired state: got %v, want ErrInvalidState", err)
+	}
+}
+
+func TestCallbackRejectsUnknownCode(t *testing.T) {
+	svc, provider, _, _ := newService(t, 0)
+	ctx := context.Background()
+	provider.SetIdentity(oidctest.Identity{Subject: "user-5"})
+
+	_, state, err := svc.AuthURL(ctx)
+	if err != nil {
+		t.Fatalf("auth url: %v", err)
+	}
+	_, err = svc.Callback(ctx, model.Actor{}, "forged", state)
+	if !errors.Is(err, oidc.ErrProvider) {
+		t.Fatalf("unknown code: got %v, want ErrProvider", err)
+	}
+}
@@ -0,0 +1,192 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"quizer_server/internal/db"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/audit"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrValidation = errors.New("validation failed")
+	ErrNameTaken  = errors.New("organization name is already taken")
+)
+
+type Service interface {
+	Resolve(ctx context.Context, actor model.Actor, requested int) (int, error)
+	Create(ctx context.Context, actor model.Actor, req dto.CreateOrganizationRequest) (int, error)
+	List(ctx context.Context, actor model.Actor) ([]model.Organization, error)
+	Members(ctx context.Context, actor model.Actor, orgId int) ([]model.OrganizationMember, error)
+	AddMember(ctx context.Context, actor model.Actor, orgId int, req dto.AddMemberRequest) error
+	RemoveMember(ctx context.Context, actor model.Actor, orgId int, userId int) (int, error)
+}
+
+type organizationService struct {
+	storage db.Storage
+	audit   audit.Service
+}
+
+func New(s db.Storage, a audit.Service) Service {
+	return &organizationService{
+		storage: s,
+		audit:   a,
+	}
+}
+
+// Resolve returns the organization the actor works in. The requested organization
+// is used when the actor is a member of it or an admin, otherwise the first organization
+// the actor joined is used. Zero is returned for actors without an organization.
+func (s *organizationService) Resolve(ctx context.Context, actor model.Actor, requested int) (int, error) {
+	if requested != 0 {
+		err := s.requireRole(ctx, actor, requested, model.OrgRoleMember)
+		if err != nil {
+			return 0, err
+		}
+		return requested, nil
+	}
+
+	orgs, err := s.storage.OrganizationsByUser(ctx, actor.Id)
+	if err != nil {
+		log.Println("organization svc resolve err:", err)
+		return 0, err
+	}
+	if len(orgs) == 0 {
+		return 0, nil
+	}
+	return orgs[0].Id, nil
+}
+
+// Create creates an organization and makes the actor its admin.
+func (s *organizationService) Create(ctx context.Context, actor model.Actor, req dto.CreateOrganizationRequest) (int, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return 0, fmt.Errorf("%w: name must be 1-100 characters long", ErrValidation)
+	}
+
+	id, err := s.storage.CreateOrganization(ctx, name)
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			return 0, ErrNameTaken
+		}
+		log.Println("organization svc create err:", err)
+		return 0, err
+	}
+
+	err = s.storage.SaveOrganizationMember(ctx, id, actor.Id, model.OrgRoleAdmin)
+	if err != nil {
+		log.Println("organization svc create add admin err:", err)
+		return id, err
+	}
+
+	s.audit.Record(ctx, actor, "organization.create", model.AuditTargetOrg, id, nil, req)
+	return id, nil
+}
+
+// List returns the organizations of the actor. Admins see every organization.
+func (s *organizationService) List(ctx context.Context, actor model.Actor) ([]model.Organization, error) {
+	var (
+		res []model.Organization
+		err error
+	)
+	if actor.IsAdmin() {
+		res, err = s.storage.OrganizationList(ctx)
+	} else {
+		res, err = s.storage.OrganizationsByUser(ctx, actor.Id)
+	}
+	if err != nil {
+		log.Println("organization svc list err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+func (s *organizationService) Members(ctx context.Context, actor model.Actor, orgId int) ([]model.OrganizationMember, error) {
+	err := s.requireRole(ctx, actor, orgId, model.OrgRoleMember)
+	if err != nil {
+		log.Println("organization svc members access err:", err)
+		return nil, err
+	}
+	res, err := s.storage.OrganizationMembers(ctx, orgId)
+	if err != nil {
+		log.Println("organization svc members err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// AddMember adds the user with the given login to the organization or changes the role
+// of an existing member. Only organization admins can manage members.
+func (s *organizationService) AddMember(ctx context.Context, actor model.Actor, orgId int, req dto.AddMemberRequest) error {
+	err := s.requireRole(ctx, actor, orgId, model.OrgRoleAdmin)
+	if err != nil {
+		log.Println("organization svc add member access err:", err)
+		return err
+	}
+
+	if req.Role == "" {
+		req.Role = model.OrgRoleMember
+	}
+	if req.Role != model.OrgRoleMember && req.Role != model.OrgRoleAdmin {
+		return fmt.Errorf("%w: unknown role %q", ErrValidation, req.Role)
+	}
+
+	user, err := s.storage.UserByLogin(ctx, req.Login)
+	if err != nil {
+		log.Println("organization svc add member load user err:", err)
+		return err
+	}
+
+	before, _ := s.storage.OrganizationRole(ctx, orgId, user.Id)
+	err = s.storage.SaveOrganizationMember(ctx, orgId, user.Id, req.Role)
+	if err != nil {
+		log.Println("organization svc add member err:", err)
+		return err
+	}
+
+	s.audit.Record(ctx, actor, "organization.add_member", model.AuditTargetOrg, orgId,
+		map[string]any{"user_id": user.Id, "role": before},
+		map[string]any{"user_id": user.Id, "role": req.Role})
+	return nil
+}
+
+func (s *organizationService) RemoveMember(ctx context.Context, actor model.Actor, orgId int, userId int) (int, error) {
+	err := s.requireRole(ctx, actor, orgId, model.OrgRoleAdmin)
+	if err != nil {
+		log.Println("organization svc remove member access err:", err)
+		return 0, err
+	}
+
+	before, _ := s.storage.OrganizationRole(ctx, orgId, userId)
+	id, err := s.storage.DeleteOrganizationMember(ctx, orgId, userId)
+	if err != nil {
+		log.Println("organization svc remove member err:", err)
+		return id, err
+	}
+
+	s.audit.Record(ctx, actor, "organization.remove_member", model.AuditTargetOrg, orgId,
+		map[string]any{"user_id": userId, "role": before}, nil)
+	return id, nil
+}
+
+// requireRole returns model.ErrForbidden unless the actor is an admin or has
+// the role in the organization. Organization admins have every role.
+func (s *organizationService) requireRole(ctx context.Context, actor model.Actor, orgId int, role string) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	granted, err := s.storage.OrganizationRole(ctx, orgId, actor.Id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err == nil && (granted == role || granted == model.OrgRoleAdmin) {
+		return nil
+	}
+	return fmt.Errorf("%w: user %d has no %s role in organization %d", model.ErrForbidden, actor.Id, role, orgId)
+}
@@ -0,0 +1,66 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"log"
+	"quizer_server/internal/db"
+	"quizer_server/internal/model"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+var ErrCannotClaim = errors.New("player does not exist or is claimed by another account")
+
+type Service interface {
+	Claim(ctx context.Context, actor model.Actor, playerUUID uuid.UUID) error
+	History(ctx context.Context, actor model.Actor, limit int, offset int) ([]model.PlayerHistoryEntry, error)
+}
+
+type playerService struct {
+	storage db.Storage
+}
+
+func New(s db.Storage) Service {
+	return &playerService{
+		storage: s,
+	}
+}
+
+// Claim links the player to the actor's account, so the game shows up in the account's history.
+// The caller has to prove it owns the player, usually with the player token of the lobby.
+func (ps *playerService) Claim(ctx context.Context, actor model.Actor, playerUUID uuid.UUID) error {
+	ok, err := ps.storage.ClaimPlayer(ctx, playerUUID, actor.Id)
+	if err != nil {
+		log.Println("player svc claim err:", err)
+		return err
+	}
+	if !ok {
+		return ErrCannotClaim
+	}
+	return nil
+}
+
+func (ps *playerService) History(ctx context.Context, actor model.Actor, limit int, offset int) ([]model.PlayerHistoryEntry, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	res, err := ps.storage.PlayerHistory(ctx, actor.Id, limit, offset)
+	if err != nil {
+		log.Println("player svc history err:", err)
+		return res, err
+	}
+	return res, nil
+}
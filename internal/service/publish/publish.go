@@ -0,0 +1,171 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"quizer_server/internal/db"
+	"quizer_server/internal/model"
+	"slices"
+	"strings"
+)
+
+// ErrNotPublishable is returned together with the problems that keep the game from being published.
+var ErrNotPublishable = errors.New("game is not ready to be published")
+
+// Error lists the problems a change would cause in a published game, it matches ErrNotPublishable.
+type Error struct {
+	GameId int
+	Issues []model.ImportIssue
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: game %d has %d problems", ErrNotPublishable, e.GameId, len(e.Issues))
+}
+
+func (e *Error) Unwrap() error {
+	return ErrNotPublishable
+}
+
+// Change returns the questions of the game as they are after an edit.
+type Change func(questions []model.Question) []model.Question
+
+// Adding adds the questions to the game.
+func Adding(added ...model.Question) Change {
+	return func(questions []model.Question) []model.Question {
+		return append(questions, added...)
+	}
+}
+
+// Removing takes the question out of the game.
+func Removing(id int) Change {
+	return func(questions []model.Question) []model.Question {
+		return slices.DeleteFunc(questions, func(q model.Question) bool { return q.Id == id })
+	}
+}
+
+// Replacing saves the question of the game with the same id.
+func Replacing(updated model.Question) Change {
+	return func(questions []model.Question) []model.Question {
+		for i, q := range questions {
+			if q.Id == updated.Id {
+				questions[i] = updated
+			}
+		}
+		return questions
+	}
+}
+
+// Check returns *Error when the change would keep the published game from being published.
+// Only the problems the change adds are reported, so games published with problems can still
+// be fixed one edit at a time. Drafts and archived games are validated when they are published.
+func Check(ctx context.Context, s db.Storage, orgId int, gameId int, change Change) error {
+	game, err := s.GameLoad(ctx, orgId, gameId)
+	if err != nil {
+		return err
+	}
+	if game.Status != model.GameStatusPublished {
+		return nil
+	}
+	before, err := s.QuestionsByGameId(ctx, orgId, gameId)
+	if err != nil {
+		return err
+	}
+	after := change(slices.Clone(before))
+
+	known := map[string]bool{}
+	for _, f := range validate(sortByNumber(before)) {
+		known[f.key] = true
+	}
+	issues := []model.ImportIssue{}
+	for _, f := range validate(sortByNumber(after)) {
+		if !known[f.key] {
+			issues = append(issues, f.issue)
+		}
+	}
+	if len(issues) > 0 {
+		return &Error{GameId: gameId, Issues: issues}
+	}
+	return nil
+}
+
+func sortByNumber(questions []model.Question) []model.Question {
+	slices.SortStableFunc(questions, func(a, b model.Question) int { return a.Number - b.Number })
+	return questions
+}
+
+// Validate checks that the questions are numbered 1..n, that every question
+// has a text, an answer that can be checked and a cost. Paths index the questions in
+// the order of their numbers, like in the quiz file format.
+func Validate(questions []model.Question) []model.ImportIssue {
+	issues := []model.ImportIssue{}
+	for _, f := range validate(questions) {
+		issues = append(issues, f.issue)
+	}
+	return issues
+}
+
+// finding is a problem found by validate. The key names the question by id and the field,
+// problems of the whole game by their path, so they can be compared across edits that
+// move questions to other positions.
+type finding struct {
+	issue model.ImportIssue
+	key   string
+}
+
+func validate(questions []model.Question) []finding {
+	findings := []finding{}
+	fail := func(i int, field, format string, args ...any) {
+		f := finding{issue: model.ImportIssue{Message: fmt.Sprintf(format, args...)}}
+		if i < 0 {
+			f.issue.Path = field
+			f.key = field
+		} else {
+			f.issue.Path = fmt.Sprintf("questions[%d].%s", i, field)
+			f.key = fmt.Sprintf("%d.%s", questions[i].Id, field)
+		}
+		findings = append(findings, f)
+	}
+
+	if len(questions) == 0 {
+		fail(-1, "questions", "the game has no questions")
+		return findings
+	}
+
+	numbers := map[int]bool{}
+	for i, q := range questions {
+		switch {
+		case q.Number <= 0:
+			fail(i, "number", "must be positive")
+		case numbers[q.Number]:
+			fail(i, "number", "%d is used by another question", q.Number)
+		default:
+			numbers[q.Number] = true
+		}
+
+		if strings.TrimSpace(q.Description) == "" {
+			fail(i, "description", "is required")
+		}
+		if q.Cost <= 0 {
+			fail(i, "cost", "must be positive")
+		}
+
+		answerText := strings.TrimSpace(q.Answer())
+		switch {
+		case q.AnswerNum < 0:
+			fail(i, "answer", "must not be negative")
+		case len(q.Options) > 0 && (q.AnswerNum == 0 || q.AnswerNum > len(q.Options)):
+			fail(i, "answer", "must point to one of the %d options", len(q.Options))
+		case q.AnswerNum == 0 && answerText == "":
+			fail(i, "answer", "the question needs an answer number or an answer text")
+		}
+	}
+
+	for n := 1; n <= len(questions); n++ {
+		if !numbers[n] {
+			fail(-1, "questions", "numbers must run from 1 to %d, %d is missing", len(questions), n)
+			break
+		}
+	}
+	return findings
+}
@@ -0,0 +1,126 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"quizer_server/internal/db"
+	"quizer_server/internal/model"
+	"testing"
+)
+
+// gameStorage serves one game with its questions.
+type gameStorage struct {
+	db.Storage
+
+	game      model.Game
+	questions []model.Question
+}
+
+func (s *gameStorage) GameLoad(ctx context.Context, orgId int, id int) (model.Game, error) {
+	return s.game, nil
+}
+
+func (s *gameStorage) QuestionsByGameId(ctx context.Context, orgId int, gameId int) ([]model.Question, error) {
+	return append([]model.Question{}, s.questions...), nil
+}
+
+func question(id, number, cost int) model.Question {
+	return model.Question{Id: id, Number: number, Description: "Question", Cost: cost, AnswerText: "Answer"}
+}
+
+func TestCheck(t *testing.T) {
+	valid := []model.Question{question(1, 1, 1), question(2, 2, 1)}
+	// games published before the validation existed may have questions without points
+	broken := []model.Question{question(1, 1, 0), question(2, 2, 0)}
+
+	tests := []struct {
+		name      string
+		status    string
+		questions []model.Question
+		change    Change
+		issues    []string
+	}{
+		{
+			name:      "valid edit",
+			status:    model.GameStatusPublished,
+			questions: valid,
+			change:    Replacing(question(1, 1, 5)),
+		},
+		{
+			name:      "edit breaks the question",
+			status:    model.GameStatusPublished,
+			questions: valid,
+			change:    Replacing(question(1, 1, 0)),
+			issues:    []string{"questions[0].cost"},
+		},
+		{
+			name:      "delete leaves a gap",
+			status:    model.GameStatusPublished,
+			questions: valid,
+			change:    Removing(1),
+			issues:    []string{"questions"},
+		},
+		{
+			name:      "added question takes a number",
+			status:    model.GameStatusPublished,
+			questions: valid,
+			change:    Adding(question(0, 2, 1)),
+			issues:    []string{"questions[2].number", "questions"},
+		},
+		{
+			name:      "fix one of the old problems",
+			status:    model.GameStatusPublished,
+			questions: broken,
+			change:    Replacing(question(2, 2, 3)),
+		},
+		{
+			name:      "old problem moves with its question",
+			status:    model.GameStatusPublished,
+			questions: broken,
+			change:    Replacing(question(1, 3, 0)),
+			issues:    []string{"questions"},
+		},
+		{
+			name:      "new question repeats an old problem",
+			status:    model.GameStatusPublished,
+			questions: broken,
+			change:    Adding(question(0, 3, 0)),
+			issues:    []string{"questions[2].cost"},
+		},
+		{
+			name:      "drafts are not checked",
+			status:    model.GameStatusDraft,
+			questions: valid,
+			change:    Removing(1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &gameStorage{game: model.Game{Id: 7, Status: tt.status}, questions: tt.questions}
+			err := Check(context.Background(), s, 1, 7, tt.change)
+
+			var notPublishable *Error
+			if len(tt.issues) == 0 {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			if !errors.As(err, &notPublishable) || !errors.Is(err, ErrNotPublishable) {
+				t.Fatalf("got %v, want *Error", err)
+			}
+			paths := []string{}
+			for _, issue := range notPublishable.Issues {
+				paths = append(paths, issue.Path)
+			}
+			if len(paths) != len(tt.issues) {
+				t.Fatalf("issues %v, want %v", paths, tt.issues)
+			}
+			for i := range paths {
+				if paths[i] != tt.issues[i] {
+					t.Fatalf("issues %v, want %v", paths, tt.issues)
+				}
+			}
+		})
+	}
+}
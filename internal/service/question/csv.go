@@ -0,0 +1,200 @@
+package question
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"strconv"
+	"strings"
+)
+
+// csvColumns maps normalized header names to question fields.
+var csvColumns = map[string]string{
+	"number":      "number",
+	"description": "description",
+	"answer":      "answer",
+	"answer_text": "answer_text",
+	"cost":        "cost",
+}
+
+// ImportCSV adds the questions from a CSV file to the game. The first row is a header
+// naming the columns, the delimiter is a comma or a semicolon. Every row is validated
+// first and nothing is saved while the report has errors; otherwise all rows are saved
+// in one transaction unless dryRun is set.
+func (s *questionService) ImportCSV(ctx context.Context, actor model.Actor, gameId int, r io.Reader, dryRun bool) (model.ImportReport, error) {
+	return s.importQuestions(ctx, actor, gameId, dryRun, "question.import_csv",
+		func(existing []model.Question, report *model.ImportReport) []dto.CreateNewQuestionRequest {
+			return parseQuestionsCSV(r, gameId, existing, report)
+		})
+}
+
+// parseQuestionsCSV reads the rows and fills the report. Issue paths are "row N.column"
+// where N is the line of the row in the file, the header being line 1.
+func parseQuestionsCSV(r io.Reader, gameId int, existing []model.Question, report *model.ImportReport) []dto.CreateNewQuestionRequest {
+	fail, warn := issueCollector(report)
+
+	br := bufio.NewReader(r)
+	// spreadsheets saved as "CSV UTF-8" start with a byte order mark
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+	reader := csv.NewReader(br)
+	reader.Comma = detectDelimiter(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		fail("header", "the file is empty")
+		return nil
+	}
+	if err != nil {
+		fail("header", "malformed csv: %v", err)
+		return nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		key := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+		if key == "" {
+			continue
+		}
+		field, ok := csvColumns[key]
+		if !ok {
+			warn(fmt.Sprintf("header.%d", i+1), "unknown column %q is ignored", name)
+			continue
+		}
+		if _, dup := columns[field]; dup {
+			fail("header."+field, "the column is given more than once")
+			continue
+		}
+		columns[field] = i
+	}
+	for _, field := range []string{"number", "description"} {
+		if _, ok := columns[field]; !ok {
+			fail("header."+field, "the column is required")
+		}
+	}
+	if len(report.Errors) > 0 {
+		return nil
+	}
+
+	taken := map[int]string{}
+	for _, q := range existing {
+		taken[q.Number] = "an existing question"
+	}
+
+	res := []dto.CreateNewQuestionRequest{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// a broken quote makes the rest of the file unreliable, so reading stops here
+			path := "rows"
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				path = fmt.Sprintf("row %d", parseErr.StartLine)
+				err = parseErr.Err
+			}
+			fail(path, "malformed csv: %v", err)
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		path := fmt.Sprintf("row %d", line)
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(res) == maxImportRows {
+			fail(path, "at most %d questions are supported", maxImportRows)
+			break
+		}
+
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		number := func(field string) int {
+			v := value(field)
+			if v == "" {
+				return 0
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				fail(path+"."+field, "%q is not a whole number", v)
+			}
+			return n
+		}
+
+		q := dto.CreateNewQuestionRequest{
+			GameId:      gameId,
+			Number:      number("number"),
+			Cost:        number("cost"),
+			AnswerNum:   number("answer"),
+			AnswerText:  value("answer_text"),
+			Description: value("description"),
+		}
+		if value("number") == "" {
+			fail(path+".number", "is required")
+		} else if q.Number <= 0 {
+			fail(path+".number", "must be positive")
+		} else if prev, ok := taken[q.Number]; ok {
+			fail(path+".number", "%d duplicates the number of %s", q.Number, prev)
+		} else {
+			taken[q.Number] = path
+		}
+		if q.Description == "" {
+			fail(path+".description", "is required")
+		}
+		if q.Cost < 0 {
+			fail(path+".cost", "must not be negative")
+		}
+		if q.AnswerNum < 0 {
+			fail(path+".answer", "must not be negative")
+		}
+		if q.AnswerNum == 0 && q.AnswerText == "" {
+			warn(path, "has neither an answer number nor an answer text")
+		}
+		if q.Cost == 0 {
+			warn(path+".cost", "the question gives no points")
+		}
+		res = append(res, q)
+	}
+
+	if len(res) == 0 && len(report.Errors) == 0 {
+		warn("rows", "the file has no questions")
+	}
+	return res
+}
+
+// detectDelimiter picks a semicolon when the header has more of them than commas,
+// which is what spreadsheets use in locales with a decimal comma.
+func detectDelimiter(br *bufio.Reader) rune {
+	line, _ := br.Peek(br.Size())
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
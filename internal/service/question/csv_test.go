@@ -0,0 +1,141 @@
+package question
+
+import (
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuestionsCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		existing []model.Question
+		want     []dto.CreateNewQuestionRequest
+		errors   []string
+		warnings []string
+	}{
+		{
+			name: "comma",
+			src:  "number,description,answer,answer_text,cost\n1,\"Capital of France, the city?\",,Paris,2\n2,Pick one,3,,1\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "Capital of France, the city?", AnswerText: "Paris", Cost: 2},
+				{GameId: 7, Number: 2, Description: "Pick one", AnswerNum: 3, Cost: 1},
+			},
+		},
+		{
+			name: "semicolon",
+			src:  "number;description;answer_text;cost\n1;Pi, to two places;3,14;1\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "Pi, to two places", AnswerText: "3,14", Cost: 1},
+			},
+		},
+		{
+			name: "byte order mark and CRLF",
+			src:  "\ufeffnumber,description,answer,cost\r\n1,Q1,1,1\r\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "Q1", AnswerNum: 1, Cost: 1},
+			},
+		},
+		{
+			name: "headers ignore case, spaces and dashes",
+			src:  " Number ,DESCRIPTION,Answer Text,answer-text2,Cost\n1,Q1,Paris,x,1\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "Q1", AnswerText: "Paris", Cost: 1},
+			},
+			warnings: []string{"header.4"},
+		},
+		{
+			name: "unknown and empty columns",
+			src:  "number,,notes,description,answer,cost\n1,,remember this,Q1,1,1\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "Q1", AnswerNum: 1, Cost: 1},
+			},
+			warnings: []string{"header.3"},
+		},
+		{
+			name:   "required and repeated columns",
+			src:    "Number,number,answer\n1,1,1\n",
+			errors: []string{"header.number", "header.description"},
+		},
+		{
+			name:   "empty file",
+			src:    "",
+			errors: []string{"header"},
+		},
+		{
+			name:     "header only",
+			src:      "number,description\n",
+			want:     []dto.CreateNewQuestionRequest{},
+			warnings: []string{"rows"},
+		},
+		{
+			name:     "existing and duplicate numbers",
+			src:      "number,description,answer,cost\n1,Q1,1,1\n2,Q2,1,1\n2,Q3,1,1\n",
+			existing: []model.Question{{Number: 1}},
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "Q1", AnswerNum: 1, Cost: 1},
+				{GameId: 7, Number: 2, Description: "Q2", AnswerNum: 1, Cost: 1},
+				{GameId: 7, Number: 2, Description: "Q3", AnswerNum: 1, Cost: 1},
+			},
+			errors: []string{"row 2.number", "row 4.number"},
+		},
+		{
+			name: "field issues",
+			src:  "number,description,answer,cost\n,Q1,1,1\n0,Q2,1,1\nx,,-1,-2\n4,Q4,,0\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Description: "Q1", AnswerNum: 1, Cost: 1},
+				{GameId: 7, Description: "Q2", AnswerNum: 1, Cost: 1},
+				{GameId: 7, AnswerNum: -1, Cost: -2},
+				{GameId: 7, Number: 4, Description: "Q4"},
+			},
+			errors:   []string{"row 2.number", "row 3.number", "row 4.number", "row 4.number", "row 4.description", "row 4.cost", "row 4.answer"},
+			warnings: []string{"row 5", "row 5.cost"},
+		},
+		{
+			name: "blank rows and short rows",
+			src:  "number,description,answer_text,cost\n\n , ,,\n1,Q1\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "Q1"},
+			},
+			warnings: []string{"row 4", "row 4.cost"},
+		},
+		{
+			name: "multiline field",
+			src:  "number,description,answer_text,cost\n1,\"first line\nsecond line\",a,1\n2,Q2,b,1\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "first line\nsecond line", AnswerText: "a", Cost: 1},
+				{GameId: 7, Number: 2, Description: "Q2", AnswerText: "b", Cost: 1},
+			},
+		},
+		{
+			name: "broken quote stops reading",
+			src:  "number,description,answer_text,cost\n1,Q1,a,1\n2,\"Q2\"x,b,1\n3,Q3,c,1\n",
+			want: []dto.CreateNewQuestionRequest{
+				{GameId: 7, Number: 1, Description: "Q1", AnswerText: "a", Cost: 1},
+			},
+			errors: []string{"row 3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := newReport()
+			got := parseQuestionsCSV(strings.NewReader(tt.src), 7, tt.existing, report)
+
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			if tt.want == nil && len(got) > 0 {
+				t.Fatalf("got %+v, want no questions", got)
+			}
+			if paths := issuePaths(report.Errors); !reflect.DeepEqual(paths, orEmpty(tt.errors)) {
+				t.Fatalf("errors at %v, want %v: %+v", paths, tt.errors, report.Errors)
+			}
+			if paths := issuePaths(report.Warnings); !reflect.DeepEqual(paths, orEmpty(tt.warnings)) {
+				t.Fatalf("warnings at %v, want %v: %+v", paths, tt.warnings, report.Warnings)
+			}
+		})
+	}
+}
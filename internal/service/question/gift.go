@@ -0,0 +1,283 @@
+package question
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"quizer_server/internal/model"
+	"strconv"
+	"strings"
+)
+
+// giftSpecial are the characters escaped with a backslash in GIFT texts.
+const giftSpecial = "~=#{}:\\"
+
+// readGIFT splits the GIFT text into questions separated by blank lines. Issue paths
+// are "line N" with the first line of the question.
+func readGIFT(r io.Reader, report *model.ImportReport) []moodleQuestion {
+	fail, warn := issueCollector(report)
+	res := []moodleQuestion{}
+
+	block := []string{}
+	start := 0
+	flush := func() {
+		if len(block) > 0 {
+			if q, ok := parseGIFTQuestion(strings.Join(block, "\n"), fmt.Sprintf("line %d", start), fail); ok {
+				res = append(res, q)
+			}
+		}
+		block = block[:0]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	categoryReported := false
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case strings.HasPrefix(trimmed, "//"):
+			continue
+		case trimmed == "":
+			flush()
+			continue
+		case strings.HasPrefix(trimmed, "$CATEGORY:"):
+			if !categoryReported {
+				warn(fmt.Sprintf("line %d", line), "categories are not imported, all questions go to the game")
+				categoryReported = true
+			}
+			continue
+		}
+		if len(block) == 0 {
+			start = line
+		}
+		block = append(block, text)
+	}
+	if err := scanner.Err(); err != nil {
+		fail("file", "can not read the file: %v", err)
+		return nil
+	}
+	flush()
+	return res
+}
+
+// parseGIFTQuestion parses one question: an optional ::title::, an optional [format],
+// the text and the answer block in braces.
+func parseGIFTQuestion(src, path string, fail func(path, format string, args ...any)) (moodleQuestion, bool) {
+	res := moodleQuestion{path: path}
+	src = strings.TrimSpace(src)
+
+	if strings.HasPrefix(src, "::") {
+		end := indexUnescaped(src[2:], "::")
+		if end < 0 {
+			fail(path, "the question title is not closed with ::")
+			return res, false
+		}
+		res.name = unescapeGIFT(strings.TrimSpace(src[2 : 2+end]))
+		src = strings.TrimSpace(src[4+end:])
+	}
+
+	format := "plain"
+	if strings.HasPrefix(src, "[") {
+		if end := strings.IndexByte(src, ']'); end > 0 {
+			format = src[1:end]
+			src = src[end+1:]
+		}
+	}
+
+	open := indexUnescaped(src, "{")
+	if open < 0 {
+		// a text without answers is a description in Moodle
+		res.kind = "description"
+		res.text = unescapeGIFT(src)
+		return res, true
+	}
+	closing := indexUnescaped(src[open:], "}")
+	if closing < 0 {
+		fail(path, "the answer block is not closed with }")
+		return res, false
+	}
+	closing += open
+
+	text := strings.TrimSpace(src[:open])
+	if after := strings.TrimSpace(src[closing+1:]); after != "" {
+		// missing word format, the answer block stands for a gap in the text
+		text += " _____ " + after
+	}
+	text, dropped := plainText(format, unescapeGIFT(text))
+	res.text = text
+	res.dropped = append(res.dropped, dropped...)
+
+	body := strings.TrimSpace(src[open+1 : closing])
+	switch {
+	case body == "":
+		res.kind = moodleEssay
+		return res, true
+	case strings.HasPrefix(body, "#"):
+		res.kind = "numerical"
+		return res, true
+	}
+
+	head, feedback := splitGIFTFeedback(body)
+	switch strings.ToUpper(strings.TrimSpace(head)) {
+	case "T", "TRUE", "F", "FALSE":
+		res.kind = moodleTrueFalse
+		answer := moodleAnswer{text: "true", feedback: feedback}
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(head)), "T") {
+			answer.fraction = 100
+		}
+		res.answers = []moodleAnswer{answer}
+		return res, true
+	}
+
+	hasWrong := false
+	for _, entry := range splitGIFTAnswers(body) {
+		text, feedback := splitGIFTFeedback(entry[1:])
+		if indexUnescaped(text, "->") >= 0 {
+			res.kind = "matching"
+			return res, true
+		}
+		answer := moodleAnswer{feedback: unescapeGIFT(strings.TrimSpace(feedback))}
+		if entry[0] == '=' {
+			answer.fraction = 100
+		} else {
+			hasWrong = true
+		}
+		text = strings.TrimSpace(text)
+		if strings.HasPrefix(text, "%") {
+			end := strings.IndexByte(text[1:], '%')
+			if end < 0 {
+				fail(path, "the answer weight %q is not closed with %%", text)
+				return res, false
+			}
+			weight, err := strconv.ParseFloat(text[1:1+end], 64)
+			if err != nil {
+				fail(path, "the answer weight %q is not a number", text[1:1+end])
+				return res, false
+			}
+			answer.fraction = weight
+			text = strings.TrimSpace(text[2+end:])
+		}
+		answer.text = unescapeGIFT(text)
+		res.answers = append(res.answers, answer)
+	}
+	if len(res.answers) == 0 {
+		fail(path, "the answer block has no answers")
+		return res, false
+	}
+	res.kind = moodleShortAnswer
+	if hasWrong {
+		res.kind = moodleMultichoice
+	}
+	return res, true
+}
+
+// splitGIFTAnswers splits the answer block at unescaped = and ~, each entry keeps its marker.
+func splitGIFTAnswers(body string) []string {
+	res := []string{}
+	start := -1
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			if start >= 0 {
+				res = append(res, body[start:i])
+			}
+			start = i
+		}
+	}
+	if start >= 0 {
+		res = append(res, body[start:])
+	}
+	return res
+}
+
+// splitGIFTFeedback separates the text of an answer from its #feedback.
+func splitGIFTFeedback(s string) (string, string) {
+	i := indexUnescaped(s, "#")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i+1:])
+}
+
+// indexUnescaped returns the index of the first sep in s that is not preceded by a backslash.
+func indexUnescaped(s, sep string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeGIFT(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func escapeGIFT(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case strings.ContainsRune(giftSpecial, r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// writeGIFT renders the questions as GIFT. GIFT has no grades, the points are kept in comments.
+func writeGIFT(questions []moodleQuestion) ([]byte, error) {
+	var b strings.Builder
+	for _, q := range questions {
+		fmt.Fprintf(&b, "// %s, %g points\n", q.name, q.grade)
+		for _, d := range q.dropped {
+			// a line break would end the comment and turn the rest into question text
+			fmt.Fprintf(&b, "// note: %s\n", strings.Join(strings.Fields(d), " "))
+		}
+		fmt.Fprintf(&b, "::%s::%s {", escapeGIFT(q.name), escapeGIFT(q.text))
+		switch q.kind {
+		case moodleTrueFalse:
+			if q.answers[0].fraction >= 100 {
+				b.WriteString("T")
+			} else {
+				b.WriteString("F")
+			}
+		case moodleMultichoice, moodleShortAnswer:
+			for _, a := range q.answers {
+				marker := "~"
+				if a.fraction >= 100 {
+					marker = "="
+				}
+				fmt.Fprintf(&b, "\n\t%s%s", marker, escapeGIFT(a.text))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n\n")
+	}
+	return []byte(b.String()), nil
+}
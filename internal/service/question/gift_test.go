@@ -0,0 +1,229 @@
+package question
+
+import (
+	"quizer_server/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGIFTQuestion(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want moodleQuestion
+		// err is a part of the expected error, the question is parsed when it is empty
+		err string
+	}{
+		{
+			name: "multiple choice",
+			src:  "::Capitals::Capital of France? {=Paris ~London ~Berlin}",
+			want: moodleQuestion{kind: moodleMultichoice, name: "Capitals", text: "Capital of France?", answers: []moodleAnswer{
+				{text: "Paris", fraction: 100}, {text: "London"}, {text: "Berlin"},
+			}},
+		},
+		{
+			name: "escapes",
+			src:  `::Q\:1::Is 2\=2 \{really\}\? {=yes \~ sure ~no \# way}`,
+			want: moodleQuestion{kind: moodleMultichoice, name: "Q:1", text: "Is 2=2 {really}?", answers: []moodleAnswer{
+				{text: "yes ~ sure", fraction: 100}, {text: "no # way"},
+			}},
+		},
+		{
+			name: "line break escape",
+			src:  `First line\nsecond line {=a}`,
+			want: moodleQuestion{kind: moodleShortAnswer, text: "First line\nsecond line", answers: []moodleAnswer{
+				{text: "a", fraction: 100},
+			}},
+		},
+		{
+			name: "short answer",
+			src:  "Capital of France? {=Paris =Paris, France}",
+			want: moodleQuestion{kind: moodleShortAnswer, text: "Capital of France?", answers: []moodleAnswer{
+				{text: "Paris", fraction: 100}, {text: "Paris, France", fraction: 100},
+			}},
+		},
+		{
+			name: "weights and feedback",
+			src:  "Pick one {=a#right ~%50%b#almost ~%-25%c}",
+			want: moodleQuestion{kind: moodleMultichoice, text: "Pick one", answers: []moodleAnswer{
+				{text: "a", fraction: 100, feedback: "right"}, {text: "b", fraction: 50, feedback: "almost"}, {text: "c", fraction: -25},
+			}},
+		},
+		{
+			name: "true",
+			src:  "The sun is a star {T#it is}",
+			want: moodleQuestion{kind: moodleTrueFalse, text: "The sun is a star", answers: []moodleAnswer{
+				{text: "true", fraction: 100, feedback: "it is"},
+			}},
+		},
+		{
+			name: "false",
+			src:  "The moon is a star {FALSE}",
+			want: moodleQuestion{kind: moodleTrueFalse, text: "The moon is a star", answers: []moodleAnswer{
+				{text: "true"},
+			}},
+		},
+		{
+			name: "missing word",
+			src:  "Paris is the {=capital ~port} of France.",
+			want: moodleQuestion{kind: moodleMultichoice, text: "Paris is the _____ of France.", answers: []moodleAnswer{
+				{text: "capital", fraction: 100}, {text: "port"},
+			}},
+		},
+		{
+			name: "html",
+			src:  `[html]<p>Who wrote <b>Hamlet</b>?</p><img src\="hamlet.png"> {=Shakespeare}`,
+			want: moodleQuestion{kind: moodleShortAnswer, text: "Who wrote Hamlet?", dropped: []string{"images in the text"}, answers: []moodleAnswer{
+				{text: "Shakespeare", fraction: 100},
+			}},
+		},
+		{
+			name: "essay",
+			src:  "Tell a story {}",
+			want: moodleQuestion{kind: moodleEssay, text: "Tell a story"},
+		},
+		{
+			name: "numerical",
+			src:  "2 + 2 {#4}",
+			want: moodleQuestion{kind: "numerical", text: "2 + 2"},
+		},
+		{
+			name: "matching",
+			src:  "Match {=cat -> meow =dog -> woof}",
+			want: moodleQuestion{kind: "matching", text: "Match"},
+		},
+		{
+			name: "description",
+			src:  "Round two starts here",
+			want: moodleQuestion{kind: "description", text: "Round two starts here"},
+		},
+		{name: "title not closed", src: "::Title {=a}", err: "title is not closed"},
+		{name: "answers not closed", src: "Text {=a ~b", err: "not closed with }"},
+		{name: "weight not closed", src: "Text {~%50 b =a}", err: "is not closed with %"},
+		{name: "weight not a number", src: "Text {~%half%b =a}", err: `"half" is not a number`},
+		{name: "no answers", src: "Text {just text}", err: "has no answers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := newReport()
+			fail, _ := issueCollector(report)
+			got, ok := parseGIFTQuestion(tt.src, "line 3", fail)
+
+			if tt.err != "" {
+				if ok || len(report.Errors) != 1 || !strings.Contains(report.Errors[0].Message, tt.err) {
+					t.Fatalf("got %v with %+v, want an error with %q", ok, report.Errors, tt.err)
+				}
+				if report.Errors[0].Path != "line 3" {
+					t.Fatalf("error path %q, want line 3", report.Errors[0].Path)
+				}
+				return
+			}
+			if !ok || len(report.Errors) > 0 {
+				t.Fatalf("parse failed: %+v", report.Errors)
+			}
+			tt.want.path = "line 3"
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadGIFT(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		paths    []string
+		errors   []string
+		warnings []string
+	}{
+		{
+			name:  "blank lines separate questions",
+			src:   "Q1 {=a}\n\nQ2\n{=b ~c}\n\n\n::Q3:: Q3 {T}\n",
+			paths: []string{"line 1", "line 3", "line 7"},
+		},
+		{
+			name:  "comments are skipped",
+			src:   "// exported from quizer\n// Question 1, 2 points\nQ1 {=a}\n",
+			paths: []string{"line 3"},
+		},
+		{
+			name:  "byte order mark and CRLF",
+			src:   "\ufeffQ1 {=a}\r\n\r\nQ2 {=b}\r\n",
+			paths: []string{"line 1", "line 3"},
+		},
+		{
+			name:     "categories are reported once",
+			src:      "$CATEGORY: top/one\n\nQ1 {=a}\n\n$CATEGORY: top/two\nQ2 {=b}\n",
+			paths:    []string{"line 3", "line 6"},
+			warnings: []string{"line 1"},
+		},
+		{
+			name:   "broken questions are reported",
+			src:    "Q1 {=a}\n\nQ2 {=b\n\nQ3 {=c}\n",
+			paths:  []string{"line 1", "line 5"},
+			errors: []string{"line 3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := newReport()
+			questions := readGIFT(strings.NewReader(tt.src), report)
+
+			paths := []string{}
+			for _, q := range questions {
+				paths = append(paths, q.path)
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Fatalf("questions at %v, want %v", paths, tt.paths)
+			}
+			if got := issuePaths(report.Errors); !reflect.DeepEqual(got, orEmpty(tt.errors)) {
+				t.Fatalf("errors at %v, want %v", got, tt.errors)
+			}
+			if got := issuePaths(report.Warnings); !reflect.DeepEqual(got, orEmpty(tt.warnings)) {
+				t.Fatalf("warnings at %v, want %v", got, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestWriteGIFTEscapes(t *testing.T) {
+	written := moodleQuestion{
+		kind:    moodleMultichoice,
+		name:    "Question 1",
+		text:    "Is {2+2} = 4? #math: yes\nor no",
+		grade:   2,
+		answers: []moodleAnswer{{text: "a~b", fraction: 100}, {text: `c\d`}},
+		dropped: []string{"note with\na line break"},
+	}
+	data, err := writeGIFT([]moodleQuestion{written})
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	report := newReport()
+	questions := readGIFT(strings.NewReader(string(data)), report)
+	if len(report.Errors) > 0 || len(questions) != 1 {
+		t.Fatalf("written file reads back as %+v with %+v", questions, report.Errors)
+	}
+	got := questions[0]
+	if got.name != written.name || got.text != written.text || !reflect.DeepEqual(got.answers, written.answers) {
+		t.Fatalf("read back %+v, want %+v", got, written)
+	}
+}
+
+func issuePaths(issues []model.ImportIssue) []string {
+	res := []string{}
+	for _, i := range issues {
+		res = append(res, i.Path)
+	}
+	return res
+}
+
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
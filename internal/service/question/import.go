@@ -0,0 +1,86 @@
+package question
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"quizer_server/internal/service/publish"
+)
+
+// maxImportRows limits the number of questions added by one import.
+const maxImportRows = 1000
+
+// parseFunc turns an uploaded file into new questions of the game. It appends every
+// problem to the report; existing holds the questions already in the game.
+type parseFunc func(existing []model.Question, report *model.ImportReport) []dto.CreateNewQuestionRequest
+
+// importQuestions checks the actor may edit the game, parses the file and saves all
+// questions in one transaction. Nothing is saved when the report has errors or dryRun is set,
+// questions that would keep a published game from being published are reported as errors.
+func (s *questionService) importQuestions(ctx context.Context, actor model.Actor, gameId int, dryRun bool, action string, parse parseFunc) (model.ImportReport, error) {
+	err := access.Require(ctx, s.storage, actor, gameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("question svc import access err:", err)
+		return model.ImportReport{}, err
+	}
+
+	existing, err := s.storage.QuestionsByGameId(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("question svc import load questions err:", err)
+		return model.ImportReport{}, err
+	}
+
+	report := model.ImportReport{
+		GameId:   gameId,
+		DryRun:   dryRun,
+		Errors:   []model.ImportIssue{},
+		Warnings: []model.ImportIssue{},
+	}
+	questions := parse(existing, &report)
+	report.Questions = len(questions)
+	if len(report.Errors) == 0 && len(questions) > 0 {
+		added := make([]model.Question, 0, len(questions))
+		for _, q := range questions {
+			added = append(added, fromRequest(q))
+		}
+		err = publish.Check(ctx, s.storage, actor.OrgId, gameId, publish.Adding(added...))
+		var notPublishable *publish.Error
+		if errors.As(err, &notPublishable) {
+			for _, issue := range notPublishable.Issues {
+				report.Errors = append(report.Errors, model.ImportIssue{Path: issue.Path, Message: "the game is published: " + issue.Message})
+			}
+		} else if err != nil {
+			log.Println("question svc import publish check err:", err)
+			return model.ImportReport{}, err
+		}
+	}
+	report.Valid = len(report.Errors) == 0
+	if !report.Valid || dryRun || len(questions) == 0 {
+		return report, nil
+	}
+
+	err = s.storage.CreateQuestions(ctx, gameId, questions)
+	if err != nil {
+		log.Println("question svc import save err:", err)
+		return model.ImportReport{}, err
+	}
+	s.audit.Record(ctx, actor, action, model.AuditTargetGame, gameId, nil, map[string]any{
+		"questions": len(questions),
+	})
+	return report, nil
+}
+
+// issueCollector returns helpers appending errors and warnings to the report.
+func issueCollector(report *model.ImportReport) (fail, warn func(path, format string, args ...any)) {
+	fail = func(path, format string, args ...any) {
+		report.Errors = append(report.Errors, model.ImportIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	warn = func(path, format string, args ...any) {
+		report.Warnings = append(report.Warnings, model.ImportIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	return fail, warn
+}
@@ -0,0 +1,314 @@
+package question
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"math"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Moodle question bank formats.
+const (
+	FormatGIFT      = "gift"
+	FormatMoodleXML = "xml"
+)
+
+// Option texts of true/false questions.
+const (
+	trueOption  = "True"
+	falseOption = "False"
+)
+
+// Moodle question types mapped to the question model.
+const (
+	moodleMultichoice = "multichoice"
+	moodleTrueFalse   = "truefalse"
+	moodleShortAnswer = "shortanswer"
+	moodleEssay       = "essay"
+)
+
+var ErrUnknownFormat = errors.New("unknown question bank format")
+
+// moodleQuestion is a question of a GIFT or Moodle XML file in a form shared by both formats.
+type moodleQuestion struct {
+	// path locates the question in the file for the report
+	path  string
+	kind  string
+	name  string
+	text  string
+	grade float64
+	// graded is false when the file sets no grade, GIFT never does
+	graded bool
+	// answers of multichoice, truefalse and shortanswer questions
+	answers []moodleAnswer
+	// dropped lists the parts of the question the question model can not hold
+	dropped []string
+}
+
+type moodleAnswer struct {
+	text     string
+	fraction float64
+	feedback string
+}
+
+// ImportMoodle adds the multiple choice, true/false and short answer questions of a GIFT
+// or Moodle XML file to the game after its last question. Other question types and the
+// parts of a question that can not be kept, like feedback or partial credit, are reported
+// as warnings; a file that can not be read is reported with errors and nothing is saved.
+func (s *questionService) ImportMoodle(ctx context.Context, actor model.Actor, gameId int, format string, r io.Reader, dryRun bool) (model.ImportReport, error) {
+	var read func(io.Reader, *model.ImportReport) []moodleQuestion
+	switch format {
+	case FormatGIFT:
+		read = readGIFT
+	case FormatMoodleXML:
+		read = readMoodleXML
+	default:
+		return model.ImportReport{}, ErrUnknownFormat
+	}
+	return s.importQuestions(ctx, actor, gameId, dryRun, "question.import_"+format,
+		func(existing []model.Question, report *model.ImportReport) []dto.CreateNewQuestionRequest {
+			parsed := read(r, report)
+			if len(report.Errors) > 0 {
+				return nil
+			}
+			next := 1
+			for _, q := range existing {
+				next = max(next, q.Number+1)
+			}
+			res := []dto.CreateNewQuestionRequest{}
+			for _, mq := range parsed {
+				q, ok := mapMoodleQuestion(mq, report)
+				if !ok {
+					continue
+				}
+				if len(res) == maxImportRows {
+					_, warn := issueCollector(report)
+					warn(mq.path, "at most %d questions are imported, the rest is skipped", maxImportRows)
+					break
+				}
+				q.GameId = gameId
+				q.Number = next
+				next++
+				res = append(res, q)
+			}
+			if len(res) == 0 && len(report.Errors) == 0 {
+				_, warn := issueCollector(report)
+				warn("questions", "the file has no questions that can be imported")
+			}
+			return res
+		})
+}
+
+// ExportMoodle returns the questions of the game as a GIFT or Moodle XML file. Notes on
+// what the format can not express are written into the file as comments.
+func (s *questionService) ExportMoodle(ctx context.Context, actor model.Actor, gameId int, format string) ([]byte, error) {
+	var write func([]moodleQuestion) ([]byte, error)
+	switch format {
+	case FormatGIFT:
+		write = writeGIFT
+	case FormatMoodleXML:
+		write = writeMoodleXML
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	err := access.Require(ctx, s.storage, actor, gameId, model.PermissionView)
+	if err != nil {
+		log.Println("question svc export access err:", err)
+		return nil, err
+	}
+	questions, err := s.storage.QuestionsByGameId(ctx, actor.OrgId, gameId)
+	if err != nil {
+		log.Println("question svc export load questions err:", err)
+		return nil, err
+	}
+	rounds, err := s.storage.RoundsByGameId(ctx, gameId)
+	if err != nil {
+		log.Println("question svc export load rounds err:", err)
+		return nil, err
+	}
+	byId := map[int]model.Round{}
+	for _, r := range rounds {
+		byId[r.Id] = r
+	}
+
+	items := make([]moodleQuestion, 0, len(questions))
+	for _, q := range questions {
+		item := toMoodleQuestion(q)
+		// question banks have no rounds, the round is kept in a note like other lost details
+		if q.RoundId != nil {
+			if r, ok := byId[*q.RoundId]; ok {
+				item.dropped = append(item.dropped, fmt.Sprintf("round %d %q, points multiplied by %g", r.Position, r.Title, r.Multiplier))
+			}
+		}
+		items = append(items, item)
+	}
+	res, err := write(items)
+	if err != nil {
+		log.Println("question svc export write err:", err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// mapMoodleQuestion converts the question to the question model. It reports false with
+// a warning when the question type can not be mapped and adds warnings for dropped parts.
+func mapMoodleQuestion(mq moodleQuestion, report *model.ImportReport) (dto.CreateNewQuestionRequest, bool) {
+	fail, warn := issueCollector(report)
+	res := dto.CreateNewQuestionRequest{
+		Description: strings.TrimSpace(mq.text),
+		Cost:        1,
+	}
+
+	switch mq.kind {
+	case moodleMultichoice:
+		correct := []int{}
+		for i, a := range mq.answers {
+			switch {
+			case a.fraction >= 100:
+				correct = append(correct, i)
+			case a.fraction > 0:
+				mq.dropped = append(mq.dropped, fmt.Sprintf("partial credit of %g%% for option %d", a.fraction, i+1))
+			case a.fraction < 0:
+				mq.dropped = append(mq.dropped, fmt.Sprintf("penalty of %g%% for option %d", a.fraction, i+1))
+			}
+			res.Options = append(res.Options, strings.TrimSpace(a.text))
+		}
+		if len(correct) != 1 {
+			warn(mq.path, "skipped: multiple choice questions need exactly one fully correct option, found %d", len(correct))
+			return res, false
+		}
+		res.AnswerNum = correct[0] + 1
+	case moodleTrueFalse:
+		if len(mq.answers) == 0 {
+			warn(mq.path, "skipped: the true/false question has no answer")
+			return res, false
+		}
+		res.Options = []string{trueOption, falseOption}
+		res.AnswerNum = 2
+		if mq.answers[0].fraction >= 100 {
+			res.AnswerNum = 1
+		}
+	case moodleShortAnswer:
+		for i, a := range mq.answers {
+			if a.fraction < 100 {
+				mq.dropped = append(mq.dropped, fmt.Sprintf("answer %q worth %g%%", a.text, a.fraction))
+				continue
+			}
+			if res.AnswerText != "" {
+				mq.dropped = append(mq.dropped, fmt.Sprintf("alternative answer %d %q", i+1, a.text))
+				continue
+			}
+			res.AnswerText = strings.TrimSpace(a.text)
+		}
+		if res.AnswerText == "" {
+			warn(mq.path, "skipped: the short answer question has no fully correct answer")
+			return res, false
+		}
+	default:
+		warn(mq.path, "skipped: %s questions are not supported", mq.kind)
+		return res, false
+	}
+
+	for _, a := range mq.answers {
+		if a.feedback != "" {
+			mq.dropped = append(mq.dropped, "answer feedback")
+			break
+		}
+	}
+	if res.Description == "" {
+		fail(mq.path, "the question has no text")
+		return res, false
+	}
+	if mq.grade < 0 {
+		fail(mq.path, "the grade must not be negative")
+		return res, false
+	}
+	if mq.graded {
+		res.Cost = int(math.Round(mq.grade))
+		if float64(res.Cost) != mq.grade {
+			warn(mq.path, "the grade %g is rounded to %d points", mq.grade, res.Cost)
+		}
+	}
+	for _, d := range mq.dropped {
+		warn(mq.path, "not imported: %s", d)
+	}
+	return res, true
+}
+
+// toMoodleQuestion converts the question of the game to the closest Moodle question type.
+func toMoodleQuestion(q model.Question) moodleQuestion {
+	res := moodleQuestion{
+		name:   fmt.Sprintf("Question %d", q.Number),
+		text:   q.Description,
+		grade:  float64(q.Cost),
+		graded: true,
+	}
+	answerText := q.Answer()
+
+	switch {
+	case len(q.Options) == 2 && strings.EqualFold(q.Options[0], trueOption) && strings.EqualFold(q.Options[1], falseOption) &&
+		(q.AnswerNum == 1 || q.AnswerNum == 2):
+		res.kind = moodleTrueFalse
+		res.answers = []moodleAnswer{{text: "true"}, {text: "false"}}
+		res.answers[q.AnswerNum-1].fraction = 100
+	case len(q.Options) > 0:
+		res.kind = moodleMultichoice
+		for i, o := range q.Options {
+			a := moodleAnswer{text: o}
+			if i+1 == q.AnswerNum {
+				a.fraction = 100
+			}
+			res.answers = append(res.answers, a)
+		}
+		if q.AnswerNum < 1 || q.AnswerNum > len(q.Options) {
+			res.dropped = append(res.dropped, "the question has no correct option")
+		}
+	case answerText != "":
+		res.kind = moodleShortAnswer
+		res.answers = []moodleAnswer{{text: answerText, fraction: 100}}
+	case q.AnswerNum > 0:
+		// the options are shown only in the presentation, the number is all there is
+		res.kind = moodleShortAnswer
+		res.answers = []moodleAnswer{{text: strconv.Itoa(q.AnswerNum), fraction: 100}}
+		res.dropped = append(res.dropped, "the options are only in the presentation, the answer is the option number")
+	default:
+		res.kind = moodleEssay
+	}
+	return res
+}
+
+var (
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+	htmlImage = regexp.MustCompile(`(?i)<img\b`)
+)
+
+// plainText converts text in the given Moodle text format to plain text and reports
+// the constructs lost on the way.
+func plainText(format, text string) (string, []string) {
+	if format != "html" {
+		return strings.TrimSpace(text), nil
+	}
+	dropped := []string{}
+	if htmlImage.MatchString(text) {
+		dropped = append(dropped, "images in the text")
+	}
+	text = htmlBreak.ReplaceAllString(text, "\n")
+	text = htmlTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), dropped
+}
@@ -0,0 +1,151 @@
+package question
+
+import (
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMapMoodleQuestion(t *testing.T) {
+	tests := []struct {
+		name string
+		mq   moodleQuestion
+		want dto.CreateNewQuestionRequest
+		ok   bool
+		// errors and warnings are parts of the expected messages in their order
+		errors   []string
+		warnings []string
+	}{
+		{
+			name: "multiple choice",
+			mq: moodleQuestion{kind: moodleMultichoice, text: " Capital of France? ", answers: []moodleAnswer{
+				{text: "London"}, {text: " Paris ", fraction: 100}, {text: "Lyon", fraction: 50}, {text: "Rome", fraction: -25},
+			}},
+			want:     dto.CreateNewQuestionRequest{Description: "Capital of France?", Cost: 1, AnswerNum: 2, Options: []string{"London", "Paris", "Lyon", "Rome"}},
+			ok:       true,
+			warnings: []string{"partial credit of 50% for option 3", "penalty of -25% for option 4"},
+		},
+		{
+			name: "no fully correct option",
+			mq: moodleQuestion{kind: moodleMultichoice, text: "Pick", answers: []moodleAnswer{
+				{text: "a", fraction: 50}, {text: "b", fraction: 50},
+			}},
+			warnings: []string{"exactly one fully correct option, found 0"},
+		},
+		{
+			name: "two fully correct options",
+			mq: moodleQuestion{kind: moodleMultichoice, text: "Pick", answers: []moodleAnswer{
+				{text: "a", fraction: 100}, {text: "b", fraction: 100},
+			}},
+			warnings: []string{"exactly one fully correct option, found 2"},
+		},
+		{
+			name: "true",
+			mq:   moodleQuestion{kind: moodleTrueFalse, text: "The sun is a star", answers: []moodleAnswer{{text: "true", fraction: 100}}},
+			want: dto.CreateNewQuestionRequest{Description: "The sun is a star", Cost: 1, AnswerNum: 1, Options: []string{trueOption, falseOption}},
+			ok:   true,
+		},
+		{
+			name: "false",
+			mq:   moodleQuestion{kind: moodleTrueFalse, text: "The moon is a star", answers: []moodleAnswer{{text: "true"}}},
+			want: dto.CreateNewQuestionRequest{Description: "The moon is a star", Cost: 1, AnswerNum: 2, Options: []string{trueOption, falseOption}},
+			ok:   true,
+		},
+		{
+			name:     "true/false without answer",
+			mq:       moodleQuestion{kind: moodleTrueFalse, text: "The moon is a star"},
+			warnings: []string{"the true/false question has no answer"},
+		},
+		{
+			name: "short answer",
+			mq: moodleQuestion{kind: moodleShortAnswer, text: "Capital of France?", answers: []moodleAnswer{
+				{text: "Lutetia", fraction: 50}, {text: " Paris ", fraction: 100}, {text: "Paris, France", fraction: 100, feedback: "right"},
+			}},
+			want: dto.CreateNewQuestionRequest{Description: "Capital of France?", Cost: 1, AnswerText: "Paris"},
+			ok:   true,
+			warnings: []string{
+				`answer "Lutetia" worth 50%`,
+				`alternative answer 3 "Paris, France"`,
+				"answer feedback",
+			},
+		},
+		{
+			name:     "short answer without fully correct answer",
+			mq:       moodleQuestion{kind: moodleShortAnswer, text: "Capital of France?", answers: []moodleAnswer{{text: "Lutetia", fraction: 50}}},
+			warnings: []string{"no fully correct answer"},
+		},
+		{
+			name:     "unsupported type",
+			mq:       moodleQuestion{kind: "matching", text: "Match"},
+			warnings: []string{"matching questions are not supported"},
+		},
+		{
+			name:   "no text",
+			mq:     moodleQuestion{kind: moodleShortAnswer, text: " ", answers: []moodleAnswer{{text: "a", fraction: 100}}},
+			errors: []string{"the question has no text"},
+		},
+		{
+			name:   "negative grade",
+			mq:     moodleQuestion{kind: moodleShortAnswer, text: "Q", grade: -1, graded: true, answers: []moodleAnswer{{text: "a", fraction: 100}}},
+			errors: []string{"must not be negative"},
+		},
+		{
+			name: "whole grade",
+			mq:   moodleQuestion{kind: moodleShortAnswer, text: "Q", grade: 3, graded: true, answers: []moodleAnswer{{text: "a", fraction: 100}}},
+			want: dto.CreateNewQuestionRequest{Description: "Q", Cost: 3, AnswerText: "a"},
+			ok:   true,
+		},
+		{
+			name:     "grade rounded up",
+			mq:       moodleQuestion{kind: moodleShortAnswer, text: "Q", grade: 2.5, graded: true, answers: []moodleAnswer{{text: "a", fraction: 100}}},
+			want:     dto.CreateNewQuestionRequest{Description: "Q", Cost: 3, AnswerText: "a"},
+			ok:       true,
+			warnings: []string{"the grade 2.5 is rounded to 3 points"},
+		},
+		{
+			name:     "grade rounded down",
+			mq:       moodleQuestion{kind: moodleShortAnswer, text: "Q", grade: 0.4, graded: true, answers: []moodleAnswer{{text: "a", fraction: 100}}},
+			want:     dto.CreateNewQuestionRequest{Description: "Q", Cost: 0, AnswerText: "a"},
+			ok:       true,
+			warnings: []string{"the grade 0.4 is rounded to 0 points"},
+		},
+		{
+			name: "dropped parts",
+			mq: moodleQuestion{kind: moodleShortAnswer, text: "Q", dropped: []string{"images in the text"},
+				answers: []moodleAnswer{{text: "a", fraction: 100}}},
+			want:     dto.CreateNewQuestionRequest{Description: "Q", Cost: 1, AnswerText: "a"},
+			ok:       true,
+			warnings: []string{"not imported: images in the text"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := newReport()
+			tt.mq.path = "question 1"
+			got, ok := mapMoodleQuestion(tt.mq, report)
+
+			if ok != tt.ok {
+				t.Fatalf("mapped %v, want %v, errors %+v, warnings %+v", ok, tt.ok, report.Errors, report.Warnings)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			checkIssues(t, "errors", report.Errors, tt.errors)
+			checkIssues(t, "warnings", report.Warnings, tt.warnings)
+		})
+	}
+}
+
+func checkIssues(t *testing.T, kind string, issues []model.ImportIssue, want []string) {
+	t.Helper()
+	if len(issues) != len(want) {
+		t.Fatalf("%s %+v, want %q", kind, issues, want)
+	}
+	for i, issue := range issues {
+		if issue.Path != "question 1" || !strings.Contains(issue.Message, want[i]) {
+			t.Fatalf("%s %+v, want %q", kind, issues, want)
+		}
+	}
+}
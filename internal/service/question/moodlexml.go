@@ -0,0 +1,169 @@
+package question
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"quizer_server/internal/model"
+	"strconv"
+	"strings"
+)
+
+type moodleXMLQuiz struct {
+	XMLName   xml.Name            `xml:"quiz"`
+	Questions []moodleXMLQuestion `xml:"question"`
+}
+
+type moodleXMLQuestion struct {
+	Comment      string            `xml:",comment"`
+	Type         string            `xml:"type,attr"`
+	Name         *moodleXMLText    `xml:"name,omitempty"`
+	QuestionText *moodleXMLText    `xml:"questiontext,omitempty"`
+	DefaultGrade string            `xml:"defaultgrade,omitempty"`
+	Single       string            `xml:"single,omitempty"`
+	UseCase      string            `xml:"usecase,omitempty"`
+	Answers      []moodleXMLAnswer `xml:"answer"`
+}
+
+type moodleXMLText struct {
+	Format string          `xml:"format,attr,omitempty"`
+	Text   string          `xml:"text"`
+	Files  []moodleXMLFile `xml:"file"`
+}
+
+type moodleXMLFile struct {
+	Name string `xml:"name,attr"`
+}
+
+type moodleXMLAnswer struct {
+	Fraction string         `xml:"fraction,attr"`
+	Format   string         `xml:"format,attr,omitempty"`
+	Text     string         `xml:"text"`
+	Feedback *moodleXMLText `xml:"feedback,omitempty"`
+}
+
+// readMoodleXML decodes a Moodle XML question bank. Issue paths are "question N" with
+// the position of the question element in the file starting from 1.
+func readMoodleXML(r io.Reader, report *model.ImportReport) []moodleQuestion {
+	fail, warn := issueCollector(report)
+
+	quiz := moodleXMLQuiz{}
+	err := xml.NewDecoder(r).Decode(&quiz)
+	if err != nil {
+		fail("file", "malformed Moodle XML: %v", err)
+		return nil
+	}
+
+	res := []moodleQuestion{}
+	categoryReported := false
+	for i, xq := range quiz.Questions {
+		path := fmt.Sprintf("question %d", i+1)
+		if xq.Type == "category" {
+			if !categoryReported {
+				warn(path, "categories are not imported, all questions go to the game")
+				categoryReported = true
+			}
+			continue
+		}
+
+		q := moodleQuestion{path: path, kind: xq.Type}
+		if xq.Name != nil {
+			q.name = strings.TrimSpace(xq.Name.Text)
+		}
+		if xq.QuestionText != nil {
+			q.text, q.dropped = plainText(xq.QuestionText.Format, xq.QuestionText.Text)
+			if len(xq.QuestionText.Files) > 0 {
+				q.dropped = append(q.dropped, fmt.Sprintf("%d embedded files", len(xq.QuestionText.Files)))
+			}
+		}
+		if grade := strings.TrimSpace(xq.DefaultGrade); grade != "" {
+			q.grade, err = strconv.ParseFloat(grade, 64)
+			if err != nil {
+				fail(path+".defaultgrade", "%q is not a number", grade)
+				continue
+			}
+			q.graded = true
+		}
+
+		answers := []moodleAnswer{}
+		for j, xa := range xq.Answers {
+			a := moodleAnswer{}
+			a.text, _ = plainText(xa.Format, xa.Text)
+			if xa.Feedback != nil {
+				a.feedback = strings.TrimSpace(xa.Feedback.Text)
+			}
+			if fraction := strings.TrimSpace(xa.Fraction); fraction != "" {
+				a.fraction, err = strconv.ParseFloat(fraction, 64)
+				if err != nil {
+					fail(fmt.Sprintf("%s.answer %d", path, j+1), "the fraction %q is not a number", fraction)
+					continue
+				}
+			}
+			answers = append(answers, a)
+		}
+
+		switch xq.Type {
+		case moodleMultichoice:
+			if xq.Single == "false" || xq.Single == "0" {
+				q.kind = "multiple answer"
+			}
+			q.answers = answers
+		case moodleTrueFalse:
+			// the model keeps the "true" answer only, false is correct when it is not
+			for _, a := range answers {
+				if strings.EqualFold(a.text, "true") {
+					q.answers = []moodleAnswer{a}
+				}
+			}
+		default:
+			q.answers = answers
+		}
+		res = append(res, q)
+	}
+	return res
+}
+
+// writeMoodleXML renders the questions as a Moodle XML question bank.
+func writeMoodleXML(questions []moodleQuestion) ([]byte, error) {
+	quiz := moodleXMLQuiz{}
+	for _, q := range questions {
+		xq := moodleXMLQuestion{
+			Type:         q.kind,
+			Name:         &moodleXMLText{Text: q.name},
+			QuestionText: &moodleXMLText{Format: "plain_text", Text: q.text},
+			DefaultGrade: strconv.FormatFloat(q.grade, 'f', -1, 64),
+		}
+		if len(q.dropped) > 0 {
+			xq.Comment = xmlComment(strings.Join(q.dropped, "; "))
+		}
+		switch q.kind {
+		case moodleMultichoice:
+			xq.Single = "true"
+		case moodleShortAnswer:
+			xq.UseCase = "0"
+		}
+		for _, a := range q.answers {
+			xq.Answers = append(xq.Answers, moodleXMLAnswer{
+				Fraction: strconv.FormatFloat(a.fraction, 'f', -1, 64),
+				Format:   "plain_text",
+				Text:     a.text,
+			})
+		}
+		quiz.Questions = append(quiz.Questions, xq)
+	}
+
+	data, err := xml.MarshalIndent(quiz, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// xmlComment makes the text safe for an XML comment, which must not contain "--".
+// The notes hold free texts like round titles, so dashes are spaced out.
+func xmlComment(text string) string {
+	for strings.Contains(text, "--") {
+		text = strings.ReplaceAll(text, "--", "- -")
+	}
+	return " " + text + " "
+}
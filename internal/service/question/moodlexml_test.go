@@ -0,0 +1,183 @@
+package question
+
+import (
+	"bytes"
+	"fmt"
+	"quizer_server/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newReport() *model.ImportReport {
+	return &model.ImportReport{Errors: []model.ImportIssue{}, Warnings: []model.ImportIssue{}}
+}
+
+func TestWriteMoodleXMLComment(t *testing.T) {
+	tests := []struct {
+		name    string
+		dropped []string
+	}{
+		{name: "plain", dropped: []string{"answer feedback"}},
+		{name: "double dash", dropped: []string{`round 1 "Music -- 80s", points multiplied by 2`}},
+		{name: "dash runs", dropped: []string{"a---b", "c----d"}},
+		{name: "trailing dash", dropped: []string{"round 2 \"Finale -\""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := writeMoodleXML([]moodleQuestion{{
+				kind:    moodleShortAnswer,
+				name:    "Question 1",
+				text:    "Capital of France?",
+				grade:   1,
+				answers: []moodleAnswer{{text: "Paris", fraction: 100}},
+				dropped: tt.dropped,
+			}})
+			if err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			start := bytes.Index(data, []byte("<!--"))
+			end := bytes.Index(data, []byte("-->"))
+			if start < 0 || end < start {
+				t.Fatalf("no comment in %s", data)
+			}
+			if comment := string(data[start+4 : end]); strings.Contains(comment, "--") {
+				t.Fatalf("comment %q contains --", comment)
+			}
+
+			questions := readMoodleXML(bytes.NewReader(data), newReport())
+			if len(questions) != 1 || questions[0].text != "Capital of France?" {
+				t.Fatalf("written file reads back as %+v", questions)
+			}
+		})
+	}
+}
+
+func TestReadMoodleXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		want     []moodleQuestion
+		errors   []string
+		warnings []string
+	}{
+		{
+			name: "multiple choice",
+			src: `<quiz><question type="multichoice">
+				<name><text>Capitals</text></name>
+				<questiontext format="plain_text"><text> Capital of France? </text></questiontext>
+				<defaultgrade>2.5</defaultgrade>
+				<single>true</single>
+				<answer fraction="100"><text>Paris</text><feedback><text> right </text></feedback></answer>
+				<answer fraction="-50"><text>London</text></answer>
+			</question></quiz>`,
+			want: []moodleQuestion{{path: "question 1", kind: moodleMultichoice, name: "Capitals", text: "Capital of France?", grade: 2.5, graded: true,
+				answers: []moodleAnswer{{text: "Paris", fraction: 100, feedback: "right"}, {text: "London", fraction: -50}},
+			}},
+		},
+		{
+			name: "html text",
+			src: `<quiz><question type="shortanswer">
+				<questiontext format="html"><text><![CDATA[<p>Who wrote <b>Hamlet</b>?</p><p>Think &amp; answer</p><img src="@@PLUGINFILE@@/a.png">]]></text>
+				<file name="a.png">aGk=</file></questiontext>
+				<answer fraction="100" format="html"><text><![CDATA[<p>Shakespeare</p>]]></text></answer>
+			</question></quiz>`,
+			want: []moodleQuestion{{path: "question 1", kind: moodleShortAnswer, text: "Who wrote Hamlet?\nThink & answer",
+				dropped: []string{"images in the text", "1 embedded files"},
+				answers: []moodleAnswer{{text: "Shakespeare", fraction: 100}},
+			}},
+		},
+		{
+			name: "true/false keeps the true answer",
+			src: `<quiz><question type="truefalse">
+				<questiontext><text>The moon is a star</text></questiontext>
+				<answer fraction="0"><text>true</text></answer>
+				<answer fraction="100"><text>false</text></answer>
+			</question></quiz>`,
+			want: []moodleQuestion{{path: "question 1", kind: moodleTrueFalse, text: "The moon is a star",
+				answers: []moodleAnswer{{text: "true"}},
+			}},
+		},
+		{
+			name: "multiple answers",
+			src: `<quiz><question type="multichoice"><single>false</single>
+				<questiontext><text>Pick two</text></questiontext>
+			</question></quiz>`,
+			want: []moodleQuestion{{path: "question 1", kind: "multiple answer", text: "Pick two", answers: []moodleAnswer{}}},
+		},
+		{
+			name: "categories are reported once",
+			src: `<quiz>
+				<question type="category"><category><text>$course$/one</text></category></question>
+				<question type="essay"><questiontext><text>Tell a story</text></questiontext></question>
+				<question type="category"><category><text>$course$/two</text></category></question>
+			</quiz>`,
+			want:     []moodleQuestion{{path: "question 2", kind: moodleEssay, text: "Tell a story", answers: []moodleAnswer{}}},
+			warnings: []string{"question 1"},
+		},
+		{
+			name: "bad numbers",
+			src: `<quiz>
+				<question type="shortanswer"><defaultgrade>two</defaultgrade></question>
+				<question type="shortanswer"><answer fraction="all"><text>a</text></answer><answer fraction="100"><text>b</text></answer></question>
+			</quiz>`,
+			want:   []moodleQuestion{{path: "question 2", kind: moodleShortAnswer, answers: []moodleAnswer{{text: "b", fraction: 100}}}},
+			errors: []string{"question 1.defaultgrade", "question 2.answer 1"},
+		},
+		{
+			name:   "malformed",
+			src:    `<quiz><question type="essay">`,
+			errors: []string{"file"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := newReport()
+			got := readMoodleXML(strings.NewReader(tt.src), report)
+
+			if len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			if len(tt.want) == 0 && len(got) > 0 {
+				t.Fatalf("got %+v, want no questions", got)
+			}
+			if paths := issuePaths(report.Errors); !reflect.DeepEqual(paths, orEmpty(tt.errors)) {
+				t.Fatalf("errors at %v, want %v", paths, tt.errors)
+			}
+			if paths := issuePaths(report.Warnings); !reflect.DeepEqual(paths, orEmpty(tt.warnings)) {
+				t.Fatalf("warnings at %v, want %v", paths, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestWriteMoodleXML(t *testing.T) {
+	written := []moodleQuestion{
+		{kind: moodleMultichoice, name: "Question 1", text: "Capital of France?", grade: 2, graded: true,
+			answers: []moodleAnswer{{text: "Paris", fraction: 100}, {text: "<London & Co>"}}},
+		{kind: moodleTrueFalse, name: "Question 2", text: "The sun is a star", grade: 1, graded: true,
+			answers: []moodleAnswer{{text: "true", fraction: 100}, {text: "false"}}},
+		{kind: moodleShortAnswer, name: "Question 3", text: "2 + 2", grade: 3, graded: true,
+			answers: []moodleAnswer{{text: "4", fraction: 100}}},
+		{kind: moodleEssay, name: "Question 4", text: "Tell a story", grade: 1, graded: true},
+	}
+	data, err := writeMoodleXML(written)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	report := newReport()
+	questions := readMoodleXML(bytes.NewReader(data), report)
+	if len(report.Errors) > 0 || len(report.Warnings) > 0 {
+		t.Fatalf("written file reads back with %+v %+v", report.Errors, report.Warnings)
+	}
+	// true/false questions are read with their true answer only
+	written[1].answers = written[1].answers[:1]
+	written[3].answers = []moodleAnswer{}
+	for i := range written {
+		written[i].path = fmt.Sprintf("question %d", i+1)
+	}
+	if !reflect.DeepEqual(questions, written) {
+		t.Fatalf("read back %+v, want %+v", questions, written)
+	}
+}
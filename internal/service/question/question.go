@@ -2,51 +2,113 @@ package question
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"quizer_server/internal/db"
 	"quizer_server/internal/dto"
 	"quizer_server/internal/model"
+	"quizer_server/internal/service/access"
+	"quizer_server/internal/service/audit"
+	"quizer_server/internal/service/publish"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrRoundNotInGame is returned when a question is put into a round of another game.
+var ErrRoundNotInGame = errors.New("the round does not belong to the game of the question")
+
+const (
+	defaultSearchSize = 50
+	maxSearchSize     = 200
 )
 
 type Service interface {
-	Create(ctx context.Context, data dto.CreateNewQuestionRequest) (int, error)
-	Load(ctx context.Context, id int) (model.Question, error)
-	LoadByNumber(ctx context.Context, gameId int, number int) (model.Question, error)
-	ListByGameId(ctx context.Context, gameId int) ([]model.Question, error)
-	DeleteById(ctx context.Context, id int) (int, error)
-	Update(ctx context.Context, data model.Question) (int, error)
+	Create(ctx context.Context, actor model.Actor, data dto.CreateNewQuestionRequest) (int, error)
+	Load(ctx context.Context, actor model.Actor, id int) (model.Question, error)
+	LoadForLobby(ctx context.Context, lobbyUUID uuid.UUID, position int) (model.Question, error)
+	ListByGameId(ctx context.Context, actor model.Actor, gameId int) ([]model.Question, error)
+	ListForLobby(ctx context.Context, lobbyUUID uuid.UUID) ([]model.Question, error)
+	ForPlayer(ctx context.Context, lobbyUUID uuid.UUID, playerUUID uuid.UUID, questions []model.Question) []model.Question
+	DeleteById(ctx context.Context, actor model.Actor, id int) (int, error)
+	Trash(ctx context.Context, actor model.Actor, gameId int) ([]model.DeletedQuestion, error)
+	Restore(ctx context.Context, actor model.Actor, id int) (int, error)
+	Update(ctx context.Context, actor model.Actor, data model.Question) (int, error)
+	ImportCSV(ctx context.Context, actor model.Actor, gameId int, r io.Reader, dryRun bool) (model.ImportReport, error)
+	ImportMoodle(ctx context.Context, actor model.Actor, gameId int, format string, r io.Reader, dryRun bool) (model.ImportReport, error)
+	ExportMoodle(ctx context.Context, actor model.Actor, gameId int, format string) ([]byte, error)
+	Search(ctx context.Context, actor model.Actor, filter dto.QuestionSearchFilter) ([]model.QuestionSearchResult, error)
 }
 
 type questionService struct {
 	storage db.Storage
+	audit   audit.Service
 }
 
-func New(s db.Storage) Service {
+func New(s db.Storage, a audit.Service) Service {
 	return &questionService{
 		storage: s,
+		audit:   a,
 	}
 }
 
-func (s *questionService) Create(ctx context.Context, data dto.CreateNewQuestionRequest) (int, error) {
+func (s *questionService) Create(ctx context.Context, actor model.Actor, data dto.CreateNewQuestionRequest) (int, error) {
+	err := access.Require(ctx, s.storage, actor, data.GameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("question svc create access err:", err)
+		return 0, err
+	}
+	err = s.checkRound(ctx, actor, data.GameId, data.RoundId)
+	if err != nil {
+		return 0, err
+	}
+	err = publish.Check(ctx, s.storage, actor.OrgId, data.GameId, publish.Adding(fromRequest(data)))
+	if err != nil {
+		log.Println("question svc create publish check err:", err)
+		return 0, err
+	}
 	id, err := s.storage.CreateQuestion(ctx, data)
 	if err != nil {
 		log.Println(err)
 		return id, err
 	}
+	s.audit.Record(ctx, actor, "question.create", model.AuditTargetQuestion, id, nil, data)
 	return id, err
 }
 
-func (s *questionService) Load(ctx context.Context, id int) (model.Question, error) {
-	res, err := s.storage.QuestionLoad(ctx, id)
+func (s *questionService) Load(ctx context.Context, actor model.Actor, id int) (model.Question, error) {
+	res, err := s.storage.QuestionLoad(ctx, actor.OrgId, id)
 	if err != nil {
 		log.Println(err)
 		return res, err
 	}
+	err = access.Require(ctx, s.storage, actor, res.GameId, model.PermissionView)
+	if err != nil {
+		log.Println("question svc load access err:", err)
+		return model.Question{}, err
+	}
 	return res, err
 }
 
-func (s *questionService) LoadByNumber(ctx context.Context, gameId int, number int) (model.Question, error) {
-	res, err := s.storage.QuestionLoadByNumber(ctx, gameId, number)
+// LoadForLobby loads the question played at the position from the snapshot the lobby plays,
+// lobbies that have not started yet see the live question. The position is the question number
+// unless the lobby shuffles questions, see model.QuestionNumber.
+func (s *questionService) LoadForLobby(ctx context.Context, lobbyUUID uuid.UUID, position int) (model.Question, error) {
+	lobby, err := s.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	if err != nil {
+		log.Println("question svc load for lobby load lobby err:", err)
+		return model.Question{}, err
+	}
+	number := model.QuestionNumber(lobby.QuestionOrder, position)
+	var res model.Question
+	if lobby.SnapshotId != nil {
+		res, err = s.storage.SnapshotQuestionByNumber(ctx, *lobby.SnapshotId, number)
+	} else {
+		res, err = s.storage.QuestionLoadByNumber(ctx, lobby.OrgId, lobby.GameId, number)
+	}
 	if err != nil {
 		log.Println("question svc load by number err: ", err)
 		return res, err
@@ -54,8 +116,13 @@ func (s *questionService) LoadByNumber(ctx context.Context, gameId int, number i
 	return res, err
 }
 
-func (s *questionService) ListByGameId(ctx context.Context, gameId int) ([]model.Question, error) {
-	res, err := s.storage.QuestionsByGameId(ctx, gameId)
+func (s *questionService) ListByGameId(ctx context.Context, actor model.Actor, gameId int) ([]model.Question, error) {
+	err := access.Require(ctx, s.storage, actor, gameId, model.PermissionView)
+	if err != nil {
+		log.Println("question svc list access err:", err)
+		return nil, err
+	}
+	res, err := s.storage.QuestionsByGameId(ctx, actor.OrgId, gameId)
 	if err != nil {
 		log.Println(err)
 		return res, err
@@ -63,20 +130,198 @@ func (s *questionService) ListByGameId(ctx context.Context, gameId int) ([]model
 	return res, err
 }
 
-func (s *questionService) DeleteById(ctx context.Context, id int) (int, error) {
+// ListForLobby returns the questions of the game played in the lobby, taken from its snapshot
+// once the lobby has started, in the order the lobby plays them. Access is checked when joining
+// the lobby, so no actor is required here.
+func (s *questionService) ListForLobby(ctx context.Context, lobbyUUID uuid.UUID) ([]model.Question, error) {
+	lobby, err := s.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	if err != nil {
+		log.Println("question svc list for lobby load lobby err:", err)
+		return nil, err
+	}
+	var res []model.Question
+	if lobby.SnapshotId != nil {
+		res, err = s.storage.SnapshotQuestions(ctx, *lobby.SnapshotId)
+	} else {
+		res, err = s.storage.QuestionsByGameId(ctx, lobby.OrgId, lobby.GameId)
+	}
+	if err != nil {
+		log.Println("question svc list for lobby err:", err)
+		return res, err
+	}
+	return orderQuestions(res, lobby.QuestionOrder), nil
+}
+
+func (s *questionService) DeleteById(ctx context.Context, actor model.Actor, id int) (int, error) {
+	question, err := s.storage.QuestionLoad(ctx, actor.OrgId, id)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	err = access.Require(ctx, s.storage, actor, question.GameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("question svc delete access err:", err)
+		return 0, err
+	}
+	err = publish.Check(ctx, s.storage, actor.OrgId, question.GameId, publish.Removing(id))
+	if err != nil {
+		log.Println("question svc delete publish check err:", err)
+		return 0, err
+	}
 	res, err := s.storage.DeleteQuestion(ctx, id)
 	if err != nil {
 		log.Println(err)
 		return res, err
 	}
+	s.audit.Record(ctx, actor, "question.delete", model.AuditTargetQuestion, id, question, nil)
 	return res, err
 }
 
-func (s *questionService) Update(ctx context.Context, data model.Question) (int, error) {
+// fromRequest returns the question the request creates, for checks before it is saved.
+func fromRequest(data dto.CreateNewQuestionRequest) model.Question {
+	return model.Question{
+		Number:      data.Number,
+		Description: data.Description,
+		GameId:      data.GameId,
+		AnswerNum:   data.AnswerNum,
+		AnswerText:  data.AnswerText,
+		Cost:        data.Cost,
+		Options:     data.Options,
+		RoundId:     data.RoundId,
+	}
+}
+
+// checkRound returns ErrRoundNotInGame unless the round is one of the game's rounds.
+func (s *questionService) checkRound(ctx context.Context, actor model.Actor, gameId int, roundId *int) error {
+	if roundId == nil {
+		return nil
+	}
+	round, err := s.storage.RoundLoad(ctx, actor.OrgId, *roundId)
+	if errors.Is(err, pgx.ErrNoRows) || err == nil && round.GameId != gameId {
+		return fmt.Errorf("%w: round %d, game %d", ErrRoundNotInGame, *roundId, gameId)
+	}
+	if err != nil {
+		log.Println("question svc check round err:", err)
+		return err
+	}
+	return nil
+}
+
+// Trash lists the deleted questions of the game.
+func (s *questionService) Trash(ctx context.Context, actor model.Actor, gameId int) ([]model.DeletedQuestion, error) {
+	err := access.Require(ctx, s.storage, actor, gameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("question svc trash access err:", err)
+		return nil, err
+	}
+	res, err := s.storage.DeletedQuestions(ctx, gameId)
+	if err != nil {
+		log.Println("question svc trash err:", err)
+		return res, err
+	}
+	return res, nil
+}
+
+// Restore takes the question out of the trash. The question keeps its number, restoring it
+// into a published game fails when another question took the number meanwhile.
+func (s *questionService) Restore(ctx context.Context, actor model.Actor, id int) (int, error) {
+	question, err := s.storage.DeletedQuestionLoad(ctx, actor.OrgId, id)
+	if err != nil {
+		log.Println("question svc restore load err:", err)
+		return 0, err
+	}
+	err = access.Require(ctx, s.storage, actor, question.GameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("question svc restore access err:", err)
+		return 0, err
+	}
+	err = publish.Check(ctx, s.storage, actor.OrgId, question.GameId, publish.Adding(question))
+	if err != nil {
+		log.Println("question svc restore publish check err:", err)
+		return 0, err
+	}
+	res, err := s.storage.RestoreQuestion(ctx, id)
+	if err != nil {
+		log.Println("question svc restore err:", err)
+		return res, err
+	}
+	s.audit.Record(ctx, actor, "question.restore", model.AuditTargetQuestion, id, nil, question)
+	return res, nil
+}
+
+// Update saves the question. The actor has to be able to edit both the game
+// the question currently belongs to and the game it is moved to. Published games
+// have to stay publishable, see publish.Check.
+func (s *questionService) Update(ctx context.Context, actor model.Actor, data model.Question) (int, error) {
+	current, err := s.storage.QuestionLoad(ctx, actor.OrgId, data.Id)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	err = access.Require(ctx, s.storage, actor, current.GameId, model.PermissionEdit)
+	if err != nil {
+		log.Println("question svc update access err:", err)
+		return 0, err
+	}
+	if data.GameId != current.GameId {
+		err = access.Require(ctx, s.storage, actor, data.GameId, model.PermissionEdit)
+		if err != nil {
+			log.Println("question svc update access err:", err)
+			return 0, err
+		}
+	}
+	err = s.checkRound(ctx, actor, data.GameId, data.RoundId)
+	if err != nil {
+		return 0, err
+	}
+	if data.GameId == current.GameId {
+		err = publish.Check(ctx, s.storage, actor.OrgId, data.GameId, publish.Replacing(data))
+	} else {
+		err = publish.Check(ctx, s.storage, actor.OrgId, current.GameId, publish.Removing(data.Id))
+		if err == nil {
+			err = publish.Check(ctx, s.storage, actor.OrgId, data.GameId, publish.Adding(data))
+		}
+	}
+	if err != nil {
+		log.Println("question svc update publish check err:", err)
+		return 0, err
+	}
 	id, err := s.storage.UpdateQuestion(ctx, data)
 	if err != nil {
 		log.Println(err)
 		return id, err
 	}
+	s.audit.Record(ctx, actor, "question.update", model.AuditTargetQuestion, data.Id, current, data)
 	return id, err
 }
+
+// Search runs a full-text search over the questions of the games the actor can see,
+// admins search the whole organization.
+func (s *questionService) Search(ctx context.Context, actor model.Actor, filter dto.QuestionSearchFilter) ([]model.QuestionSearchResult, error) {
+	filter.OrgId = actor.OrgId
+	filter.MemberId = 0
+	if !actor.IsAdmin() {
+		filter.MemberId = actor.Id
+	}
+	if filter.GameId != 0 {
+		err := access.Require(ctx, s.storage, actor, filter.GameId, model.PermissionView)
+		if err != nil {
+			log.Println("question svc search access err:", err)
+			return nil, err
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchSize
+	}
+	if filter.Limit > maxSearchSize {
+		filter.Limit = maxSearchSize
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	res, err := s.storage.SearchQuestions(ctx, filter)
+	if err != nil {
+		log.Println("question svc search err:", err)
+		return res, err
+	}
+	return res, nil
+}
@@ -0,0 +1,123 @@
+package question
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"quizer_server/internal/model"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// orderQuestions puts the questions in the order of their numbers in order,
+// questions missing from the order follow in their own order.
+func orderQuestions(questions []model.Question, order []int) []model.Question {
+	if len(order) == 0 {
+		return questions
+	}
+	position := make(map[int]int, len(order))
+	for i, number := range order {
+		position[number] = i
+	}
+	res := slices.Clone(questions)
+	slices.SortStableFunc(res, func(a, b model.Question) int {
+		pa, okA := position[a.Number]
+		pb, okB := position[b.Number]
+		switch {
+		case okA && okB:
+			return pa - pb
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	})
+	return res
+}
+
+// shuffleOptions returns a copy of the question with its options in the order.
+func shuffleOptions(q model.Question, order model.OptionOrder) model.Question {
+	if len(order.Order) != len(q.Options) {
+		return q
+	}
+	options := make([]string, len(q.Options))
+	for i, n := range order.Order {
+		options[i] = q.Options[n-1]
+	}
+	q.Options = options
+	return q
+}
+
+// ForPlayer returns the questions the way the player sees them in the lobby: without their answers
+// and, in lobbies shuffling options, with the player's own option order. The order is stored the
+// first time the player gets a question, so that rejoining shows the same order and answers can be
+// graded against the original options. Options keep their order when it can not be stored.
+func (s *questionService) ForPlayer(ctx context.Context, lobbyUUID uuid.UUID, playerUUID uuid.UUID, questions []model.Question) []model.Question {
+	orders, err := s.optionOrders(ctx, lobbyUUID, playerUUID, questions)
+	if err != nil {
+		log.Println("question svc for player err:", err)
+	}
+	res := make([]model.Question, 0, len(questions))
+	for _, q := range questions {
+		if order, ok := orders[q.Id]; ok {
+			q = shuffleOptions(q, order)
+		}
+		q.AnswerNum = 0
+		q.AnswerText = ""
+		res = append(res, q)
+	}
+	return res
+}
+
+// optionOrders returns the option orders of the player by question id, creating the missing ones.
+// Lobbies that do not shuffle options have none.
+func (s *questionService) optionOrders(ctx context.Context, lobbyUUID uuid.UUID, playerUUID uuid.UUID, questions []model.Question) (map[int]model.OptionOrder, error) {
+	lobby, err := s.storage.LobbyLoadByUUID(ctx, lobbyUUID)
+	if err != nil || !lobby.ShuffleOptions {
+		return nil, err
+	}
+
+	stored, err := s.storage.OptionOrders(ctx, lobbyUUID, playerUUID)
+	if err != nil {
+		return nil, err
+	}
+	orders := make(map[int]model.OptionOrder, len(stored))
+	for _, o := range stored {
+		orders[o.QuestionId] = o
+	}
+
+	missing := []model.OptionOrder{}
+	for _, q := range questions {
+		if _, ok := orders[q.Id]; ok || len(q.Options) < 2 {
+			continue
+		}
+		order := model.OptionOrder{
+			LobbyUUID:  lobbyUUID,
+			PlayerUUID: playerUUID,
+			QuestionId: q.Id,
+			Order:      make([]int, len(q.Options)),
+		}
+		for i, n := range rand.Perm(len(q.Options)) {
+			order.Order[i] = n + 1
+		}
+		missing = append(missing, order)
+	}
+	if len(missing) == 0 {
+		return orders, nil
+	}
+	err = s.storage.SaveOptionOrders(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	// another connection of the player may have stored its orders first
+	stored, err = s.storage.OptionOrders(ctx, lobbyUUID, playerUUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range stored {
+		orders[o.QuestionId] = o
+	}
+	return orders, nil
+}
@@ -0,0 +1,94 @@
+package question
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"quizer_server/internal/model"
+	"slices"
+	"testing"
+)
+
+// TestShuffleRoundTrip plays seeded question and option orders and checks that every answer
+// a player gives in the shuffled order is graded against the option they saw.
+func TestShuffleRoundTrip(t *testing.T) {
+	for seed := uint64(1); seed <= 20; seed++ {
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			r := rand.New(rand.NewPCG(seed, seed))
+
+			questions := make([]model.Question, 2+r.IntN(8))
+			for i := range questions {
+				q := model.Question{Id: 100 + i, Number: i + 1, Options: make([]string, r.IntN(6))}
+				for j := range q.Options {
+					q.Options[j] = fmt.Sprintf("q%d option %d", q.Number, j+1)
+				}
+				if len(q.Options) > 0 {
+					q.AnswerNum = 1 + r.IntN(len(q.Options))
+				}
+				questions[i] = q
+			}
+			original := make([]model.Question, len(questions))
+			for i, q := range questions {
+				q.Options = slices.Clone(q.Options)
+				original[i] = q
+			}
+
+			// the lobby stores question numbers in the order they are played
+			order := make([]int, len(questions))
+			for i, n := range r.Perm(len(questions)) {
+				order[i] = n + 1
+			}
+			played := orderQuestions(questions, order)
+			if len(played) != len(questions) {
+				t.Fatalf("played %d questions, want %d", len(played), len(questions))
+			}
+
+			for i, q := range played {
+				if want := model.QuestionNumber(order, i+1); q.Number != want {
+					t.Fatalf("question %d at position %d, want %d", q.Number, i+1, want)
+				}
+
+				// option orders are created like in optionOrders
+				optionOrder := model.OptionOrder{QuestionId: q.Id, Order: make([]int, len(q.Options))}
+				for j, n := range r.Perm(len(q.Options)) {
+					optionOrder.Order[j] = n + 1
+				}
+				shown := shuffleOptions(q, optionOrder)
+				if len(shown.Options) != len(q.Options) {
+					t.Fatalf("question %d shows %d options, want %d", q.Number, len(shown.Options), len(q.Options))
+				}
+
+				correct := 0
+				for answer, text := range shown.Options {
+					n := optionOrder.Original(answer + 1)
+					if n < 1 || n > len(q.Options) || q.Options[n-1] != text {
+						t.Fatalf("question %d: shown option %d %q maps back to %d", q.Number, answer+1, text, n)
+					}
+					if n == q.AnswerNum {
+						correct++
+					}
+				}
+				if len(q.Options) > 0 && correct != 1 {
+					t.Fatalf("question %d: %d shown options grade as correct, want 1", q.Number, correct)
+				}
+			}
+
+			for i := range questions {
+				if questions[i].Number != original[i].Number || !slices.Equal(questions[i].Options, original[i].Options) {
+					t.Fatalf("shuffling changed question %+v, was %+v", questions[i], original[i])
+				}
+			}
+		})
+	}
+}
+
+func TestShuffleOptionsKeepsMismatchedOrder(t *testing.T) {
+	q := model.Question{Options: []string{"a", "b", "c"}}
+	got := shuffleOptions(q, model.OptionOrder{Order: []int{2, 1}})
+	if !slices.Equal(got.Options, q.Options) {
+		t.Fatalf("options %v, want %v", got.Options, q.Options)
+	}
+	// answers outside of the order are graded as they are
+	if n := (model.OptionOrder{Order: []int{2, 1}}).Original(3); n != 3 {
+		t.Fatalf("original of 3 is %d, want 3", n)
+	}
+}
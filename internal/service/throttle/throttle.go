@@ -0,0 +1,119 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"log"
+	"quizer_server/internal/config"
+	"quizer_server/internal/db"
+	"quizer_server/internal/model"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LockedError is returned when the login or the client IP is temporarily locked out.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return "too many failed login attempts"
+}
+
+type Service interface {
+	Check(ctx context.Context, login string, ip string) error
+	Fail(ctx context.Context, login string, ip string)
+	Reset(ctx context.Context, login string)
+}
+
+type throttleService struct {
+	storage db.Storage
+	cfg     *config.Config
+}
+
+// New creates a login throttling service. Failed attempts are counted per login
+// and per client IP and stored in the database, so lockouts survive restarts.
+func New(s db.Storage) Service {
+	return &throttleService{
+		storage: s,
+		cfg:     config.GetConfig(),
+	}
+}
+
+// Check returns a *LockedError if either the login or the IP is currently locked.
+func (ts *throttleService) Check(ctx context.Context, login string, ip string) error {
+	now := time.Now()
+	for _, k := range ts.keys(login, ip) {
+		attempt, err := ts.storage.LoginAttempt(ctx, k.scope, k.key)
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				log.Println("throttle svc check err:", err)
+			}
+			continue
+		}
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			return &LockedError{RetryAfter: attempt.LockedUntil.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// Fail registers a failed attempt and locks the login or IP once the limit is reached.
+// Every further failure doubles the lockout up to the configured maximum.
+func (ts *throttleService) Fail(ctx context.Context, login string, ip string) {
+	for _, k := range ts.keys(login, ip) {
+		attempt, err := ts.storage.RegisterLoginFailure(ctx, k.scope, k.key, ts.cfg.Login.FailureWindow)
+		if err != nil {
+			log.Println("throttle svc fail err:", err)
+			continue
+		}
+		if attempt.Failures < k.limit {
+			continue
+		}
+		lockout := ts.lockout(attempt.Failures - k.limit)
+		log.Println("throttle svc: locking", k.scope, "for", lockout)
+		err = ts.storage.LockLogin(ctx, k.scope, k.key, time.Now().Add(lockout))
+		if err != nil {
+			log.Println("throttle svc lock err:", err)
+		}
+	}
+}
+
+// Reset clears the failure counter of the login after a successful authentication.
+// The IP counter is kept so that one valid account can not be used to reset it.
+func (ts *throttleService) Reset(ctx context.Context, login string) {
+	err := ts.storage.ResetLoginAttempts(ctx, model.LoginScopeLogin, normalizeLogin(login))
+	if err != nil {
+		log.Println("throttle svc reset err:", err)
+	}
+}
+
+func (ts *throttleService) lockout(exceeded int) time.Duration {
+	lockout := ts.cfg.Login.BaseLockout
+	for i := 0; i < exceeded && lockout < ts.cfg.Login.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > ts.cfg.Login.MaxLockout {
+		lockout = ts.cfg.Login.MaxLockout
+	}
+	return lockout
+}
+
+type throttleKey struct {
+	scope string
+	key   string
+	limit int
+}
+
+func (ts *throttleService) keys(login string, ip string) []throttleKey {
+	return []throttleKey{
+		{scope: model.LoginScopeLogin, key: normalizeLogin(login), limit: ts.cfg.Login.MaxAttempts},
+		{scope: model.LoginScopeIP, key: ip, limit: ts.cfg.Login.IPMaxAttempts},
+	}
+}
+
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
@@ -0,0 +1,37 @@
+package user
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when the user does not exist, so that the
+// response time does not reveal whether a login is registered.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// hashPassword returns a bcrypt hash of the given plaintext password.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// isHashed reports whether the stored password is already a bcrypt hash.
+// Rows seeded before hashing was introduced still hold plaintext values.
+func isHashed(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") ||
+		strings.HasPrefix(stored, "$2b$") ||
+		strings.HasPrefix(stored, "$2y$")
+}
+
+// checkPassword compares the plaintext password with the stored value,
+// which may be either a bcrypt hash or a legacy plaintext password.
+func checkPassword(stored, password string) bool {
+	if !isHashed(stored) {
+		return stored != "" && stored == password
+	}
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+}
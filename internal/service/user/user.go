@@ -2,22 +2,50 @@ package user
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"quizer_server/internal/config"
 	"quizer_server/internal/db"
+	"quizer_server/internal/dto"
 	"quizer_server/internal/model"
+	"quizer_server/internal/service/audit"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid login or password")
+	ErrValidation         = errors.New("validation failed")
+	ErrLoginTaken         = errors.New("login is already taken")
+	ErrHasGames           = errors.New("user still owns games")
 )
 
 type Service interface {
 	UserByLogin(ctx context.Context, login string) (model.User, error)
+	UserById(ctx context.Context, id int) (model.User, error)
+	Authenticate(ctx context.Context, login string, password string) (model.User, error)
+
+	Register(ctx context.Context, actor model.Actor, req dto.CreateUserRequest) (int, error)
+	LoginExternal(ctx context.Context, actor model.Actor, identity dto.ExternalIdentity) (model.User, error)
+	UpdateProfile(ctx context.Context, actor model.Actor, req dto.UpdateProfileRequest) (int, error)
+	ChangePassword(ctx context.Context, actor model.Actor, req dto.ChangePasswordRequest) error
+	Delete(ctx context.Context, actor model.Actor, password string) (int, error)
+	SetRoles(ctx context.Context, actor model.Actor, userId int, roles []string) (int, error)
 }
 
 type userService struct {
 	storage db.Storage
+	audit   audit.Service
+	cfg     *config.Config
 }
 
-func New(s db.Storage) Service {
+func New(s db.Storage, a audit.Service) Service {
 	return &userService{
 		storage: s,
+		audit:   a,
+		cfg:     config.GetConfig(),
 	}
 }
 
@@ -29,3 +57,271 @@ func (s *userService) UserByLogin(ctx context.Context, login string) (model.User
 	}
 	return user, nil
 }
+
+func (s *userService) UserById(ctx context.Context, id int) (model.User, error) {
+	user, err := s.storage.UserById(ctx, id)
+	if err != nil {
+		log.Println("user svc load by id err:", err)
+		return user, err
+	}
+	return user, nil
+}
+
+// Authenticate checks the user's credentials against the stored password.
+// Users that still have a plaintext password are transparently upgraded
+// to a bcrypt hash after a successful login.
+func (s *userService) Authenticate(ctx context.Context, login string, password string) (model.User, error) {
+	user, err := s.storage.UserByLogin(ctx, login)
+	if err != nil {
+		log.Println("user svc authenticate load user err:", err)
+		checkPassword(string(dummyHash), password)
+		return model.User{}, ErrInvalidCredentials
+	}
+
+	if !checkPassword(user.Password, password) {
+		return model.User{}, ErrInvalidCredentials
+	}
+
+	if !isHashed(user.Password) {
+		hash, err := hashPassword(password)
+		if err != nil {
+			log.Println("user svc authenticate hash password err:", err)
+			return user, nil
+		}
+		err = s.storage.UpdatePassword(ctx, user.Id, hash)
+		if err != nil {
+			log.Println("user svc authenticate upgrade password err:", err)
+			return user, nil
+		}
+		user.Password = hash
+	}
+
+	return user, nil
+}
+
+// Register validates the request and creates a new user with a hashed password.
+// The actor is anonymous, the new user is recorded as the author of the audit record.
+func (s *userService) Register(ctx context.Context, actor model.Actor, req dto.CreateUserRequest) (int, error) {
+	req.Login = strings.TrimSpace(req.Login)
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	req.Email = strings.TrimSpace(req.Email)
+
+	err := validateLogin(req.Login)
+	if err != nil {
+		return 0, err
+	}
+	err = validatePassword(req.Password)
+	if err != nil {
+		return 0, err
+	}
+	err = validateProfile(req.DisplayName, req.Email)
+	if err != nil {
+		return 0, err
+	}
+
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		log.Println("user svc register hash password err:", err)
+		return 0, err
+	}
+
+	id, err := s.storage.CreateUser(ctx, dto.CreateUser{
+		Login:       req.Login,
+		Password:    hash,
+		DisplayName: req.DisplayName,
+		Email:       req.Email,
+		Roles:       s.cfg.Users.DefaultRoles,
+	})
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			return 0, ErrLoginTaken
+		}
+		log.Println("user svc register err:", err)
+		return 0, err
+	}
+	s.joinDefaultOrganization(ctx, id)
+
+	actor.Id = id
+	after, _ := s.storage.UserById(ctx, id)
+	s.audit.Record(ctx, actor, "user.register", model.AuditTargetUser, id, nil, after)
+	return id, nil
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, actor model.Actor, req dto.UpdateProfileRequest) (int, error) {
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	req.Email = strings.TrimSpace(req.Email)
+
+	err := validateProfile(req.DisplayName, req.Email)
+	if err != nil {
+		return 0, err
+	}
+
+	before, _ := s.storage.UserById(ctx, actor.Id)
+	id, err := s.storage.UpdateProfile(ctx, actor.Id, req)
+	if err != nil {
+		log.Println("user svc update profile err:", err)
+		return id, err
+	}
+	after, _ := s.storage.UserById(ctx, actor.Id)
+	s.audit.Record(ctx, actor, "user.update_profile", model.AuditTargetUser, actor.Id, before, after)
+	return id, nil
+}
+
+// ChangePassword replaces the user's password after verifying the current one.
+func (s *userService) ChangePassword(ctx context.Context, actor model.Actor, req dto.ChangePasswordRequest) error {
+	user, err := s.storage.UserById(ctx, actor.Id)
+	if err != nil {
+		log.Println("user svc change password load user err:", err)
+		return err
+	}
+
+	if !checkPassword(user.Password, req.CurrentPassword) {
+		return ErrInvalidCredentials
+	}
+
+	err = validatePassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(req.NewPassword)
+	if err != nil {
+		log.Println("user svc change password hash err:", err)
+		return err
+	}
+
+	err = s.storage.UpdatePassword(ctx, actor.Id, hash)
+	if err != nil {
+		log.Println("user svc change password err:", err)
+		return err
+	}
+	s.audit.Record(ctx, actor, "user.change_password", model.AuditTargetUser, actor.Id, nil, nil)
+	return nil
+}
+
+// Delete removes the user's account after verifying the password.
+// Accounts that still own games can not be deleted.
+func (s *userService) Delete(ctx context.Context, actor model.Actor, password string) (int, error) {
+	user, err := s.storage.UserById(ctx, actor.Id)
+	if err != nil {
+		log.Println("user svc delete load user err:", err)
+		return 0, err
+	}
+
+	if !checkPassword(user.Password, password) {
+		return 0, ErrInvalidCredentials
+	}
+
+	count, err := s.storage.GamesCountByOwner(ctx, actor.Id)
+	if err != nil {
+		log.Println("user svc delete count games err:", err)
+		return 0, err
+	}
+	if count > 0 {
+		return 0, ErrHasGames
+	}
+
+	id, err := s.storage.DeleteUser(ctx, actor.Id)
+	if err != nil {
+		log.Println("user svc delete err:", err)
+		return id, err
+	}
+	s.audit.Record(ctx, actor, "user.delete", model.AuditTargetUser, actor.Id, user, nil)
+	return id, nil
+}
+
+// SetRoles replaces the user's roles. Only known roles are accepted.
+func (s *userService) SetRoles(ctx context.Context, actor model.Actor, userId int, roles []string) (int, error) {
+	err := validateRoles(roles)
+	if err != nil {
+		return 0, err
+	}
+
+	before, _ := s.storage.UserById(ctx, userId)
+	id, err := s.storage.UpdateRoles(ctx, userId, roles)
+	if err != nil {
+		log.Println("user svc set roles err:", err)
+		return id, err
+	}
+	s.audit.Record(ctx, actor, "user.set_roles", model.AuditTargetUser, userId, before.Roles, roles)
+	return id, nil
+}
+
+// joinDefaultOrganization adds a new user to the organization configured with DEFAULT_ORGANIZATION.
+// Users without an organization are added later by an organization admin.
+func (s *userService) joinDefaultOrganization(ctx context.Context, userId int) {
+	if s.cfg.Users.DefaultOrg == "" {
+		return
+	}
+	org, err := s.storage.OrganizationByName(ctx, s.cfg.Users.DefaultOrg)
+	if err != nil {
+		log.Println("user svc join default organization load err:", err)
+		return
+	}
+	err = s.storage.SaveOrganizationMember(ctx, org.Id, userId, model.OrgRoleMember)
+	if err != nil {
+		log.Println("user svc join default organization err:", err)
+	}
+}
+
+// LoginExternal returns the user linked to an identity of an external provider.
+// On the first login a new user without a local password is created and linked to the identity.
+func (s *userService) LoginExternal(ctx context.Context, actor model.Actor, identity dto.ExternalIdentity) (model.User, error) {
+	user, err := s.storage.UserByIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		log.Println("user svc login external load user err:", err)
+		return user, err
+	}
+
+	email := strings.TrimSpace(identity.Email)
+	displayName := strings.TrimSpace(identity.Name)
+	if validateProfile(displayName, email) != nil {
+		email = ""
+		displayName = ""
+	}
+
+	base := externalLogin(identity)
+	for i := 1; i <= maxLoginSuffix; i++ {
+		login := base
+		if i > 1 {
+			login = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		// an empty password can never match, so the user can only sign in through the provider
+		id, err := s.storage.CreateUserWithIdentity(ctx, dto.CreateUser{
+			Login:       login,
+			DisplayName: displayName,
+			Email:       email,
+			Roles:       s.cfg.Users.DefaultRoles,
+		}, identity.Issuer, identity.Subject)
+		if db.IsUniqueViolation(err) {
+			// either the login is taken or a concurrent login has just linked the identity
+			user, lerr := s.storage.UserByIdentity(ctx, identity.Issuer, identity.Subject)
+			if lerr == nil {
+				return user, nil
+			}
+			continue
+		}
+		if err != nil {
+			log.Println("user svc login external create user err:", err)
+			return model.User{}, err
+		}
+
+		s.joinDefaultOrganization(ctx, id)
+
+		user, err = s.storage.UserById(ctx, id)
+		if err != nil {
+			log.Println("user svc login external load user err:", err)
+			return user, err
+		}
+		actor.Id = id
+		s.audit.Record(ctx, actor, "user.register_external", model.AuditTargetUser, id, nil,
+			map[string]any{"user": user, "issuer": identity.Issuer, "subject": identity.Subject})
+		return user, nil
+	}
+
+	return model.User{}, fmt.Errorf("%w: no free login for %q", ErrLoginTaken, base)
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"fmt"
+	"net/mail"
+	"quizer_server/internal/dto"
+	"quizer_server/internal/model"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	minPasswordLen    = 8
+	maxPasswordLen    = 72 // bcrypt ignores everything past 72 bytes
+	maxDisplayNameLen = 64
+)
+
+var loginRe = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
+func validateLogin(login string) error {
+	if !loginRe.MatchString(login) {
+		return fmt.Errorf("%w: login must be 3-32 characters of latin letters, digits, '_', '.' or '-'", ErrValidation)
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLen {
+		return fmt.Errorf("%w: password must be at least %d characters long", ErrValidation, minPasswordLen)
+	}
+	if len(password) > maxPasswordLen {
+		return fmt.Errorf("%w: password must be at most %d bytes long", ErrValidation, maxPasswordLen)
+	}
+	return nil
+}
+
+func validateProfile(displayName, email string) error {
+	if utf8.RuneCountInString(displayName) > maxDisplayNameLen {
+		return fmt.Errorf("%w: display name must be at most %d characters long", ErrValidation, maxDisplayNameLen)
+	}
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			return fmt.Errorf("%w: email is invalid", ErrValidation)
+		}
+	}
+	return nil
+}
+
+func validateRoles(roles []string) error {
+	for _, r := range roles {
+		switch r {
+		case model.RoleAdmin, model.RoleAuthor, model.RoleHost:
+		default:
+			return fmt.Errorf("%w: unknown role %q", ErrValidation, r)
+		}
+	}
+	return nil
+}
+
+// maxLoginSuffix limits the number of "-N" suffixes tried for a login taken by another user.
+const maxLoginSuffix = 20
+
+var loginCharsRe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// externalLogin derives a valid login from the identity's preferred username or email,
+// leaving room for a numeric suffix.
+func externalLogin(identity dto.ExternalIdentity) string {
+	candidate := identity.PreferredUsername
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(identity.Email, "@")
+	}
+	candidate = loginCharsRe.ReplaceAllString(candidate, "")
+	if len(candidate) > 28 {
+		candidate = candidate[:28]
+	}
+	if len(candidate) < 3 {
+		candidate = "user"
+	}
+	return candidate
+}
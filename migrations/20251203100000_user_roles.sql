@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN roles TEXT[] NOT NULL DEFAULT '{author,host}';
+
+UPDATE users SET roles = '{admin,author,host}' WHERE login = 'admin';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS roles;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE organizations (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE organization_members (
+    org_id INTEGER NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'admin')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE INDEX organization_members_user_idx ON organization_members (user_id);
+
+-- existing content and users are moved to the default organization
+INSERT INTO organizations (name) VALUES ('default');
+
+INSERT INTO organization_members (org_id, user_id, role)
+SELECT o.id, u.id, CASE WHEN 'admin' = ANY (u.roles) THEN 'admin' ELSE 'member' END
+FROM users u, organizations o
+WHERE o.name = 'default';
+
+ALTER TABLE games ADD COLUMN org_id INTEGER REFERENCES organizations (id);
+UPDATE games SET org_id = (SELECT id FROM organizations WHERE name = 'default');
+ALTER TABLE games ALTER COLUMN org_id SET NOT NULL;
+
+ALTER TABLE lobbies ADD COLUMN org_id INTEGER REFERENCES organizations (id);
+UPDATE lobbies SET org_id = (SELECT id FROM organizations WHERE name = 'default');
+ALTER TABLE lobbies ALTER COLUMN org_id SET NOT NULL;
+
+CREATE INDEX games_org_idx ON games (org_id);
+CREATE INDEX lobbies_org_idx ON lobbies (org_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE lobbies DROP COLUMN IF EXISTS org_id;
+ALTER TABLE games DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE players ADD COLUMN user_id INTEGER REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE lobbies ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX players_user_idx ON players (user_id);
+CREATE INDEX player_results_lobby_player_idx ON player_results (lobby_uuid, player_uuid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS player_results_lobby_player_idx;
+ALTER TABLE lobbies DROP COLUMN IF EXISTS created_at;
+ALTER TABLE players DROP COLUMN IF EXISTS user_id;
+-- +goose StatementEnd
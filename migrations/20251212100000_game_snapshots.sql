@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- snapshots outlive the game so that finished lobbies can still be regraded
+CREATE TABLE game_snapshots (
+    id SERIAL PRIMARY KEY,
+    game_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    link TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (game_id, version)
+);
+
+CREATE TABLE snapshot_questions (
+    snapshot_id INTEGER NOT NULL REFERENCES game_snapshots (id) ON DELETE CASCADE,
+    question_id INTEGER NOT NULL,
+    number INTEGER NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    answer INTEGER NOT NULL DEFAULT 0,
+    answer_text TEXT NOT NULL DEFAULT '',
+    cost INTEGER NOT NULL DEFAULT 0,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (snapshot_id, question_id)
+);
+
+CREATE INDEX snapshot_questions_number_idx ON snapshot_questions (snapshot_id, number);
+
+ALTER TABLE lobbies ADD COLUMN snapshot_id INTEGER REFERENCES game_snapshots (id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE lobbies DROP COLUMN IF EXISTS snapshot_id;
+DROP TABLE IF EXISTS snapshot_questions;
+DROP TABLE IF EXISTS game_snapshots;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE games ADD COLUMN status TEXT NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'published', 'archived'));
+
+-- games created before the lifecycle existed are already being played
+UPDATE games SET status = 'published';
+
+CREATE INDEX games_org_status_idx ON games (org_id, status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS games_org_status_idx;
+ALTER TABLE games DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE games ADD COLUMN category TEXT NOT NULL DEFAULT '';
+ALTER TABLE games ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- the russian configuration stems latin words with the english stemmer, so mixed texts work too
+ALTER TABLE games ADD COLUMN search tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', COALESCE(description, ''))) STORED;
+ALTER TABLE questions ADD COLUMN search tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', COALESCE(description, ''))) STORED;
+
+CREATE INDEX games_search_idx ON games USING GIN (search);
+CREATE INDEX questions_search_idx ON questions USING GIN (search);
+CREATE INDEX games_tags_idx ON games USING GIN (tags);
+CREATE INDEX games_org_category_idx ON games (org_id, category);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS games_org_category_idx;
+DROP INDEX IF EXISTS games_tags_idx;
+DROP INDEX IF EXISTS questions_search_idx;
+DROP INDEX IF EXISTS games_search_idx;
+ALTER TABLE questions DROP COLUMN IF EXISTS search;
+ALTER TABLE games DROP COLUMN IF EXISTS search;
+ALTER TABLE games DROP COLUMN IF EXISTS tags;
+ALTER TABLE games DROP COLUMN IF EXISTS category;
+-- +goose StatementEnd
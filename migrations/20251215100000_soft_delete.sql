@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE games ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE questions ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX games_deleted_at_idx ON games (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX questions_deleted_at_idx ON questions (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX lobbies_game_id_idx ON lobbies (game_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS lobbies_game_id_idx;
+DROP INDEX IF EXISTS questions_deleted_at_idx;
+DROP INDEX IF EXISTS games_deleted_at_idx;
+ALTER TABLE questions DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE games DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
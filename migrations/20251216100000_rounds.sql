@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE rounds (
+    id SERIAL PRIMARY KEY,
+    game_id INTEGER NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    multiplier DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (multiplier > 0),
+    intermission_text TEXT NOT NULL DEFAULT '',
+    intermission_slide INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX rounds_game_idx ON rounds (game_id, position);
+
+ALTER TABLE questions ADD COLUMN round_id INTEGER REFERENCES rounds (id) ON DELETE SET NULL;
+
+CREATE TABLE snapshot_rounds (
+    snapshot_id INTEGER NOT NULL REFERENCES game_snapshots (id) ON DELETE CASCADE,
+    round_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    multiplier DOUBLE PRECISION NOT NULL DEFAULT 1,
+    intermission_text TEXT NOT NULL DEFAULT '',
+    intermission_slide INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (snapshot_id, round_id)
+);
+
+ALTER TABLE snapshot_questions ADD COLUMN round_id INTEGER;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE snapshot_questions DROP COLUMN IF EXISTS round_id;
+DROP TABLE IF EXISTS snapshot_rounds;
+ALTER TABLE questions DROP COLUMN IF EXISTS round_id;
+DROP TABLE IF EXISTS rounds;
+-- +goose StatementEnd
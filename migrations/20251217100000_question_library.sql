@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE library_questions (
+    id SERIAL PRIMARY KEY,
+    org_id INTEGER NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    owner_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'organization')),
+    description TEXT NOT NULL DEFAULT '',
+    answer INTEGER NOT NULL DEFAULT 0,
+    answer_text TEXT NOT NULL DEFAULT '',
+    cost INTEGER NOT NULL DEFAULT 0,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    search tsvector GENERATED ALWAYS AS (to_tsvector('russian', description)) STORED
+);
+
+CREATE INDEX library_questions_org_idx ON library_questions (org_id, owner_id);
+CREATE INDEX library_questions_search_idx ON library_questions USING GIN (search);
+
+-- questions that are not forked follow every change of their library question
+ALTER TABLE questions ADD COLUMN library_question_id INTEGER REFERENCES library_questions (id) ON DELETE SET NULL;
+ALTER TABLE questions ADD COLUMN forked BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX questions_library_idx ON questions (library_question_id) WHERE library_question_id IS NOT NULL;
+
+-- snapshots remember the library question so that results stay attributed to it
+ALTER TABLE snapshot_questions ADD COLUMN library_question_id INTEGER;
+CREATE INDEX snapshot_questions_library_idx ON snapshot_questions (library_question_id) WHERE library_question_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS snapshot_questions_library_idx;
+ALTER TABLE snapshot_questions DROP COLUMN IF EXISTS library_question_id;
+DROP INDEX IF EXISTS questions_library_idx;
+ALTER TABLE questions DROP COLUMN IF EXISTS forked;
+ALTER TABLE questions DROP COLUMN IF EXISTS library_question_id;
+DROP TABLE IF EXISTS library_questions;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE lobbies ADD COLUMN shuffle_questions BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE lobbies ADD COLUMN shuffle_options BOOLEAN NOT NULL DEFAULT false;
+-- question numbers in the order the lobby plays them, set when a shuffled lobby starts
+ALTER TABLE lobbies ADD COLUMN question_order INTEGER[];
+
+-- option_order[i] is the original number of the option the player sees at position i
+CREATE TABLE player_option_orders (
+    lobby_uuid UUID NOT NULL REFERENCES lobbies (uuid) ON DELETE CASCADE,
+    player_uuid UUID NOT NULL,
+    question_id INTEGER NOT NULL,
+    option_order INTEGER[] NOT NULL,
+    PRIMARY KEY (lobby_uuid, player_uuid, question_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS player_option_orders;
+ALTER TABLE lobbies DROP COLUMN IF EXISTS question_order;
+ALTER TABLE lobbies DROP COLUMN IF EXISTS shuffle_options;
+ALTER TABLE lobbies DROP COLUMN IF EXISTS shuffle_questions;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- rounds sharing a position keep their order and are moved apart
+UPDATE rounds r
+SET position = n.position
+FROM (
+    SELECT
+        id,
+        ROW_NUMBER() OVER (PARTITION BY game_id ORDER BY position, id) AS position
+    FROM rounds
+    WHERE game_id IN (
+        SELECT game_id FROM rounds GROUP BY game_id, position HAVING COUNT(*) > 1
+    )
+) n
+WHERE r.id = n.id;
+
+DROP INDEX IF EXISTS rounds_game_idx;
+ALTER TABLE rounds ADD CONSTRAINT rounds_game_position_key UNIQUE (game_id, position);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_game_position_key;
+CREATE INDEX rounds_game_idx ON rounds (game_id, position);
+-- +goose StatementEnd